@@ -21,7 +21,7 @@ func (app *application) healthcheckHandler(rw http.ResponseWriter, r *http.Reque
 
 	utils := app.registry.Services.SharedUtil
 
-	err := utils.WriteJson(rw, http.StatusOK, data, nil)
+	err := utils.WriteResponse(rw, r, http.StatusOK, data, nil)
 	if err != nil {
 		utils.ServerErrorResponse(rw, r, err)
 	}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/terdia/greenlight/src/movies/entities"
+)
+
+// importMovies runs the import subcommand, it imports a CSV or NDJSON file synchronously
+// using the same pipeline as POST /v1/movies/imports e.g.
+//
+//	api -dsn=$GREENLIGHT_DB_DSN import -dry-run ./movies.csv
+func (app *application) importMovies(args []string) error {
+
+	cmd := flag.NewFlagSet("import", flag.ExitOnError)
+	format := cmd.String("format", "", "File format (csv|ndjson), detected from the file extension by default")
+	dryRun := cmd.Bool("dry-run", false, "Validate the file without importing any movie")
+
+	cmd.Usage = func() {
+		fmt.Fprintf(cmd.Output(), "Usage: api [flags] import [-format=csv|ndjson] [-dry-run] <file>\n")
+		cmd.PrintDefaults()
+	}
+
+	cmd.Parse(args)
+
+	if cmd.NArg() != 1 {
+		cmd.Usage()
+		return errors.New("import: exactly one file must be provided")
+	}
+
+	path := cmd.Arg(0)
+
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			*format = entities.ImportFormatCSV
+		case ".ndjson", ".jsonl":
+			*format = entities.ImportFormatNDJSON
+		default:
+			return fmt.Errorf("import: unable to detect the format of %s, use -format", path)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	service := app.registry.Services.MovieService
+
+	job := service.CreateImportJob(*format, *dryRun)
+
+	// an interrupted import is rolled back
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = service.RunImport(ctx, job.ID, file)
+	if err != nil {
+		return err
+	}
+
+	job, err = service.GetImportJob(int64(job.ID))
+	if err != nil {
+		return err
+	}
+
+	for _, lineError := range job.Errors {
+		for field, message := range lineError.Errors {
+			fmt.Printf("line %d: %s %s\n", lineError.Line, field, message)
+		}
+	}
+
+	fmt.Printf("Status:\t\t%s\n", job.Status)
+	fmt.Printf("Processed:\t%d\n", job.Processed)
+	fmt.Printf("Inserted:\t%d\n", job.Inserted)
+	fmt.Printf("Invalid:\t%d\n", job.Invalid)
+
+	if job.Status == entities.ImportStatusFailed {
+		return errors.New(job.Message)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"expvar"
 	"flag"
 	"fmt"
@@ -14,7 +15,6 @@ import (
 
 	"github.com/terdia/greenlight/config"
 	"github.com/terdia/greenlight/infrastructures/logger"
-	"github.com/terdia/greenlight/infrastructures/persistence/postgres"
 	"github.com/terdia/greenlight/internal/mailer"
 	"github.com/terdia/greenlight/internal/registry"
 )
@@ -43,10 +43,12 @@ func main() {
 
 	flag.IntVar(&cfg.AppPort, "port", 4000, "Api server")
 	flag.StringVar(&cfg.Env, "env", "development", "Environment (development|staging|production)")
-	flag.StringVar(&cfg.Db.Dsn, "dsn", "xxxxxx", "PostgreSQL DSN")
-	flag.IntVar(&cfg.Db.MaxOpenConns, "db-max-open-conns", 25, "PostgreSQL max open connections")
-	flag.IntVar(&cfg.Db.MaxIdleConns, "db-max-idle-conns", 25, "PostgreSQL max idle connections")
-	flag.StringVar(&cfg.Db.MaxIdleTime, "db-max-idle-time", "15m", "PostgreSQL max connection idle time")
+	flag.StringVar(&cfg.Storage, "storage", registry.StorageDatabase, "Storage backend (db|memory), memory storage is lost on exit")
+	flag.StringVar(&cfg.Db.Dsn, "dsn", "xxxxxx", "PostgreSQL DSN, or SQLite DSN e.g. sqlite://greenlight.db")
+	flag.IntVar(&cfg.Db.MaxOpenConns, "db-max-open-conns", 25, "Database max open connections")
+	flag.IntVar(&cfg.Db.MaxIdleConns, "db-max-idle-conns", 25, "Database max idle connections")
+	flag.StringVar(&cfg.Db.MaxIdleTime, "db-max-idle-time", "15m", "Database max connection idle time")
+	flag.BoolVar(&cfg.Db.AutoMigrate, "auto-migrate", false, "Apply the pending database migrations on startup")
 
 	flag.Float64Var(&cfg.Limiter.Rps, "limiter-rps", 2, "Rate limiter maximum requests per second")
 	flag.IntVar(&cfg.Limiter.Burst, "limiter-burst", 4, "Rate limiter maximum burst")
@@ -62,6 +64,18 @@ func main() {
 		cfg.Cors.TrustedOrigins = strings.Fields(val)
 		return nil
 	})
+	flag.DurationVar(&cfg.Deadlines.Default, "deadline", 10*time.Second, "Request deadline, 0 disables it")
+
+	cfg.Deadlines.Routes = map[string]time.Duration{
+		// an export streams the whole catalogue, only its writes to the client are bounded
+		"GET /v1/movies/export": 0,
+		"POST /v1/movies/batch": 30 * time.Second,
+	}
+
+	flag.Func("route-deadlines", `Request deadline of routes (comma separated) e.g. "GET /v1/movies/export=1m,GET /v1/movies=5s"`, func(val string) error {
+		return parseRouteDeadlines(val, cfg.Deadlines.Routes)
+	})
+
 	// Create a new version boolean flag with the default value of false.
 	displayVersion := flag.Bool("version", false, "Display version and exit")
 
@@ -77,13 +91,32 @@ func main() {
 
 	logger := logger.New(os.Stdout, logger.LevelInfo)
 
-	db, err := postgres.OpenDb(cfg.Db)
+	storage, db, migrator, err := registry.OpenStorage(cfg, logger)
 	if err != nil {
 		logger.PrintFatal(err, nil)
 	}
 
-	defer db.Close()
-	logger.PrintInfo("database connection pool established", nil)
+	if db != nil {
+		defer db.Close()
+	}
+
+	if flag.Arg(0) == "migrate" {
+		err = migrateDatabase(migrator, flag.Args()[1:])
+		if err != nil {
+			logger.PrintFatal(err, nil)
+		}
+
+		return
+	}
+
+	if cfg.Db.AutoMigrate && migrator != nil {
+		err = migrator.Up(context.Background())
+		if err != nil {
+			logger.PrintFatal(err, nil)
+		}
+
+		logger.PrintInfo("database migrations applied", nil)
+	}
 
 	mailer := mailer.New(cfg.Smtp)
 
@@ -95,9 +128,11 @@ func main() {
 		return runtime.NumGoroutine()
 	}))
 
-	expvar.Publish("database", expvar.Func(func() interface{} {
-		return db.Stats()
-	}))
+	if db != nil {
+		expvar.Publish("database", expvar.Func(func() interface{} {
+			return db.Stats()
+		}))
+	}
 
 	expvar.Publish("timestamp", expvar.Func(func() interface{} {
 		return time.Now().Unix()
@@ -105,13 +140,62 @@ func main() {
 
 	app := &application{
 		config:   cfg,
-		registry: registry.NewRegistry(db, logger, mailer, wg),
+		registry: registry.NewRegistry(storage, logger, mailer, wg),
 		logger:   logger,
 		wg:       wg,
 	}
 
+	if flag.Arg(0) == "import" {
+		err = app.importMovies(flag.Args()[1:])
+		if err != nil {
+			logger.PrintFatal(err, nil)
+		}
+
+		return
+	}
+
+	if flag.Arg(0) == "seed" {
+		err = app.seedDatabase(storage, flag.Args()[1:])
+		if err != nil {
+			logger.PrintFatal(err, nil)
+		}
+
+		return
+	}
+
 	err = app.serve()
 	if err != nil {
 		logger.PrintFatal(err, nil)
 	}
 }
+
+// parseRouteDeadlines adds the deadlines of a comma separated list of "METHOD /pattern=duration"
+// to routes.
+func parseRouteDeadlines(val string, routes map[string]time.Duration) error {
+
+	for _, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndexByte(entry, '=')
+		if i < 0 {
+			return fmt.Errorf("invalid route deadline %q, want METHOD /pattern=duration", entry)
+		}
+
+		deadline, err := time.ParseDuration(entry[i+1:])
+		if err != nil {
+			return fmt.Errorf("invalid route deadline %q: %w", entry, err)
+		}
+
+		fields := strings.Fields(entry[:i])
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid route deadline %q, want METHOD /pattern=duration", entry)
+		}
+
+		routes[strings.ToUpper(fields[0])+" "+fields[1]] = deadline
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"expvar"
 	"fmt"
@@ -11,19 +12,81 @@ import (
 	"time"
 
 	"github.com/felixge/httpsnoop"
+	"github.com/go-chi/chi/v5"
 	"github.com/tomasen/realip"
 	"golang.org/x/time/rate"
 
+	"github.com/terdia/greenlight/internal/commons"
 	"github.com/terdia/greenlight/internal/data"
 	"github.com/terdia/greenlight/internal/validator"
 	"github.com/terdia/greenlight/src/users/entities"
 )
 
+// negotiateContent selects the media type of the responses from the Accept header, requests
+// accepting no media type we can encode are rejected with 406 Not Acceptable.
+func (app *application) negotiateContent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		r, ok := commons.NegotiateContent(r)
+		if !ok {
+			app.registry.Services.SharedUtil.NotAcceptableResponse(rw, r)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// negotiateLanguage selects the language of the error messages from the Accept-Language header.
+func (app *application) negotiateLanguage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(rw, commons.NegotiateLanguage(r))
+	})
+}
+
+// requestDeadline bounds the request context with the deadline of the matched route, or the
+// default deadline, so the queries of a slow request are cancelled.
+func (app *application) requestDeadline(router chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+			deadline := app.config.Deadlines.Default
+
+			rctx := chi.NewRouteContext()
+			if router.Match(rctx, r.Method, r.URL.Path) {
+				pattern := rctx.RoutePattern()
+				if len(pattern) > 1 {
+					pattern = strings.TrimSuffix(pattern, "/")
+				}
+
+				if routeDeadline, exists := app.config.Deadlines.Routes[r.Method+" "+pattern]; exists {
+					deadline = routeDeadline
+				}
+			}
+
+			if deadline > 0 {
+				ctx, cancel := context.WithTimeout(r.Context(), deadline)
+				defer cancel()
+
+				r = r.WithContext(ctx)
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
 func (app *application) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		defer func() {
 			if err := recover(); err != nil {
+				// http.ErrAbortHandler is used by streaming handlers to abort a response that
+				// has already been partially sent, let net/http close the connection.
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
 				w.Header().Set("Connection", "close")
 
 				app.registry.Services.SharedUtil.ServerErrorResponse(w, r, fmt.Errorf("%s", err))
@@ -147,7 +210,7 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		user, err := app.registry.Services.UserRepository.GetForToken(token, data.TokenScopeAuthentication)
+		user, err := app.registry.Services.UserRepository.GetForToken(r.Context(), token, data.TokenScopeAuthentication)
 		if err != nil {
 			switch {
 			case errors.Is(err, data.ErrRecordNotFound):
@@ -207,7 +270,7 @@ func (app *application) requirePermission(code string, next http.HandlerFunc) ht
 		user := app.contextGetUser(r)
 
 		utils := app.registry.Services.SharedUtil
-		permissions, err := app.registry.Services.PermissionRepository.GetAllForUser(user.ID)
+		permissions, err := app.registry.Services.PermissionRepository.GetAllForUser(r.Context(), user.ID)
 
 		if err != nil {
 			utils.ServerErrorResponse(rw, r, err)
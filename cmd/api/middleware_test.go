@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequestDeadline(t *testing.T) {
+
+	app := newTestApplication(t, 0)
+	app.config.Deadlines.Default = time.Minute
+	app.config.Deadlines.Routes = map[string]time.Duration{"GET /v1/slow/{id}": 20 * time.Millisecond}
+
+	router := chi.NewRouter()
+	router.Use(app.requestDeadline(router))
+
+	// slow stands for a handler waiting on a query, the query returns when the context is done
+	router.Get("/v1/slow/{id}", func(rw http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			app.registry.Services.SharedUtil.ServerErrorResponse(rw, r, r.Context().Err())
+		case <-time.After(5 * time.Second):
+			rw.WriteHeader(http.StatusOK)
+		}
+	})
+
+	router.Get("/v1/fast", func(rw http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		if !ok || time.Until(deadline) < 50*time.Second {
+			t.Errorf("want the default deadline; got %v", deadline)
+		}
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	ts := newTestServer(t, router)
+	defer ts.Close()
+
+	start := time.Now()
+	rs := ts.get(t, "/v1/slow/1")
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("want the request to be cancelled after its deadline; took %s", elapsed)
+	}
+
+	if rs.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("want %d; got %d", http.StatusServiceUnavailable, rs.StatusCode)
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+
+	if err := json.Unmarshal(rs.Body, &body); err != nil || body.Code != "timeout" {
+		t.Errorf("want code timeout; got %s", rs.Body)
+	}
+
+	if rs := ts.get(t, "/v1/fast"); rs.StatusCode != http.StatusOK {
+		t.Errorf("want %d; got %d", http.StatusOK, rs.StatusCode)
+	}
+}
+
+func TestParseRouteDeadlines(t *testing.T) {
+
+	routes := map[string]time.Duration{"GET /v1/movies/export": time.Minute}
+
+	err := parseRouteDeadlines("get /v1/movies/{id}=2s, POST /v1/movies/batch=1m30s", routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]time.Duration{
+		"GET /v1/movies/export": time.Minute,
+		"GET /v1/movies/{id}":   2 * time.Second,
+		"POST /v1/movies/batch": 90 * time.Second,
+	}
+
+	for route, deadline := range want {
+		if routes[route] != deadline {
+			t.Errorf("want %s for %s; got %s", deadline, route, routes[route])
+		}
+	}
+
+	for _, invalid := range []string{"/v1/movies=2s", "GET /v1/movies", "GET /v1/movies=soon"} {
+		if err := parseRouteDeadlines(invalid, routes); err == nil {
+			t.Errorf("want an error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/terdia/greenlight/infrastructures/persistence/migrate"
+)
+
+const migrateUsage = "Usage: api [flags] migrate up|down|status|goto <version>"
+
+// migrateDatabase runs the migrate subcommand, it applies or rolls back the migrations embedded in
+// the binary e.g.
+//
+//	api -dsn=$GREENLIGHT_DB_DSN migrate up
+//
+// up applies the pending migrations, down rolls back the last one and goto applies or rolls back
+// migrations until the given version, 0 rolling back all of them.
+func migrateDatabase(migrator *migrate.Migrator, args []string) error {
+
+	if migrator == nil {
+		return errors.New("migrate: the memory storage has no database to migrate")
+	}
+
+	if len(args) == 0 || (args[0] == "goto") != (len(args) == 2) || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return errors.New("migrate: invalid arguments")
+	}
+
+	// an interrupted migration is rolled back
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx)
+	case "goto":
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("migrate: invalid version %q", args[1])
+		}
+
+		err = migrator.Goto(ctx, version)
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return fmt.Errorf("migrate: unknown command %q", args[0])
+	}
+
+	if err != nil {
+		return err
+	}
+
+	version, _, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Version:\t%d\n", version)
+
+	return nil
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrate.Migrator) error {
+
+	version, dirty, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Version:\t%d\n", version)
+	fmt.Printf("Dirty:\t\t%t\n", dirty)
+
+	for _, migration := range migrator.Migrations() {
+		status := "pending"
+		if migration.Version <= version {
+			status = "applied"
+		}
+
+		fmt.Printf("%06d\t\t%s\t%s\n", migration.Version, status, migration.Name)
+	}
+
+	return nil
+}
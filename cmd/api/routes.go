@@ -19,7 +19,7 @@ func (app *application) routes() http.Handler {
 	router.NotFound(utils.NotFoundResponse)
 	router.MethodNotAllowed(utils.MethodNotAllowedResponse)
 
-	router.Use(app.metrics, app.recoverPanic, app.logRequest, app.enableCors, app.rateLimit, app.authenticate)
+	router.Use(app.metrics, app.negotiateLanguage, app.negotiateContent, app.recoverPanic, app.logRequest, app.enableCors, app.rateLimit, app.requestDeadline(router), app.authenticate)
 
 	router.Get("/v1/healthcheck", app.healthcheckHandler)
 
@@ -31,10 +31,16 @@ func (app *application) routes() http.Handler {
 
 		r.Post("/", app.requirePermission("movies:write", movieHandler.CreateMovie))
 		r.Get("/", app.requirePermission("movies:read", movieHandler.ListMovie))
+		r.Get("/export", app.requirePermission("movies:read", movieHandler.ExportMovies))
+		r.Get("/suggest", app.requirePermission("movies:read", movieHandler.SuggestMovies))
+		r.Post("/batch", app.requirePermission("movies:write", movieHandler.BatchMovies))
+		r.Post("/imports", app.requirePermission("movies:write", movieHandler.ImportMovies))
+		r.Get("/imports/{id}", app.requirePermission("movies:write", movieHandler.ShowImportJob))
 
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", app.requirePermission("movies:read", movieHandler.ShowMovie))
 			r.Patch("/", app.requirePermission("movies:write", movieHandler.UpdateMovie))  // PATCH v1/movies/xxxx
+			r.Put("/", app.requirePermission("movies:write", movieHandler.ReplaceMovie))   // PUT v1/movies/xxxx
 			r.Delete("/", app.requirePermission("movies:write", movieHandler.DeleteMovie)) // DELETE v1/movies/xxxx
 		})
 
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/terdia/greenlight/internal/registry"
+	"github.com/terdia/greenlight/internal/seed"
+)
+
+const maxSeedScale = 1000
+
+// seedDatabase runs the seed subcommand, it fills the database with generated movies, users and
+// tokens e.g.
+//
+//	api -dsn=$GREENLIGHT_DB_DSN seed -seed=1 -scale=10
+//
+// The same seed and scale always generate the same data, and seeding again only adds what is
+// missing, so the command can be run every time a development database is set up. It refuses to
+// seed the production environment unless -force is given, and only prints the password of the
+// users it created, the users already stored keep their own.
+func (app *application) seedDatabase(storage registry.Storage, args []string) error {
+
+	cmd := flag.NewFlagSet("seed", flag.ExitOnError)
+	seedValue := cmd.Int64("seed", 1, "Seed of the generated data")
+	scale := cmd.Int("scale", 1, fmt.Sprintf("Scale of the generated data, %d movies and %d users each", seed.MoviesPerScale, seed.UsersPerScale))
+	force := cmd.Bool("force", false, "Seed the database of the production environment")
+
+	cmd.Usage = func() {
+		fmt.Fprintf(cmd.Output(), "Usage: api [flags] seed [-seed=<seed>] [-scale=<scale>] [-force]\n")
+		cmd.PrintDefaults()
+	}
+
+	cmd.Parse(args)
+
+	if cmd.NArg() != 0 {
+		cmd.Usage()
+		return errors.New("seed: unexpected arguments")
+	}
+
+	if *scale < 1 || *scale > maxSeedScale {
+		return fmt.Errorf("seed: scale must be between 1 and %d", maxSeedScale)
+	}
+
+	if app.config.Env == "production" && !*force {
+		return errors.New("seed: refusing to seed the production environment, use -force to seed it anyway")
+	}
+
+	if app.config.Storage == registry.StorageMemory {
+		return errors.New("seed: the memory storage is lost on exit, there is no database to seed")
+	}
+
+	fixtures := seed.Generate(seed.Options{Seed: *seedValue, Scale: *scale})
+
+	// an interrupted seed is rolled back
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, err := seed.Apply(ctx, storage.UnitOfWork, fixtures)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Movies:\t\t%d inserted, %d existing\n", result.MoviesInserted, result.MoviesExisting)
+	fmt.Printf("Users:\t\t%d created, %d existing\n\n", result.UsersCreated, result.UsersExisting)
+
+	created := make(map[string]bool, len(result.Created))
+	for _, email := range result.Created {
+		created[email] = true
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(w, "EMAIL\tPASSWORD\tPERMISSIONS\tTOKEN\tSCOPE")
+
+	for _, user := range fixtures.Users {
+		// the users already stored keep their password, their tokens are replaced
+		password := "-"
+		if created[user.Email] {
+			password = seed.Password
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", user.Email, password, strings.Join(user.Permissions, ","), user.Token.Plaintext, user.Token.Scope)
+	}
+
+	return w.Flush()
+}
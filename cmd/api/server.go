@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,6 +19,11 @@ const (
 
 func (app *application) serve() error {
 
+	// requests still running at the end of the grace period are cancelled through their
+	// context, which aborts their queries
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", app.config.AppPort),
 		Handler:      app.routes(),
@@ -25,6 +31,7 @@ func (app *application) serve() error {
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
+		BaseContext:  func(net.Listener) context.Context { return baseCtx },
 	}
 
 	shutdownError := make(chan error)
@@ -46,6 +53,7 @@ func (app *application) serve() error {
 		defer cancel()
 
 		err := srv.Shutdown(ctx)
+		cancelRequests()
 		if err != nil {
 			shutdownError <- err
 		}
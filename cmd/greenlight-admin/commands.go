@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/validator"
+	"github.com/terdia/greenlight/src/users/entities"
+)
+
+// tokenScopes are the scopes of the tokens deleted by revoke-tokens.
+var tokenScopes = []string{data.TokenScopeActivation, data.TokenScopeAuthentication}
+
+type userResult struct {
+	User            dto.UserResponse `json:"user"`
+	Permissions     data.Permissions `json:"permissions"`
+	ActivationToken string           `json:"activation_token,omitempty"` // only for a user created without -activate
+}
+
+type permissionsResult struct {
+	Email       string           `json:"email"`
+	Permissions data.Permissions `json:"permissions"`
+}
+
+type revokeTokensResult struct {
+	Email  string   `json:"email"`
+	Scopes []string `json:"scopes"`
+}
+
+type purgeTokensResult struct {
+	Deleted int64 `json:"deleted"`
+}
+
+type statsResult struct {
+	Movies int `json:"movies"`
+	Users  struct {
+		Total     int `json:"total"`
+		Activated int `json:"activated"`
+	} `json:"users"`
+	Tokens struct {
+		Active  map[string]int `json:"active"` // by scope
+		Expired int            `json:"expired"`
+	} `json:"tokens"`
+}
+
+// createUser runs the create-user command e.g.
+//
+//	greenlight-admin create-user -name=Alice -email=alice@example.com -activate -grant=movies:write < password.txt
+//
+// The password is read from the first line of stdin unless -password is set.
+func (app *application) createUser(ctx context.Context, args []string) error {
+
+	cmd := flag.NewFlagSet("create-user", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the user")
+	email := cmd.String("email", "", "Email address of the user")
+	password := cmd.String("password", "", "Password of the user, read from stdin by default")
+	activate := cmd.Bool("activate", false, "Activate the user")
+	grant := cmd.String("grant", "", "Permissions granted besides the default ones (comma separated) e.g. movies:write")
+
+	cmd.Usage = func() {
+		fmt.Fprintf(cmd.Output(), "Usage: greenlight-admin [flags] create-user -name=<name> -email=<email> [-password=<password>] [-activate] [-grant=<permissions>]\n")
+		cmd.PrintDefaults()
+	}
+
+	cmd.Parse(args)
+
+	if cmd.NArg() != 0 {
+		cmd.Usage()
+		return errors.New("create-user: unexpected arguments")
+	}
+
+	if *password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("create-user: reading the password from stdin: %w", err)
+		}
+
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	var codes []string
+	if *grant != "" {
+		codes = strings.Split(*grant, ",")
+	}
+
+	request := dto.CreateUserRequest{Name: *name, Email: *email, Password: *password}
+
+	user, token, permissions, errs, err := app.registry.Services.AdminService.CreateUser(ctx, request, *activate, codes...)
+	if err != nil {
+		return err
+	}
+
+	if errs != nil {
+		return validationError("create-user", errs)
+	}
+
+	result := userResult{User: userResponse(user), Permissions: nonNil(permissions)}
+
+	if token != nil {
+		result.ActivationToken = token.Plaintext
+	}
+
+	return app.print(result, func() { printUser(result) })
+}
+
+// activateUser runs the activate command e.g.
+//
+//	greenlight-admin activate alice@example.com
+func (app *application) activateUser(ctx context.Context, args []string) error {
+
+	if len(args) != 1 {
+		return errors.New("usage: greenlight-admin [flags] activate <email>")
+	}
+
+	services := app.registry.Services
+
+	user, err := services.AdminService.ActivateUser(ctx, args[0])
+	if err != nil {
+		return userError(args[0], err)
+	}
+
+	permissions, err := services.PermissionRepository.GetAllForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	result := userResult{User: userResponse(user), Permissions: nonNil(permissions)}
+
+	return app.print(result, func() { printUser(result) })
+}
+
+// grantPermissions runs the grant command e.g.
+//
+//	greenlight-admin grant alice@example.com movies:write
+func (app *application) grantPermissions(ctx context.Context, args []string) error {
+
+	if len(args) < 2 {
+		return errors.New("usage: greenlight-admin [flags] grant <email> <permission>...")
+	}
+
+	permissions, err := app.registry.Services.AdminService.GrantPermissions(ctx, args[0], args[1:]...)
+	if err != nil {
+		return userError(args[0], err)
+	}
+
+	return app.printPermissions(args[0], permissions)
+}
+
+// revokePermissions runs the revoke command e.g.
+//
+//	greenlight-admin revoke alice@example.com movies:write
+func (app *application) revokePermissions(ctx context.Context, args []string) error {
+
+	if len(args) < 2 {
+		return errors.New("usage: greenlight-admin [flags] revoke <email> <permission>...")
+	}
+
+	permissions, err := app.registry.Services.AdminService.RevokePermissions(ctx, args[0], args[1:]...)
+	if err != nil {
+		return userError(args[0], err)
+	}
+
+	return app.printPermissions(args[0], permissions)
+}
+
+// revokeTokens runs the revoke-tokens command, the user has to authenticate again e.g.
+//
+//	greenlight-admin revoke-tokens -scope=authentication alice@example.com
+func (app *application) revokeTokens(ctx context.Context, args []string) error {
+
+	cmd := flag.NewFlagSet("revoke-tokens", flag.ExitOnError)
+	scope := cmd.String("scope", "", "Scope of the tokens deleted (activation|authentication), all of them by default")
+
+	cmd.Usage = func() {
+		fmt.Fprintf(cmd.Output(), "Usage: greenlight-admin [flags] revoke-tokens [-scope=activation|authentication] <email>\n")
+		cmd.PrintDefaults()
+	}
+
+	cmd.Parse(args)
+
+	if cmd.NArg() != 1 {
+		cmd.Usage()
+		return errors.New("revoke-tokens: exactly one email must be provided")
+	}
+
+	scopes := tokenScopes
+
+	if *scope != "" {
+		if !validator.In(*scope, tokenScopes...) {
+			return fmt.Errorf("revoke-tokens: unknown scope %q", *scope)
+		}
+
+		scopes = []string{*scope}
+	}
+
+	email := cmd.Arg(0)
+
+	err := app.registry.Services.AdminService.RevokeTokens(ctx, email, scopes...)
+	if err != nil {
+		return userError(email, err)
+	}
+
+	result := revokeTokensResult{Email: email, Scopes: scopes}
+
+	return app.print(result, func() {
+		fmt.Printf("Email:\t\t%s\n", result.Email)
+		fmt.Printf("Revoked:\t%s\n", strings.Join(result.Scopes, ", "))
+	})
+}
+
+// purgeTokens runs the purge-tokens command e.g.
+//
+//	greenlight-admin purge-tokens
+func (app *application) purgeTokens(ctx context.Context, args []string) error {
+
+	if len(args) != 0 {
+		return errors.New("usage: greenlight-admin [flags] purge-tokens")
+	}
+
+	deleted, err := app.registry.Services.AdminService.PurgeExpiredTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := purgeTokensResult{Deleted: deleted}
+
+	return app.print(result, func() {
+		fmt.Printf("Deleted:\t%d\n", result.Deleted)
+	})
+}
+
+// stats runs the stats command e.g.
+//
+//	greenlight-admin -json stats
+func (app *application) stats(ctx context.Context, args []string) error {
+
+	if len(args) != 0 {
+		return errors.New("usage: greenlight-admin [flags] stats")
+	}
+
+	services := app.registry.Services
+
+	_, metadata, err := services.MovieService.List(ctx, dto.ListMovieRequest{
+		Filters: data.Filters{Page: 1, PageSize: 1, Sort: "id", SortSafelist: []string{"id"}, IncludeTotal: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	users, err := services.AdminService.CountUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := services.AdminService.CountTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	var result statsResult
+
+	result.Movies = metadata.TotalRecords
+	result.Users.Total, result.Users.Activated = users.Total, users.Activated
+	result.Tokens.Active, result.Tokens.Expired = map[string]int{}, tokens.Expired
+
+	for _, scope := range tokenScopes {
+		result.Tokens.Active[scope] = tokens.Active[scope]
+	}
+
+	return app.print(result, func() {
+		fmt.Printf("Movies:\t\t\t%d\n", result.Movies)
+		fmt.Printf("Users:\t\t\t%d\n", result.Users.Total)
+		fmt.Printf("Activated users:\t%d\n", result.Users.Activated)
+
+		for _, scope := range sortedKeys(result.Tokens.Active) {
+			fmt.Printf("%s tokens:\t%d\n", strings.ToUpper(scope[:1])+scope[1:], result.Tokens.Active[scope])
+		}
+
+		fmt.Printf("Expired tokens:\t\t%d\n", result.Tokens.Expired)
+	})
+}
+
+func (app *application) printPermissions(email string, permissions data.Permissions) error {
+
+	result := permissionsResult{Email: email, Permissions: nonNil(permissions)}
+
+	return app.print(result, func() {
+		fmt.Printf("Email:\t\t%s\n", result.Email)
+		fmt.Printf("Permissions:\t%s\n", strings.Join(result.Permissions, ", "))
+	})
+}
+
+func printUser(result userResult) {
+
+	fmt.Printf("ID:\t\t%d\n", result.User.ID)
+	fmt.Printf("Name:\t\t%s\n", result.User.Name)
+	fmt.Printf("Email:\t\t%s\n", result.User.Email)
+	fmt.Printf("Activated:\t%t\n", result.User.Activated)
+	fmt.Printf("Permissions:\t%s\n", strings.Join(result.Permissions, ", "))
+
+	if result.ActivationToken != "" {
+		fmt.Printf("Activation:\t%s\n", result.ActivationToken)
+	}
+}
+
+func userResponse(user *entities.User) dto.UserResponse {
+	return dto.UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Activated: user.Activated,
+		CreatedAt: user.CreatedAt,
+		Version:   user.Version,
+	}
+}
+
+// userError reports a missing user with its email.
+func userError(email string, err error) error {
+
+	if errors.Is(err, data.ErrRecordNotFound) {
+		return fmt.Errorf("no user with email %q", email)
+	}
+
+	return err
+}
+
+// validationError returns an error with the first message of every invalid field.
+func validationError(command string, errs validator.Errors) error {
+
+	flat := errs.Flat()
+
+	fields := make([]string, 0, len(flat))
+	for field := range flat {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field+" "+flat[field])
+	}
+
+	return fmt.Errorf("%s: %s", command, strings.Join(messages, ", "))
+}
+
+// nonNil returns an empty list for no permissions, so JSON output has an array.
+func nonNil(permissions data.Permissions) data.Permissions {
+
+	if permissions == nil {
+		return data.Permissions{}
+	}
+
+	return permissions
+}
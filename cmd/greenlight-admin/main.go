@@ -0,0 +1,162 @@
+// Command greenlight-admin runs operational tasks against the database of the API, through the
+// same services as the API, e.g.
+//
+//	greenlight-admin -dsn=$GREENLIGHT_DB_DSN grant alice@example.com movies:write
+//	greenlight-admin -dsn=sqlite://greenlight.db -json stats
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+
+	_ "github.com/lib/pq"
+
+	"github.com/terdia/greenlight/config"
+	"github.com/terdia/greenlight/infrastructures/logger"
+	"github.com/terdia/greenlight/internal/mailer"
+	"github.com/terdia/greenlight/internal/registry"
+)
+
+var (
+	buildTime string
+	version   string
+)
+
+type application struct {
+	registry registry.Registry
+	json     bool // print the result of the commands as JSON
+}
+
+type command struct {
+	name  string
+	usage string
+	run   func(app *application, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "create-user", usage: "Create a user, optionally activated and granted permissions", run: (*application).createUser},
+	{name: "activate", usage: "Activate a user without an activation token", run: (*application).activateUser},
+	{name: "grant", usage: "Grant permissions to a user", run: (*application).grantPermissions},
+	{name: "revoke", usage: "Revoke permissions of a user", run: (*application).revokePermissions},
+	{name: "revoke-tokens", usage: "Delete the activation and authentication tokens of a user", run: (*application).revokeTokens},
+	{name: "purge-tokens", usage: "Delete the expired tokens of every user", run: (*application).purgeTokens},
+	{name: "stats", usage: "Print the number of movies, users and tokens", run: (*application).stats},
+}
+
+func main() {
+
+	cfg := new(config.Config)
+	cfg.Storage = registry.StorageDatabase
+
+	flag.StringVar(&cfg.Db.Dsn, "dsn", os.Getenv("GREENLIGHT_DB_DSN"), "PostgreSQL DSN, or SQLite DSN e.g. sqlite://greenlight.db, $GREENLIGHT_DB_DSN by default")
+	flag.IntVar(&cfg.Db.MaxOpenConns, "db-max-open-conns", 4, "Database max open connections")
+	flag.IntVar(&cfg.Db.MaxIdleConns, "db-max-idle-conns", 4, "Database max idle connections")
+	flag.StringVar(&cfg.Db.MaxIdleTime, "db-max-idle-time", "15m", "Database max connection idle time")
+
+	jsonOutput := flag.Bool("json", false, "Print the result as JSON")
+	displayVersion := flag.Bool("version", false, "Display version and exit")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: greenlight-admin [flags] <command> [arguments]\n\nCommands:\n")
+
+		for _, cmd := range commands {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %-14s %s\n", cmd.name, cmd.usage)
+		}
+
+		fmt.Fprintf(flag.CommandLine.Output(), "\nFlags:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *displayVersion {
+		fmt.Printf("Version:\t%s\n", version)
+		fmt.Printf("Build time:\t%s\n", buildTime)
+		os.Exit(0)
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == flag.Arg(0) {
+			cmd = &commands[i]
+		}
+	}
+
+	if cmd == nil {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if cfg.Db.Dsn == "" {
+		fatal(errors.New("no database, set -dsn or GREENLIGHT_DB_DSN"))
+	}
+
+	// the output of the commands is on stdout, so it can be parsed when it is JSON
+	logger := logger.New(os.Stderr, logger.LevelError)
+
+	storage, db, _, err := registry.OpenStorage(cfg, logger)
+	if err != nil {
+		fatal(err)
+	}
+
+	// the commands don't send emails
+	mailer := mailer.New(cfg.Smtp)
+
+	app := &application{
+		registry: registry.NewRegistry(storage, logger, mailer, new(sync.WaitGroup)),
+		json:     *jsonOutput,
+	}
+
+	// an interrupted command is rolled back
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	err = cmd.run(app, ctx, flag.Args()[1:])
+
+	stop()
+	db.Close()
+
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// fatal prints the error on stderr and exits with status 1.
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "greenlight-admin: %v\n", err)
+	os.Exit(1)
+}
+
+// print prints the result of a command, as JSON when -json is set and with text otherwise.
+func (app *application) print(result interface{}, text func()) error {
+
+	if !app.json {
+		text()
+		return nil
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+
+	return enc.Encode(result)
+}
+
+// sortedKeys returns the keys of m in order, so the output of a command is stable.
+func sortedKeys(m map[string]int) []string {
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -1,8 +1,11 @@
 package config
 
+import "time"
+
 type Config struct {
 	AppPort int
 	Env     string
+	Storage string // db (PostgreSQL or SQLite by DSN scheme) or memory
 	Db      Db
 	Version string
 	Limiter struct {
@@ -14,6 +17,14 @@ type Config struct {
 	Cors struct {
 		TrustedOrigins []string
 	}
+	Deadlines Deadlines
+}
+
+// Deadlines bound the time spent on a request, its queries are cancelled once the deadline
+// is exceeded. A zero duration disables the deadline.
+type Deadlines struct {
+	Default time.Duration
+	Routes  map[string]time.Duration // by method and route pattern e.g. "GET /v1/movies/{id}"
 }
 
 type Db struct {
@@ -21,6 +32,7 @@ type Db struct {
 	MaxOpenConns int
 	MaxIdleConns int
 	MaxIdleTime  string
+	AutoMigrate  bool // apply the pending migrations on startup
 }
 
 type Smtp struct {
@@ -1,17 +1,10 @@
-// Package docs GENERATED BY THE COMMAND ABOVE; DO NOT EDIT
-// This file was generated by swaggo/swag
-package docs
+// Code generated by swaggo/swag. DO NOT EDIT.
 
-import (
-	"bytes"
-	"encoding/json"
-	"strings"
-	"text/template"
+package docs
 
-	"github.com/swaggo/swag"
-)
+import "github.com/swaggo/swag"
 
-var doc = `{
+const docTemplate = `{
     "schemes": {{ marshal .Schemes }},
     "swagger": "2.0",
     "info": {
@@ -25,7 +18,7 @@ var doc = `{
     "paths": {
         "/movies": {
             "get": {
-                "description": "get all movies",
+                "description": "get all movies, with a title search the matching words are highlighted in the highlight field",
                 "tags": [
                     "Movies"
                 ],
@@ -37,12 +30,84 @@ var doc = `{
                         "name": "title",
                         "in": "query"
                     },
+                    {
+                        "enum": [
+                            "fulltext",
+                            "prefix",
+                            "fuzzy"
+                        ],
+                        "type": "string",
+                        "default": "fulltext",
+                        "description": "fulltext matches whole words, prefix partial words and fuzzy tolerates typos",
+                        "name": "search_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only movies in the language e.g. english",
+                        "name": "language",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "command seperated list e.g. crime,drama",
                         "name": "genres",
                         "in": "query"
                     },
+                    {
+                        "enum": [
+                            "all",
+                            "any",
+                            "none"
+                        ],
+                        "type": "string",
+                        "default": "all",
+                        "description": "match movies with all, any or none of the genres",
+                        "name": "genres_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimum year, inclusive",
+                        "name": "year_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum year, inclusive",
+                        "name": "year_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimum runtime in minutes, inclusive",
+                        "name": "runtime_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum runtime in minutes, inclusive",
+                        "name": "runtime_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp or date e.g. 2021-10-01",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp or date e.g. 2021-10-01",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter expression on title, year, runtime, genres, language and created_at e.g. year\u003e=2000 and runtime\u003c120",
+                        "name": "filter",
+                        "in": "query"
+                    },
                     {
                         "maximum": 10000000,
                         "minimum": 1,
@@ -62,22 +127,43 @@ var doc = `{
                         "in": "query"
                     },
                     {
-                        "enum": [
-                            "id",
-                            "title",
-                            "year",
-                            "runtime",
-                            "-id",
-                            "-title",
-                            "-year",
-                            "-runtime"
-                        ],
                         "type": "string",
                         "default": "id",
-                        "description": "add - to sort in descing order",
+                        "description": "comma seperated list of up to 3 of id, title, year, runtime, relevance (requires title), add - to sort in descing order e.g. -year,title",
                         "name": "sort",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "cursor from metadata.next_cursor, returns the page after it instead of using page numbers",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor from metadata.prev_cursor, returns the page before it instead of using page numbers",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "count the matching records, set to false for faster responses",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma seperated list of genres, decade, runtime_bucket, counts the matching movies by each value",
+                        "name": "facets",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma seperated list of the movie fields to return e.g. title,year, the id is always returned",
+                        "name": "fields",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Authorization: Bearer XXSGGSSHHSSJSJSSS",
@@ -106,6 +192,18 @@ var doc = `{
                         }
                     },
                     "401": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "403": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "422": {
                         "description": "status: fail",
                         "schema": {
                             "allOf": [
@@ -123,8 +221,41 @@ var doc = `{
                             ]
                         }
                     },
-                    "403": {
-                        "description": "status: fail",
+                    "500": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "create a new movie with given details",
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "Create new movie",
+                "parameters": [
+                    {
+                        "description": "Update movie request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MovieRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization: Bearer XXSGGSSHHSSJSJSSS",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -134,11 +265,35 @@ var doc = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.ValidationError"
+                                            "$ref": "#/definitions/dto.SingleMovieResponse"
                                         }
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/v1/movies/QbPy4B7a2Lw1Kg7ogoEWj9k3NGMRVY"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "401": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "403": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
                         }
                     },
                     "422": {
@@ -166,21 +321,23 @@ var doc = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/movies/batch": {
             "post": {
-                "description": "create a new movie with given details",
+                "description": "run up to 100 operations in a single transaction. In atomic mode (default) a failed operation rolls back\nthe whole batch, in per_item mode only the failed operations are rolled back.",
                 "tags": [
                     "Movies"
                 ],
-                "summary": "Create new movie",
+                "summary": "Create, update and delete movies in one request",
                 "parameters": [
                     {
-                        "description": "Update movie request",
+                        "description": "Batch movie request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.MovieRequest"
+                            "$ref": "#/definitions/dto.BatchMovieRequest"
                         }
                     },
                     {
@@ -203,17 +360,11 @@ var doc = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.SingleMovieResponse"
+                                            "$ref": "#/definitions/dto.BatchMovieResponse"
                                         }
                                     }
                                 }
                             ]
-                        },
-                        "headers": {
-                            "Location": {
-                                "type": "string",
-                                "description": "/v1/movies/QbPy4B7a2Lw1Kg7ogoEWj9k3NGMRVY"
-                            }
                         }
                     },
                     "400": {
@@ -235,7 +386,7 @@ var doc = `{
                         }
                     },
                     "422": {
-                        "description": "status: fail",
+                        "description": "status: fail, atomic batch rolled back",
                         "schema": {
                             "allOf": [
                                 {
@@ -245,7 +396,7 @@ var doc = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.ValidationError"
+                                            "$ref": "#/definitions/dto.BatchMovieResponse"
                                         }
                                     }
                                 }
@@ -261,19 +412,114 @@ var doc = `{
                 }
             }
         },
-        "/movies/{id}": {
+        "/movies/export": {
             "get": {
-                "description": "show details of a given movie",
+                "description": "stream every movie matching the ListMovie filters ordered by id, as CSV (id,title,year,runtime,genres,language),\none JSON object per line (ndjson) or a single JSON document (json)",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
                 "tags": [
                     "Movies"
                 ],
-                "summary": "Show movie details by id",
+                "summary": "Export the movie catalogue",
                 "parameters": [
                     {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "json"
+                        ],
                         "type": "string",
-                        "description": "Id of the movie to show",
-                        "name": "id",
-                        "in": "path"
+                        "default": "json",
+                        "description": "export format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "full text search by movie title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "fulltext",
+                            "prefix",
+                            "fuzzy"
+                        ],
+                        "type": "string",
+                        "default": "fulltext",
+                        "description": "fulltext matches whole words, prefix partial words and fuzzy tolerates typos",
+                        "name": "search_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only movies in the language e.g. english",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "command seperated list e.g. crime,drama",
+                        "name": "genres",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "all",
+                            "any",
+                            "none"
+                        ],
+                        "type": "string",
+                        "default": "all",
+                        "description": "match movies with all, any or none of the genres",
+                        "name": "genres_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimum year, inclusive",
+                        "name": "year_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum year, inclusive",
+                        "name": "year_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimum runtime in minutes, inclusive",
+                        "name": "runtime_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum runtime in minutes, inclusive",
+                        "name": "runtime_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp or date e.g. 2021-10-01",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp or date e.g. 2021-10-01",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter expression on title, year, runtime, genres, language and created_at e.g. year\u003e=2000 and runtime\u003c120",
+                        "name": "filter",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -295,31 +541,515 @@ var doc = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.SingleMovieResponse"
+                                            "$ref": "#/definitions/dto.ExportMovieResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "403": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "422": {
+                        "description": "status: fail",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/commons.ResponseObject"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ValidationError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/imports": {
+            "post": {
+                "description": "upload a CSV file (title,year,runtime,genres header) or one movie JSON object per line. The file is imported\nasynchronously in a single transaction, poll the returned import job for progress and line numbered errors.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "Bulk import movies from a CSV or NDJSON file",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "file format, detected from the Content-Type (text/csv, application/x-ndjson) by default",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "validate the file without importing any movie",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization: Bearer XXSGGSSHHSSJSJSSS",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/commons.ResponseObject"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ImportJobResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/v1/movies/imports/QbPy4B7a2Lw1Kg7ogoEWj9k3NGMRVY"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "401": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "403": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "422": {
+                        "description": "status: fail",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/commons.ResponseObject"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ValidationError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/imports/{id}": {
+            "get": {
+                "description": "show the status, progress and line numbered errors of an import job",
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "Show the progress of a movie import",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the import job",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization: Bearer XXSGGSSHHSSJSJSSS",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/commons.ResponseObject"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ImportJobResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "403": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "404": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "500": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/suggest": {
+            "get": {
+                "description": "return the movies with a title word starting with q, movies whose title starts with q first",
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "Suggest movie titles for type-ahead",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the text typed so far e.g. godf",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 20,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "maximum number of suggestions",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization: Bearer XXSGGSSHHSSJSJSSS",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/commons.ResponseObject"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.SuggestMovieResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "403": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "422": {
+                        "description": "status: fail",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/commons.ResponseObject"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ValidationError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}": {
+            "get": {
+                "description": "show details of a given movie",
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "Show movie details by id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the movie to show",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma seperated list of the movie fields to return e.g. title,year, the id is always returned",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "similar"
+                        ],
+                        "type": "string",
+                        "description": "related resources to embed, similar: up to 5 movies sharing a genre",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization: Bearer XXSGGSSHHSSJSJSSS",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/commons.ResponseObject"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.SingleMovieResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "401": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "403": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "422": {
+                        "description": "status: fail",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/commons.ResponseObject"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ValidationError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "replace every field of the movie with the given details, omitted fields are cleared",
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "Replace a given movie",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the movie to replace",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Replace movie request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MovieRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization: Bearer XXSGGSSHHSSJSJSSS",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/commons.ResponseObject"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.SingleMovieResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "401": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "403": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "404": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "409": {
+                        "description": "e.g. status: error, message: unable to update the record due to an edit conflict, please try again",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
+                    "422": {
+                        "description": "status: fail",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/commons.ResponseObject"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ValidationError"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "e.g. status: error, message: the error reason",
-                        "schema": {
-                            "$ref": "#/definitions/commons.ResponseObject"
-                        }
-                    },
-                    "401": {
-                        "description": "e.g. status: error, message: the error reason",
-                        "schema": {
-                            "$ref": "#/definitions/commons.ResponseObject"
-                        }
-                    },
-                    "403": {
-                        "description": "e.g. status: error, message: the error reason",
-                        "schema": {
-                            "$ref": "#/definitions/commons.ResponseObject"
-                        }
-                    },
                     "500": {
                         "description": "e.g. status: error, message: the error reason",
                         "schema": {
@@ -383,7 +1113,12 @@ var doc = `{
                 }
             },
             "patch": {
-                "description": "update movie with given details",
+                "description": "update movie with given details, the body is either a partial movie (application/json),\na JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json)",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
                 "tags": [
                     "Movies"
                 ],
@@ -401,7 +1136,7 @@ var doc = `{
                         "name": "body",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/dto.MovieRequest"
+                            "$ref": "#/definitions/dto.UpdateMovieRequest"
                         }
                     },
                     {
@@ -467,6 +1202,12 @@ var doc = `{
                             "$ref": "#/definitions/commons.ResponseObject"
                         }
                     },
+                    "415": {
+                        "description": "e.g. status: error, message: the error reason",
+                        "schema": {
+                            "$ref": "#/definitions/commons.ResponseObject"
+                        }
+                    },
                     "422": {
                         "description": "status: fail",
                         "schema": {
@@ -721,13 +1462,54 @@ var doc = `{
         "commons.ResponseObject": {
             "type": "object",
             "properties": {
+                "code": {
+                    "description": "stable error code of fail and error responses e.g. edit_conflict",
+                    "type": "string"
+                },
                 "data": {},
                 "message": {
                     "type": "string"
                 },
                 "status": {
                     "description": "(success|fail|error)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/custom_type.StatusMessage"
+                        }
+                    ]
+                }
+            }
+        },
+        "custom_type.StatusMessage": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2
+            ],
+            "x-enum-varnames": [
+                "Success",
+                "Fail",
+                "Error"
+            ]
+        },
+        "data.FacetCount": {
+            "type": "object",
+            "properties": {
+                "count": {
                     "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "data.Facets": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "array",
+                "items": {
+                    "$ref": "#/definitions/data.FacetCount"
                 }
             }
         },
@@ -743,9 +1525,23 @@ var doc = `{
                 "last_page": {
                     "type": "integer"
                 },
+                "next": {
+                    "description": "link to the next page using NextCursor",
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
                 "page_size": {
                     "type": "integer"
                 },
+                "prev": {
+                    "description": "link to the previous page using PrevCursor",
+                    "type": "string"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
                 "total_records": {
                     "type": "integer"
                 }
@@ -753,6 +1549,9 @@ var doc = `{
         },
         "dto.ActivateUserRequest": {
             "type": "object",
+            "required": [
+                "token"
+            ],
             "properties": {
                 "token": {
                     "type": "string"
@@ -761,17 +1560,113 @@ var doc = `{
         },
         "dto.AuthTokenRequest": {
             "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
             "properties": {
                 "email": {
                     "type": "string"
                 },
                 "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
+                }
+            }
+        },
+        "dto.BatchMovieOperation": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "required for update and delete",
+                    "type": "integer"
+                },
+                "movie": {
+                    "description": "full movie for create, changed fields for update",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.MovieRequest"
+                        }
+                    ]
+                },
+                "op": {
+                    "description": "create|update|delete",
                     "type": "string"
                 }
             }
         },
+        "dto.BatchMovieRequest": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "atomic (default) or per_item",
+                    "type": "string"
+                },
+                "operations": {
+                    "description": "maximum 100 operations",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BatchMovieOperation"
+                    }
+                }
+            }
+        },
+        "dto.BatchMovieResponse": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BatchMovieResult"
+                    }
+                }
+            }
+        },
+        "dto.BatchMovieResult": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "movie": {
+                    "$ref": "#/definitions/dto.MovieResponse"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "(success|fail|error)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/custom_type.StatusMessage"
+                        }
+                    ]
+                }
+            }
+        },
         "dto.CreateUserRequest": {
             "type": "object",
+            "required": [
+                "email",
+                "name",
+                "password"
+            ],
             "properties": {
                 "email": {
                     "description": "unique email address",
@@ -779,17 +1674,100 @@ var doc = `{
                 },
                 "name": {
                     "description": "fullname",
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 500
                 },
                 "password": {
                     "description": "minimum 8 bytes maximum 72 bytes",
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
+                }
+            }
+        },
+        "dto.ExportMovieResponse": {
+            "type": "object",
+            "properties": {
+                "movies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MovieResponse"
+                    }
+                }
+            }
+        },
+        "dto.ImportJob": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "description": "first 100 invalid lines",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ImportLineError"
+                    }
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "description": "csv|ndjson",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "inserted": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "pending|running|completed|failed",
                     "type": "string"
                 }
             }
         },
+        "dto.ImportJobResponse": {
+            "type": "object",
+            "properties": {
+                "import_job": {
+                    "$ref": "#/definitions/dto.ImportJob"
+                }
+            }
+        },
+        "dto.ImportLineError": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "line": {
+                    "type": "integer"
+                }
+            }
+        },
         "dto.ListMovieResponse": {
             "type": "object",
             "properties": {
+                "facets": {
+                    "$ref": "#/definitions/data.Facets"
+                },
                 "metadata": {
                     "$ref": "#/definitions/data.Metadata"
                 },
@@ -803,25 +1781,41 @@ var doc = `{
         },
         "dto.MovieRequest": {
             "type": "object",
+            "required": [
+                "genres",
+                "runtime",
+                "title",
+                "year"
+            ],
             "properties": {
                 "genres": {
                     "description": "unique genres e.g action,adventure... maximum 5 genres",
                     "type": "array",
+                    "maxItems": 5,
+                    "minItems": 1,
+                    "uniqueItems": true,
                     "items": {
                         "type": "string"
                     }
                 },
+                "language": {
+                    "description": "text search language of the title e.g. english, simple (no stemming) by default",
+                    "type": "string"
+                },
                 "runtime": {
                     "description": "e.g 98 mins",
-                    "type": "integer"
+                    "type": "integer",
+                    "minimum": 1
                 },
                 "title": {
                     "description": "title for the movie, max length 500",
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 500
                 },
                 "year": {
                     "description": "published year e.g. 2021, must not be in the future",
-                    "type": "integer"
+                    "type": "integer",
+                    "minimum": 1888
                 }
             }
         },
@@ -834,12 +1828,26 @@ var doc = `{
                         "type": "string"
                     }
                 },
+                "highlight": {
+                    "description": "HTML escaped title with the matches of the title search in \u003cmark\u003e tags",
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "language": {
+                    "type": "string"
+                },
                 "runtime": {
                     "type": "integer"
                 },
+                "similar": {
+                    "description": "movies sharing a genre, with include=similar",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MovieResponse"
+                    }
+                },
                 "title": {
                     "type": "string"
                 },
@@ -851,6 +1859,17 @@ var doc = `{
                 }
             }
         },
+        "dto.MovieSuggestion": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
         "dto.SingleMovieResponse": {
             "type": "object",
             "properties": {
@@ -867,6 +1886,17 @@ var doc = `{
                 }
             }
         },
+        "dto.SuggestMovieResponse": {
+            "type": "object",
+            "properties": {
+                "suggestions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MovieSuggestion"
+                    }
+                }
+            }
+        },
         "dto.Token": {
             "type": "object",
             "properties": {
@@ -886,6 +1916,34 @@ var doc = `{
                 }
             }
         },
+        "dto.UpdateMovieRequest": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "description": "unique genres e.g action,adventure... maximum 5 genres",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "language": {
+                    "description": "text search language of the title e.g. english, simple (no stemming) by default",
+                    "type": "string"
+                },
+                "runtime": {
+                    "description": "e.g 98 mins",
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "title for the movie, max length 500",
+                    "type": "string"
+                },
+                "year": {
+                    "description": "published year e.g. 2021, must not be in the future",
+                    "type": "integer"
+                }
+            }
+        },
         "dto.UserResponse": {
             "type": "object",
             "properties": {
@@ -912,67 +1970,60 @@ var doc = `{
         "dto.ValidationError": {
             "type": "object",
             "properties": {
+                "details": {
+                    "description": "every failed check with its code and parameters",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validator.FieldError"
+                    }
+                },
                 "errors": {
+                    "description": "first message of every field",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "validator.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "rule that failed e.g. max",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "params": {
+                    "description": "parameters of the rule e.g. max: 500",
                     "type": "object",
                     "additionalProperties": {
                         "type": "string"
                     }
+                },
+                "pointer": {
+                    "description": "JSON pointer to the field e.g. /genres/2",
+                    "type": "string"
                 }
             }
         }
     }
 }`
 
-type swaggerInfo struct {
-	Version     string
-	Host        string
-	BasePath    string
-	Schemes     []string
-	Title       string
-	Description string
-}
-
 // SwaggerInfo holds exported Swagger Info so clients can modify it
-var SwaggerInfo = swaggerInfo{
-	Version:     "1.0.0",
-	Host:        "localhost:4000",
-	BasePath:    "/v1",
-	Schemes:     []string{},
-	Title:       "Greenlight API documentation",
-	Description: "",
-}
-
-type s struct{}
-
-func (s *s) ReadDoc() string {
-	sInfo := SwaggerInfo
-	sInfo.Description = strings.Replace(sInfo.Description, "\n", "\\n", -1)
-
-	t, err := template.New("swagger_info").Funcs(template.FuncMap{
-		"marshal": func(v interface{}) string {
-			a, _ := json.Marshal(v)
-			return string(a)
-		},
-		"escape": func(v interface{}) string {
-			// escape tabs
-			str := strings.Replace(v.(string), "\t", "\\t", -1)
-			// replace " with \", and if that results in \\", replace that with \\\"
-			str = strings.Replace(str, "\"", "\\\"", -1)
-			return strings.Replace(str, "\\\\\"", "\\\\\\\"", -1)
-		},
-	}).Parse(doc)
-	if err != nil {
-		return doc
-	}
-
-	var tpl bytes.Buffer
-	if err := t.Execute(&tpl, sInfo); err != nil {
-		return doc
-	}
-
-	return tpl.String()
+var SwaggerInfo = &swag.Spec{
+	Version:          "1.0.0",
+	Host:             "localhost:4000",
+	BasePath:         "/v1",
+	Schemes:          []string{},
+	Title:            "Greenlight API documentation",
+	Description:      "",
+	InfoInstanceName: "swagger",
+	SwaggerTemplate:  docTemplate,
 }
 
 func init() {
-	swag.Register(swag.Name, &s{})
+	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
 }
@@ -1,15 +1,32 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/terdia/greenlight/internal/custom_type"
 	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/filter"
+	"github.com/terdia/greenlight/internal/validator"
 )
 
+// MovieRequest is checked against its validate tags with the rules of data.Rules, swag documents
+// their bounds in the API docs.
 type MovieRequest struct {
-	Title   *string              `json:"title"`   //title for the movie, max length 500
-	Year    *int32               `json:"year"`    // published year e.g. 2021, must not be in the future
-	Runtime *custom_type.Runtime `json:"runtime"` // e.g 98 mins
-	Genres  []string             `json:"genres"`  // unique genres e.g action,adventure... maximum 5 genres
+	Title    *string              `json:"title" validate:"required,max=500"`                                                                                                                //title for the movie, max length 500
+	Year     *int32               `json:"year" validate:"required,min=1888,notfuture" messages:"min=must be greater than 1888"`                                                             // published year e.g. 2021, must not be in the future
+	Runtime  *custom_type.Runtime `json:"runtime" validate:"required,min=1" messages:"min=must be a positive integer"`                                                                      // e.g 98 mins
+	Genres   []string             `json:"genres" validate:"required,min=1,max=5,unique,dive,required" messages:"min=must contain at least 1 genre;max=must not contain more than 5 genres"` // unique genres e.g action,adventure... maximum 5 genres
+	Language *string              `json:"language,omitempty" validate:"language"`                                                                                                           // text search language of the title e.g. english, simple (no stemming) by default
+}
+
+// UpdateMovieRequest is the partial movie of an update, its fields are optional and the movie is
+// checked against the rules of MovieRequest once updated.
+type UpdateMovieRequest struct {
+	Title    *string              `json:"title,omitempty"`    //title for the movie, max length 500
+	Year     *int32               `json:"year,omitempty"`     // published year e.g. 2021, must not be in the future
+	Runtime  *custom_type.Runtime `json:"runtime,omitempty"`  // e.g 98 mins
+	Genres   []string             `json:"genres,omitempty"`   // unique genres e.g action,adventure... maximum 5 genres
+	Language *string              `json:"language,omitempty"` // text search language of the title e.g. english, simple (no stemming) by default
 }
 
 type SingleMovieResponse struct {
@@ -17,25 +34,102 @@ type SingleMovieResponse struct {
 }
 
 type MovieResponse struct {
-	ID      custom_type.ID      `json:"id"`
-	Title   string              `json:"title"`
-	Year    int32               `json:"year,omitempty"`
-	Runtime custom_type.Runtime `json:"runtime,omitempty"`
-	Genres  []string            `json:"genres,omitempty"`
-	Version int32               `json:"version"`
+	ID        custom_type.ID      `json:"id"`
+	Title     string              `json:"title"`
+	Year      int32               `json:"year,omitempty"`
+	Runtime   custom_type.Runtime `json:"runtime,omitempty"`
+	Genres    []string            `json:"genres,omitempty"`
+	Language  string              `json:"language,omitempty"`
+	Version   int32               `json:"version"`
+	Highlight string              `json:"highlight,omitempty"` // HTML escaped title with the matches of the title search in <mark> tags
+	Similar   []MovieResponse     `json:"similar,omitempty"`   // movies sharing a genre, with include=similar
 }
 
 type ListMovieRequest struct {
-	Title   string
-	Genres  []string
-	Filters data.Filters
+	Title         string
+	SearchMode    string // fulltext (default), prefix or fuzzy search of Title
+	Language      string // only movies in the language, when set
+	Genres        []string
+	GenresMode    string // all (default), any or none of Genres
+	YearMin       int
+	YearMax       int
+	RuntimeMin    int
+	RuntimeMax    int
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Filter        filter.Expr // parsed filter query parameter, nil when not set
+	Filters       data.Filters
 }
 
 type ListMovieResponse struct {
 	Metadata data.Metadata   `json:"metadata"`
 	Movies   []MovieResponse `json:"movies"`
+	Facets   data.Facets     `json:"facets,omitempty"`
 }
 
 type ValidationError struct {
+	Errors  map[string]string      `json:"errors"`            // first message of every field
+	Details []validator.FieldError `json:"details,omitempty"` // every failed check with its code and parameters
+}
+
+type BatchMovieRequest struct {
+	Mode       string                `json:"mode"`       // atomic (default) or per_item
+	Operations []BatchMovieOperation `json:"operations"` // maximum 100 operations
+}
+
+type BatchMovieOperation struct {
+	Op    string         `json:"op"`           // create|update|delete
+	ID    custom_type.ID `json:"id,omitempty"` // required for update and delete
+	Movie MovieRequest   `json:"movie"`        // full movie for create, changed fields for update
+}
+
+type BatchMovieResponse struct {
+	Mode      string             `json:"mode"`
+	Committed bool               `json:"committed"`
+	Results   []BatchMovieResult `json:"results"`
+}
+
+type BatchMovieResult struct {
+	Index   int                       `json:"index"`
+	Op      string                    `json:"op"`
+	Status  custom_type.StatusMessage `json:"status"` //(success|fail|error)
+	Message string                    `json:"message,omitempty"`
+	Errors  map[string]string         `json:"errors,omitempty"`
+	Movie   *MovieResponse            `json:"movie,omitempty"`
+}
+
+type SuggestMovieResponse struct {
+	Suggestions []MovieSuggestion `json:"suggestions"`
+}
+
+type MovieSuggestion struct {
+	ID    custom_type.ID `json:"id"`
+	Title string         `json:"title"`
+}
+
+type ImportJobResponse struct {
+	Job ImportJob `json:"import_job"`
+}
+
+type ImportJob struct {
+	ID         custom_type.ID    `json:"id"`
+	Format     string            `json:"format"` // csv|ndjson
+	DryRun     bool              `json:"dry_run"`
+	Status     string            `json:"status"` // pending|running|completed|failed
+	Processed  int               `json:"processed"`
+	Inserted   int               `json:"inserted"`
+	Invalid    int               `json:"invalid"`
+	Errors     []ImportLineError `json:"errors,omitempty"` // first 100 invalid lines
+	Message    string            `json:"message,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+type ImportLineError struct {
+	Line   int               `json:"line"`
 	Errors map[string]string `json:"errors"`
 }
+
+type ExportMovieResponse struct {
+	Movies []MovieResponse `json:"movies"`
+}
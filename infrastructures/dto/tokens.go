@@ -14,6 +14,6 @@ type Token struct {
 }
 
 type AuthTokenRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
 }
@@ -7,9 +7,9 @@ import (
 )
 
 type CreateUserRequest struct {
-	Name     string `json:"name"`     // fullname
-	Email    string `json:"email"`    // unique email address
-	Password string `json:"password"` // minimum 8 bytes maximum 72 bytes
+	Name     string `json:"name" validate:"required,max=500"`          // fullname
+	Email    string `json:"email" validate:"required,email"`           // unique email address
+	Password string `json:"password" validate:"required,min=8,max=72"` // minimum 8 bytes maximum 72 bytes
 }
 
 type SingleUserResponse struct {
@@ -17,7 +17,7 @@ type SingleUserResponse struct {
 }
 
 type ActivateUserRequest struct {
-	TokenPlaintext string `json:"token"`
+	TokenPlaintext string `json:"token" validate:"required,len=26"`
 }
 
 type UserResponse struct {
@@ -0,0 +1,21 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/terdia/greenlight/internal/repositorytest"
+	"github.com/terdia/greenlight/internal/unitofwork"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) unitofwork.Repositories {
+		store := NewStore()
+
+		return unitofwork.Repositories{
+			Movies:      NewMovieRepository(store),
+			Users:       NewUserRepository(store),
+			Tokens:      NewTokenRepository(store),
+			Permissions: NewPermissionRepository(store),
+		}
+	})
+}
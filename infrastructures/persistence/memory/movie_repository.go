@@ -0,0 +1,573 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/movies/repositories"
+)
+
+type movieRepository struct {
+	conn conn
+}
+
+func NewMovieRepository(store *Store) repositories.MovieRepository {
+	return &movieRepository{conn: store}
+}
+
+func (repo *movieRepository) WithinTransaction(ctx context.Context, fn func(repo repositories.MovieRepository) error) error {
+	return runInTransaction(ctx, repo.conn, func(tx conn) error {
+		return fn(&movieRepository{conn: tx})
+	})
+}
+
+func (repo *movieRepository) Insert(ctx context.Context, movie *entities.Movie) error {
+	return repo.conn.run(ctx, func(t *tables) error {
+		repo.insert(t, movie)
+		return nil
+	})
+}
+
+func (repo *movieRepository) insert(t *tables, movie *entities.Movie) {
+
+	movie.ID = custom_type.ID(repo.conn.root().nextMovieID())
+	movie.CreatedAt = time.Now().Truncate(time.Second)
+	movie.Version = 1
+
+	if movie.Language == "" {
+		movie.Language = data.DefaultLanguage
+	}
+
+	t.movies[int64(movie.ID)] = copyMovie(movie)
+}
+
+// CopyFrom inserts the movies in one go, like COPY it doesn't set the ID, CreatedAt and Version
+// of the movies.
+func (repo *movieRepository) CopyFrom(ctx context.Context, movies []*entities.Movie) (int64, error) {
+
+	err := repo.conn.run(ctx, func(t *tables) error {
+		for _, movie := range movies {
+			movie := *movie
+			repo.insert(t, &movie)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(movies)), nil
+}
+
+func (repo *movieRepository) Get(ctx context.Context, id int64) (*entities.Movie, error) {
+
+	var movie entities.Movie
+
+	err := repo.conn.run(ctx, func(t *tables) error {
+		stored, ok := t.movies[id]
+		if !ok {
+			return data.ErrRecordNotFound
+		}
+
+		movie = copyMovie(&stored)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &movie, nil
+}
+
+// Update saves the movie when its version is still the stored one, and returns
+// data.ErrEditConflict otherwise.
+func (repo *movieRepository) Update(ctx context.Context, movie *entities.Movie) error {
+	return repo.conn.run(ctx, func(t *tables) error {
+		stored, ok := t.movies[int64(movie.ID)]
+		if !ok || stored.Version != movie.Version {
+			return data.ErrEditConflict
+		}
+
+		updated := copyMovie(movie)
+		updated.CreatedAt = stored.CreatedAt
+		updated.Version++
+
+		t.movies[int64(movie.ID)] = updated
+		movie.Version = updated.Version
+
+		return nil
+	})
+}
+
+func (repo *movieRepository) Delete(ctx context.Context, id int64) error {
+	return repo.conn.run(ctx, func(t *tables) error {
+		if _, ok := t.movies[id]; !ok {
+			return data.ErrRecordNotFound
+		}
+
+		delete(t.movies, id)
+
+		return nil
+	})
+}
+
+// searchResult is a movie matching the filters of a list request, with the relevance of its
+// title to the title search.
+type searchResult struct {
+	movie     *entities.Movie
+	relevance float64
+}
+
+// GetAll returns a page of movies, either by page number or, when the filters hold an
+// after/before cursor, the movies after or before the cursor in the sort order.
+func (repo *movieRepository) GetAll(ctx context.Context, r dto.ListMovieRequest) ([]*entities.Movie, data.Metadata, error) {
+
+	filters := r.Filters
+
+	results, err := repo.search(ctx, r)
+	if err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	// id is always the last sort key so the order, and the cursors, are unique
+	keys := append(filters.SortKeys(), data.SortKey{Column: "id", Direction: "ASC"})
+
+	cursor, paginateByCursor := filters.Cursor()
+	backward := filters.Before != ""
+
+	// Reading backward, the movies before the cursor are read in reverse order and
+	// flipped once found.
+	if backward {
+		reverse := map[string]string{"ASC": "DESC", "DESC": "ASC"}
+		for i, key := range keys {
+			keys[i].Direction = reverse[key.Direction]
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return compareResults(keys, sortValues(keys, results[i]), sortValues(keys, results[j])) < 0
+	})
+
+	totalRecords := 0
+	if filters.IncludeTotal {
+		totalRecords = len(results)
+	}
+
+	if paginateByCursor {
+		values := make([]interface{}, 0, len(keys))
+		for i, key := range keys[:len(keys)-1] {
+			values = append(values, parseSortValue(key.Column, cursor.Values[i]))
+		}
+		values = append(values, cursor.ID)
+
+		after := sort.Search(len(results), func(i int) bool {
+			return compareResults(keys, sortValues(keys, results[i]), values) > 0
+		})
+
+		results = results[after:]
+	}
+
+	if offset := filters.Offset(); offset < len(results) {
+		results = results[offset:]
+	} else {
+		results = nil
+	}
+
+	// one extra movie is kept to find out whether there is a next page
+	if len(results) > filters.Limit()+1 {
+		results = results[:filters.Limit()+1]
+	}
+
+	hasMore := len(results) > filters.Limit()
+	if hasMore {
+		results = results[:filters.Limit()]
+	}
+
+	if backward {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+
+	movies := make([]*entities.Movie, 0, len(results))
+	for _, result := range results {
+		movies = append(movies, result.movie)
+	}
+
+	// like the count read with the rows of the page, the total is 0 for an empty page
+	if len(movies) == 0 {
+		totalRecords = 0
+	}
+
+	var metadata data.Metadata
+
+	switch {
+	case paginateByCursor:
+		metadata = data.Metadata{PageSize: filters.PageSize, TotalRecords: totalRecords}
+	case filters.IncludeTotal:
+		metadata = data.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	default:
+		metadata = data.Metadata{CurrentPage: filters.Page, PageSize: filters.PageSize, FirstPage: 1}
+	}
+
+	if len(results) > 0 {
+		first, last := results[0], results[len(results)-1]
+
+		if (backward && hasMore) || (!backward && (paginateByCursor || filters.Offset() > 0)) {
+			metadata.PrevCursor = data.Cursor{Sort: filters.Sort, Values: cursorValues(keys, first), ID: int64(first.movie.ID)}.Encode()
+		}
+
+		if (!backward && hasMore) || backward {
+			metadata.NextCursor = data.Cursor{Sort: filters.Sort, Values: cursorValues(keys, last), ID: int64(last.movie.ID)}.Encode()
+		}
+	}
+
+	return movies, metadata, nil
+}
+
+// search returns the movies matching the filters of the request in no particular order, with
+// their title highlighted for a title search.
+func (repo *movieRepository) search(ctx context.Context, r dto.ListMovieRequest) ([]searchResult, error) {
+
+	var results []searchResult
+
+	err := repo.conn.run(ctx, func(t *tables) error {
+		for _, stored := range t.movies {
+			if !matchMovie(r, &stored) {
+				continue
+			}
+
+			relevance, ok := searchTitle(r, stored.Title)
+			if !ok {
+				continue
+			}
+
+			movie := copyMovie(&stored)
+			movie.Highlight = highlightTitle(r, movie.Title)
+
+			results = append(results, searchResult{movie: &movie, relevance: relevance})
+		}
+
+		return nil
+	})
+
+	return results, err
+}
+
+// Facets counts the movies matching the filters of the request by each value of the facets,
+// genres by descending count, decades and runtime buckets in ascending order.
+func (repo *movieRepository) Facets(ctx context.Context, r dto.ListMovieRequest, facets []string) (data.Facets, error) {
+
+	result := data.Facets{}
+
+	for _, facet := range facets {
+		switch facet {
+		case data.FacetGenres, data.FacetDecade, data.FacetRuntimeBucket:
+			result[facet] = []data.FacetCount{}
+		default:
+			return nil, fmt.Errorf("unknown facet %q", facet)
+		}
+	}
+
+	if len(facets) == 0 {
+		return result, nil
+	}
+
+	results, err := repo.search(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	genres := map[string]int{}
+	decades := map[int32]int{}
+	buckets := make([]int, len(runtimeBuckets))
+
+	for _, found := range results {
+		for _, genre := range found.movie.Genres {
+			genres[genre]++
+		}
+
+		decades[found.movie.Year/10]++
+		buckets[runtimeBucket(found.movie.Runtime)]++
+	}
+
+	if _, ok := result[data.FacetGenres]; ok {
+		counts := result[data.FacetGenres]
+		for genre, count := range genres {
+			counts = append(counts, data.FacetCount{Value: genre, Count: count})
+		}
+
+		sort.Slice(counts, func(i, j int) bool {
+			if counts[i].Count != counts[j].Count {
+				return counts[i].Count > counts[j].Count
+			}
+			return counts[i].Value < counts[j].Value
+		})
+
+		result[data.FacetGenres] = counts
+	}
+
+	if _, ok := result[data.FacetDecade]; ok {
+		keys := make([]int32, 0, len(decades))
+		for decade := range decades {
+			keys = append(keys, decade)
+		}
+
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+		for _, decade := range keys {
+			result[data.FacetDecade] = append(result[data.FacetDecade], data.FacetCount{Value: fmt.Sprintf("%ds", decade*10), Count: decades[decade]})
+		}
+	}
+
+	if _, ok := result[data.FacetRuntimeBucket]; ok {
+		for i, count := range buckets {
+			if count > 0 {
+				result[data.FacetRuntimeBucket] = append(result[data.FacetRuntimeBucket], data.FacetCount{Value: runtimeBuckets[i], Count: count})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// runtimeBuckets are the values of the runtime_bucket facet, split at 90, 120 and 150 minutes.
+var runtimeBuckets = []string{"0-89", "90-119", "120-149", "150+"}
+
+func runtimeBucket(runtime custom_type.Runtime) int {
+	switch {
+	case runtime < 90:
+		return 0
+	case runtime < 120:
+		return 1
+	case runtime < 150:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Export calls fn for every movie matching the filters of the request, ordered by id. The
+// movies are those stored when the export starts.
+func (repo *movieRepository) Export(ctx context.Context, r dto.ListMovieRequest, fn func(movie *entities.Movie) error) error {
+
+	results, err := repo.search(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].movie.ID < results[j].movie.ID
+	})
+
+	for _, result := range results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result.movie.Highlight = ""
+
+		if err := fn(result.movie); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchMovie reports whether the movie matches the filters of the request, but the title search.
+func matchMovie(r dto.ListMovieRequest, movie *entities.Movie) bool {
+
+	if r.Language != "" && movie.Language != r.Language {
+		return false
+	}
+
+	if len(r.Genres) > 0 {
+		shared := 0
+		for _, genre := range r.Genres {
+			if containsString(movie.Genres, genre) {
+				shared++
+			}
+		}
+
+		switch r.GenresMode {
+		case data.GenresModeAny:
+			if shared == 0 {
+				return false
+			}
+		case data.GenresModeNone:
+			if shared > 0 {
+				return false
+			}
+		default:
+			if shared < len(r.Genres) {
+				return false
+			}
+		}
+	}
+
+	switch {
+	case r.YearMin != 0 && int(movie.Year) < r.YearMin,
+		r.YearMax != 0 && int(movie.Year) > r.YearMax,
+		r.RuntimeMin != 0 && int(movie.Runtime) < r.RuntimeMin,
+		r.RuntimeMax != 0 && int(movie.Runtime) > r.RuntimeMax,
+		!r.CreatedAfter.IsZero() && !movie.CreatedAt.After(r.CreatedAfter),
+		!r.CreatedBefore.IsZero() && !movie.CreatedAt.Before(r.CreatedBefore):
+		return false
+	}
+
+	if r.Filter != nil {
+		return r.Filter.Match(func(column string) interface{} {
+			return movieColumn(movie, column)
+		})
+	}
+
+	return true
+}
+
+// movieColumn returns the value of a column of the movies table for the filter expressions.
+func movieColumn(movie *entities.Movie, column string) interface{} {
+
+	switch column {
+	case "id":
+		return int64(movie.ID)
+	case "title":
+		return movie.Title
+	case "year":
+		return int64(movie.Year)
+	case "runtime":
+		return int64(movie.Runtime)
+	case "genres":
+		return movie.Genres
+	case "language":
+		return movie.Language
+	case "created_at":
+		return movie.CreatedAt
+	default:
+		panic("unknown movies column " + column)
+	}
+}
+
+// sortValues returns the values of the sort keys of a result, the last key is always the id.
+func sortValues(keys []data.SortKey, result searchResult) []interface{} {
+
+	values := make([]interface{}, 0, len(keys))
+
+	for _, key := range keys {
+		if key.Column == "relevance" {
+			values = append(values, result.relevance)
+		} else {
+			values = append(values, movieColumn(result.movie, key.Column))
+		}
+	}
+
+	return values
+}
+
+// cursorValues returns the values of the sort keys of a result but the id as text, for a cursor.
+func cursorValues(keys []data.SortKey, result searchResult) []string {
+
+	values := sortValues(keys[:len(keys)-1], result)
+
+	text := make([]string, len(values))
+	for i, value := range values {
+		switch value := value.(type) {
+		case float64:
+			text[i] = strconv.FormatFloat(value, 'g', -1, 64)
+		default:
+			text[i] = fmt.Sprint(value)
+		}
+	}
+
+	return text
+}
+
+// parseSortValue parses a value of a cursor read from the text of cursorValues.
+func parseSortValue(column, text string) interface{} {
+
+	switch column {
+	case "title":
+		return text
+	case "relevance":
+		value, _ := strconv.ParseFloat(text, 64)
+		return value
+	default:
+		value, _ := strconv.ParseInt(text, 10, 64)
+		return value
+	}
+}
+
+// compareResults compares the values of the sort keys of two results in the order of the keys.
+func compareResults(keys []data.SortKey, a, b []interface{}) int {
+
+	for i, key := range keys {
+		order := compareValues(a[i], b[i])
+
+		if key.Direction == "DESC" {
+			order = -order
+		}
+
+		if order != 0 {
+			return order
+		}
+	}
+
+	return 0
+}
+
+func compareValues(a, b interface{}) int {
+
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func copyMovie(movie *entities.Movie) entities.Movie {
+
+	copied := *movie
+	copied.Genres = append([]string(nil), movie.Genres...)
+	copied.Highlight = ""
+
+	return copied
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/filter"
+	"github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/movies/repositories"
+)
+
+var sortSafelist = []string{"id", "title", "year", "runtime", "relevance", "-id", "-title", "-year", "-runtime", "-relevance"}
+
+func newTestRepository(t *testing.T) repositories.MovieRepository {
+
+	repo := NewMovieRepository(NewStore())
+
+	movies := []entities.Movie{
+		{Title: "Casablanca", Year: 1942, Runtime: 102, Genres: []string{"drama", "romance"}},
+		{Title: "Alien", Year: 1979, Runtime: 117, Genres: []string{"horror", "sci-fi"}},
+		{Title: "Aliens", Year: 1986, Runtime: 137, Genres: []string{"action", "sci-fi"}},
+		{Title: "The Breakfast Club", Year: 1985, Runtime: 97, Genres: []string{"comedy", "drama"}},
+		{Title: "Heat", Year: 1995, Runtime: 170, Genres: []string{"action", "crime", "drama"}},
+	}
+
+	for i := range movies {
+		if err := repo.Insert(context.Background(), &movies[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return repo
+}
+
+func titles(movies []*entities.Movie) []string {
+	result := []string{}
+	for _, movie := range movies {
+		result = append(result, movie.Title)
+	}
+
+	return result
+}
+
+func listRequest(r dto.ListMovieRequest, sort string) dto.ListMovieRequest {
+	r.Filters = data.Filters{Page: 1, PageSize: 20, Sort: sort, SortSafelist: sortSafelist, IncludeTotal: true}
+	return r
+}
+
+func TestGetAll(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		request    dto.ListMovieRequest
+		wantTitles []string
+	}{
+		{
+			name:       "Sort by year",
+			request:    listRequest(dto.ListMovieRequest{}, "-year"),
+			wantTitles: []string{"Heat", "Aliens", "The Breakfast Club", "Alien", "Casablanca"},
+		},
+		{
+			name:       "All genres",
+			request:    listRequest(dto.ListMovieRequest{Genres: []string{"action", "drama"}}, "id"),
+			wantTitles: []string{"Heat"},
+		},
+		{
+			name:       "Any genre",
+			request:    listRequest(dto.ListMovieRequest{Genres: []string{"romance", "horror"}, GenresMode: data.GenresModeAny}, "id"),
+			wantTitles: []string{"Casablanca", "Alien"},
+		},
+		{
+			name:       "No genre",
+			request:    listRequest(dto.ListMovieRequest{Genres: []string{"drama"}, GenresMode: data.GenresModeNone}, "id"),
+			wantTitles: []string{"Alien", "Aliens"},
+		},
+		{
+			name:       "Year and runtime ranges",
+			request:    listRequest(dto.ListMovieRequest{YearMin: 1980, RuntimeMax: 140}, "id"),
+			wantTitles: []string{"Aliens", "The Breakfast Club"},
+		},
+		{
+			name: "Filter expression",
+			request: listRequest(dto.ListMovieRequest{
+				Filter: &filter.Logical{
+					Op:    "OR",
+					Left:  &filter.Comparison{Field: filter.Field{Column: "year", Type: filter.Number}, Op: "<", Value: int64(1950)},
+					Right: &filter.Comparison{Field: filter.Field{Column: "title", Type: filter.Text}, Op: "~", Value: "CLUB"},
+				},
+			}, "id"),
+			wantTitles: []string{"Casablanca", "The Breakfast Club"},
+		},
+		{
+			name:       "Fulltext search",
+			request:    listRequest(dto.ListMovieRequest{Title: "alien"}, "id"),
+			wantTitles: []string{"Alien"},
+		},
+		{
+			name:       "Prefix search by relevance",
+			request:    listRequest(dto.ListMovieRequest{Title: "the br", SearchMode: data.SearchModePrefix}, "-relevance"),
+			wantTitles: []string{"The Breakfast Club"},
+		},
+		{
+			name:       "Fuzzy search",
+			request:    listRequest(dto.ListMovieRequest{Title: "aliens", SearchMode: data.SearchModeFuzzy}, "-relevance"),
+			wantTitles: []string{"Aliens", "Alien"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepository(t)
+
+			movies, metadata, err := repo.GetAll(context.Background(), test.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := titles(movies); !reflect.DeepEqual(got, test.wantTitles) {
+				t.Errorf("want movies %v; got %v", test.wantTitles, got)
+			}
+
+			if metadata.TotalRecords != len(test.wantTitles) {
+				t.Errorf("want %d total records; got %d", len(test.wantTitles), metadata.TotalRecords)
+			}
+		})
+	}
+}
+
+func TestGetAllHighlight(t *testing.T) {
+
+	repo := newTestRepository(t)
+
+	err := repo.Insert(context.Background(), &entities.Movie{Title: "<b>Fight</b> Club & Co", Year: 1999, Runtime: 139, Genres: []string{"drama"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	movies, _, err := repo.GetAll(context.Background(), listRequest(dto.ListMovieRequest{Title: "club"}, "id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"The Breakfast <mark>Club</mark>", "&lt;b&gt;Fight&lt;/b&gt; <mark>Club</mark> &amp; Co"}
+
+	if len(movies) != len(want) {
+		t.Fatalf("want %d movies; got %v", len(want), titles(movies))
+	}
+
+	for i, movie := range movies {
+		if movie.Highlight != want[i] {
+			t.Errorf("want highlight %q; got %q", want[i], movie.Highlight)
+		}
+	}
+}
+
+func TestGetAllPagination(t *testing.T) {
+
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	request := listRequest(dto.ListMovieRequest{}, "-runtime")
+	request.Filters.PageSize = 2
+
+	var pages [][]string
+
+	for {
+		movies, metadata, err := repo.GetAll(ctx, request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pages = append(pages, titles(movies))
+
+		if metadata.NextCursor == "" {
+			break
+		}
+
+		request.Filters.After = metadata.NextCursor
+	}
+
+	want := [][]string{{"Heat", "Aliens"}, {"Alien", "Casablanca"}, {"The Breakfast Club"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Fatalf("want pages %v; got %v", want, pages)
+	}
+
+	// the first page is read backward from the first movie of the second page
+	request.Filters.After = ""
+	request.Filters.Page = 2
+
+	movies, metadata, err := repo.GetAll(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Filters.Page = 1
+	request.Filters.Before = metadata.PrevCursor
+
+	movies, _, err = repo.GetAll(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := titles(movies); !reflect.DeepEqual(got, want[0]) {
+		t.Errorf("want previous page %v; got %v", want[0], got)
+	}
+}
+
+func TestUpdateVersion(t *testing.T) {
+
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	movie, err := repo.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale := *movie
+
+	movie.Runtime = custom_type.Runtime(103)
+	if err := repo.Update(ctx, movie); err != nil {
+		t.Fatal(err)
+	}
+
+	if movie.Version != 2 {
+		t.Errorf("want version 2; got %d", movie.Version)
+	}
+
+	if err := repo.Update(ctx, &stale); !errors.Is(err, data.ErrEditConflict) {
+		t.Errorf("want error %v; got %v", data.ErrEditConflict, err)
+	}
+}
+
+func TestFacets(t *testing.T) {
+
+	repo := newTestRepository(t)
+
+	facets, err := repo.Facets(context.Background(), dto.ListMovieRequest{Genres: []string{"sci-fi"}}, []string{data.FacetGenres, data.FacetDecade, data.FacetRuntimeBucket})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := data.Facets{
+		data.FacetGenres:        {{Value: "sci-fi", Count: 2}, {Value: "action", Count: 1}, {Value: "horror", Count: 1}},
+		data.FacetDecade:        {{Value: "1970s", Count: 1}, {Value: "1980s", Count: 1}},
+		data.FacetRuntimeBucket: {{Value: "90-119", Count: 1}, {Value: "120-149", Count: 1}},
+	}
+
+	if !reflect.DeepEqual(facets, want) {
+		t.Errorf("want facets %v; got %v", want, facets)
+	}
+}
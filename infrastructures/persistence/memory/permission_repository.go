@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/src/users/repositories"
+)
+
+type permissionRepository struct {
+	conn conn
+}
+
+func NewPermissionRepository(store *Store) repositories.PermissionRepository {
+	return &permissionRepository{conn: store}
+}
+
+func (p *permissionRepository) GetAllForUser(ctx context.Context, userID custom_type.ID) (data.Permissions, error) {
+
+	var permissions data.Permissions
+
+	err := p.conn.run(ctx, func(t *tables) error {
+		for _, code := range data.PermissionCodes {
+			if t.userPermissions[userPermission{userID: int64(userID), code: code}] {
+				permissions = append(permissions, code)
+			}
+		}
+
+		return nil
+	})
+
+	return permissions, err
+}
+
+// AddForUser grants the permissions to the user, unknown codes are ignored.
+func (p *permissionRepository) AddForUser(ctx context.Context, userID custom_type.ID, codes ...string) error {
+	return p.conn.run(ctx, func(t *tables) error {
+		for _, code := range codes {
+			if containsString(data.PermissionCodes, code) {
+				t.userPermissions[userPermission{userID: int64(userID), code: code}] = true
+			}
+		}
+
+		return nil
+	})
+}
+
+// RemoveForUser revokes the permissions of the user, permissions the user doesn't have are
+// ignored.
+func (p *permissionRepository) RemoveForUser(ctx context.Context, userID custom_type.ID, codes ...string) error {
+	return p.conn.run(ctx, func(t *tables) error {
+		for _, code := range codes {
+			delete(t.userPermissions, userPermission{userID: int64(userID), code: code})
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/trigram"
+)
+
+// searchTitle reports whether the title matches the title search of the request and how well.
+// It works like the PostgreSQL search without stemming and stop words: every word of a fulltext
+// search must be a word of the title and every word of a prefix search the prefix of a word of
+// the title, the relevance is the fraction of the words of the title matching the search. A
+// fuzzy search matches titles similar enough to the search, the relevance is the similarity.
+func searchTitle(r dto.ListMovieRequest, title string) (relevance float64, ok bool) {
+
+	if r.Title == "" {
+		return 0, true
+	}
+
+	if r.SearchMode == data.SearchModeFuzzy {
+		relevance = trigram.Similarity(title, r.Title)
+		return relevance, relevance >= trigram.Threshold
+	}
+
+	search := words(r.Title)
+	if len(search) == 0 {
+		return 0, false
+	}
+
+	titleWords := words(title)
+
+	for _, word := range search {
+		found := false
+
+		for _, titleWord := range titleWords {
+			if matchWord(r, titleWord, word) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return 0, false
+		}
+	}
+
+	matched := 0
+	for _, titleWord := range titleWords {
+		if matchSearch(r, titleWord, search) {
+			matched++
+		}
+	}
+
+	return float64(matched) / float64(len(titleWords)), true
+}
+
+// highlightTitle returns the HTML escaped title with the words matching the title search in
+// <mark> tags, or an empty string when the request has no title search. Words of a fuzzy search
+// are highlighted when they match exactly.
+func highlightTitle(r dto.ListMovieRequest, title string) string {
+
+	if r.Title == "" {
+		return ""
+	}
+
+	search := words(r.Title)
+
+	var b strings.Builder
+	start := -1
+
+	for i, c := range title + " " {
+		if isWordRune(c) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 {
+			word := title[start:i]
+
+			if matchSearch(r, strings.ToLower(word), search) {
+				b.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+			} else {
+				b.WriteString(html.EscapeString(word))
+			}
+
+			start = -1
+		}
+
+		if i < len(title) {
+			b.WriteString(html.EscapeString(string(c)))
+		}
+	}
+
+	return b.String()
+}
+
+func matchSearch(r dto.ListMovieRequest, titleWord string, search []string) bool {
+	for _, word := range search {
+		if matchWord(r, titleWord, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchWord(r dto.ListMovieRequest, titleWord, word string) bool {
+	if r.SearchMode == data.SearchModePrefix {
+		return strings.HasPrefix(titleWord, word)
+	}
+
+	return titleWord == word
+}
+
+// words returns the lower case words of s.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(c rune) bool {
+		return !isWordRune(c)
+	})
+}
+
+func isWordRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
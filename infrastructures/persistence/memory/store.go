@@ -0,0 +1,359 @@
+// Package memory implements the repositories in memory, for development and tests without a
+// database. Everything is lost when the process exits.
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/terdia/greenlight/internal/data"
+	movie_entities "github.com/terdia/greenlight/src/movies/entities"
+	user_entities "github.com/terdia/greenlight/src/users/entities"
+)
+
+// ErrSerializationFailure is returned by the commit of a transaction which changed a record
+// also changed by a concurrent transaction since it began, the transaction can be retried.
+var ErrSerializationFailure = errors.New("memory: could not serialize access due to concurrent update")
+
+type userPermission struct {
+	userID int64
+	code   string
+}
+
+// tables hold the records of the repositories. Records are stored by value and never modified
+// in place, so tables are cloned by copying their maps.
+type tables struct {
+	movies          map[int64]movie_entities.Movie
+	users           map[int64]user_entities.User
+	tokens          map[string]user_entities.Token // keyed by hash
+	userPermissions map[userPermission]bool
+}
+
+func newTables() *tables {
+	return &tables{
+		movies:          map[int64]movie_entities.Movie{},
+		users:           map[int64]user_entities.User{},
+		tokens:          map[string]user_entities.Token{},
+		userPermissions: map[userPermission]bool{},
+	}
+}
+
+func (t *tables) clone() *tables {
+
+	clone := &tables{
+		movies:          make(map[int64]movie_entities.Movie, len(t.movies)),
+		users:           make(map[int64]user_entities.User, len(t.users)),
+		tokens:          make(map[string]user_entities.Token, len(t.tokens)),
+		userPermissions: make(map[userPermission]bool, len(t.userPermissions)),
+	}
+
+	for id, movie := range t.movies {
+		clone.movies[id] = movie
+	}
+
+	for id, user := range t.users {
+		clone.users[id] = user
+	}
+
+	for hash, token := range t.tokens {
+		clone.tokens[hash] = token
+	}
+
+	for permission := range t.userPermissions {
+		clone.userPermissions[permission] = true
+	}
+
+	return clone
+}
+
+// userByEmail returns the user with the email, compared case insensitively like the citext
+// column of the users table.
+func (t *tables) userByEmail(email string) (user_entities.User, bool) {
+	for _, user := range t.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, true
+		}
+	}
+
+	return user_entities.User{}, false
+}
+
+// emailTaken reports whether a user other than those ignored has the email.
+func (t *tables) emailTaken(email string, ignored map[int64]bool) bool {
+	for id, user := range t.users {
+		if !ignored[id] && strings.EqualFold(user.Email, email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// conn gives exclusive access to tables, either the committed tables of the store or the
+// tables of a transaction.
+type conn interface {
+	// run calls fn with exclusive access to the tables, unless ctx is already done.
+	run(ctx context.Context, fn func(t *tables) error) error
+	// begin starts a transaction, or a savepoint when conn is a transaction.
+	begin() *transaction
+	// root returns the store, which hands out the ids of new records.
+	root() *Store
+}
+
+// Store holds the records of the in-memory repositories, it is safe for concurrent use.
+type Store struct {
+	mu     sync.Mutex
+	tables *tables
+
+	// sequences of the ids, ids of rolled back records are never reused
+	lastMovieID int64
+	lastUserID  int64
+}
+
+func NewStore() *Store {
+	return &Store{tables: newTables()}
+}
+
+func (s *Store) run(ctx context.Context, fn func(t *tables) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fn(s.tables)
+}
+
+func (s *Store) begin() *transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base := s.tables.clone()
+
+	return &transaction{store: s, base: base, tables: base.clone()}
+}
+
+func (s *Store) root() *Store {
+	return s
+}
+
+func (s *Store) nextMovieID() int64 {
+	return atomic.AddInt64(&s.lastMovieID, 1)
+}
+
+func (s *Store) nextUserID() int64 {
+	return atomic.AddInt64(&s.lastUserID, 1)
+}
+
+// commit applies the changes made by a transaction, from base to changed, to the committed
+// tables. It fails with ErrSerializationFailure when a changed record was also changed since
+// base, and with data.ErrDuplicateEmail when a changed user has the email of another user.
+func (s *Store) commit(base, changed *tables) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.tables
+
+	movies := map[int64]bool{}
+	for id, movie := range changed.movies {
+		if before, ok := base.movies[id]; !ok || before.Version != movie.Version {
+			movies[id] = true
+		}
+	}
+
+	users := map[int64]bool{}
+	for id, user := range changed.users {
+		if before, ok := base.users[id]; !ok || before.Version != user.Version {
+			users[id] = true
+		}
+	}
+
+	tokens := map[string]bool{}
+	for hash := range changed.tokens {
+		if _, ok := base.tokens[hash]; !ok {
+			tokens[hash] = true
+		}
+	}
+
+	permissions := map[userPermission]bool{}
+	for permission := range changed.userPermissions {
+		if !base.userPermissions[permission] {
+			permissions[permission] = true
+		}
+	}
+
+	// deleted records
+	for id := range base.movies {
+		if _, ok := changed.movies[id]; !ok {
+			movies[id] = true
+		}
+	}
+
+	for id := range base.users {
+		if _, ok := changed.users[id]; !ok {
+			users[id] = true
+		}
+	}
+
+	for hash := range base.tokens {
+		if _, ok := changed.tokens[hash]; !ok {
+			tokens[hash] = true
+		}
+	}
+
+	for permission := range base.userPermissions {
+		if !changed.userPermissions[permission] {
+			permissions[permission] = true
+		}
+	}
+
+	for id := range movies {
+		before, existed := base.movies[id]
+		now, exists := current.movies[id]
+
+		if existed != exists || before.Version != now.Version {
+			return ErrSerializationFailure
+		}
+	}
+
+	for id := range users {
+		before, existed := base.users[id]
+		now, exists := current.users[id]
+
+		if existed != exists || before.Version != now.Version {
+			return ErrSerializationFailure
+		}
+	}
+
+	for hash := range tokens {
+		_, existed := base.tokens[hash]
+		_, exists := current.tokens[hash]
+
+		if existed != exists {
+			return ErrSerializationFailure
+		}
+	}
+
+	for permission := range permissions {
+		if base.userPermissions[permission] != current.userPermissions[permission] {
+			return ErrSerializationFailure
+		}
+	}
+
+	for id := range users {
+		user, ok := changed.users[id]
+		if !ok {
+			continue
+		}
+
+		if current.emailTaken(user.Email, users) {
+			return data.ErrDuplicateEmail
+		}
+	}
+
+	for id := range movies {
+		if movie, ok := changed.movies[id]; ok {
+			current.movies[id] = movie
+		} else {
+			delete(current.movies, id)
+		}
+	}
+
+	for id := range users {
+		if user, ok := changed.users[id]; ok {
+			current.users[id] = user
+		} else {
+			delete(current.users, id)
+		}
+	}
+
+	for hash := range tokens {
+		if token, ok := changed.tokens[hash]; ok {
+			current.tokens[hash] = token
+		} else {
+			delete(current.tokens, hash)
+		}
+	}
+
+	for permission := range permissions {
+		if changed.userPermissions[permission] {
+			current.userPermissions[permission] = true
+		} else {
+			delete(current.userPermissions, permission)
+		}
+	}
+
+	return nil
+}
+
+// transaction works on a copy of the tables of the store, or of its parent transaction for a
+// savepoint, its changes are applied when it is committed and dropped otherwise.
+type transaction struct {
+	mu     sync.Mutex
+	store  *Store
+	parent *transaction // the transaction of a savepoint
+	base   *tables      // committed tables when a transaction began
+	tables *tables
+}
+
+func (tx *transaction) run(ctx context.Context, fn func(t *tables) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return fn(tx.tables)
+}
+
+func (tx *transaction) begin() *transaction {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return &transaction{store: tx.store, parent: tx, tables: tx.tables.clone()}
+}
+
+func (tx *transaction) root() *Store {
+	return tx.store
+}
+
+func (tx *transaction) commit() error {
+
+	if tx.parent == nil {
+		return tx.store.commit(tx.base, tx.tables)
+	}
+
+	tx.parent.mu.Lock()
+	defer tx.parent.mu.Unlock()
+
+	tx.parent.tables = tx.tables
+
+	return nil
+}
+
+// runInTransaction calls fn within a new transaction, or within a savepoint when c is already
+// a transaction. The work done by fn is committed if it returns nil and dropped otherwise.
+func runInTransaction(ctx context.Context, c conn, fn func(tx conn) error) error {
+
+	tx := c.begin()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return tx.commit()
+}
+
+func cloneBytes(b []byte) []byte {
+	return append([]byte(nil), b...)
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/unitofwork"
+	"github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/movies/repositories"
+	user_entities "github.com/terdia/greenlight/src/users/entities"
+)
+
+var errRollback = errors.New("rollback")
+
+func newTestMovie(title string) *entities.Movie {
+	return &entities.Movie{Title: title, Year: 2000, Runtime: 100, Genres: []string{"drama"}}
+}
+
+func TestTransactions(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		fn         func(repo repositories.MovieRepository) error
+		wantErr    error
+		wantTitles []string
+	}{
+		{
+			name: "Commit",
+			fn: func(repo repositories.MovieRepository) error {
+				return repo.Insert(ctx, newTestMovie("Heat"))
+			},
+			wantTitles: []string{"Alien", "Heat"},
+		},
+		{
+			name: "Rollback",
+			fn: func(repo repositories.MovieRepository) error {
+				if err := repo.Insert(ctx, newTestMovie("Heat")); err != nil {
+					return err
+				}
+				return errRollback
+			},
+			wantErr:    errRollback,
+			wantTitles: []string{"Alien"},
+		},
+		{
+			name: "Rolled back savepoint",
+			fn: func(repo repositories.MovieRepository) error {
+				if err := repo.Insert(ctx, newTestMovie("Heat")); err != nil {
+					return err
+				}
+
+				err := repo.WithinTransaction(ctx, func(repo repositories.MovieRepository) error {
+					if err := repo.Delete(ctx, 1); err != nil {
+						return err
+					}
+					return errRollback
+				})
+
+				if !errors.Is(err, errRollback) {
+					t.Errorf("want savepoint error %v; got %v", errRollback, err)
+				}
+
+				return nil
+			},
+			wantTitles: []string{"Alien", "Heat"},
+		},
+		{
+			name: "Released savepoint",
+			fn: func(repo repositories.MovieRepository) error {
+				return repo.WithinTransaction(ctx, func(repo repositories.MovieRepository) error {
+					return repo.Delete(ctx, 1)
+				})
+			},
+			wantTitles: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := NewMovieRepository(NewStore())
+
+			if err := repo.Insert(ctx, newTestMovie("Alien")); err != nil {
+				t.Fatal(err)
+			}
+
+			err := repo.WithinTransaction(ctx, test.fn)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("want error %v; got %v", test.wantErr, err)
+			}
+
+			titles := []string{}
+			err = repo.Export(ctx, dto.ListMovieRequest{}, func(movie *entities.Movie) error {
+				titles = append(titles, movie.Title)
+				return nil
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(titles, test.wantTitles) {
+				t.Errorf("want movies %v; got %v", test.wantTitles, titles)
+			}
+		})
+	}
+}
+
+func TestTransactionConflict(t *testing.T) {
+
+	ctx := context.Background()
+	store := NewStore()
+	repo := NewMovieRepository(store)
+
+	movie := newTestMovie("Alien")
+	if err := repo.Insert(ctx, movie); err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := 0
+
+	err := NewUnitOfWork(store).Do(ctx, func(repos unitofwork.Repositories) error {
+		attempts++
+
+		stored, err := repos.Movies.Get(ctx, int64(movie.ID))
+		if err != nil {
+			return err
+		}
+
+		// a concurrent update commits while the first attempt is running
+		if attempts == 1 {
+			concurrent := *stored
+			concurrent.Title = "Aliens"
+
+			if err := repo.Update(ctx, &concurrent); err != nil {
+				return err
+			}
+		}
+
+		stored.Runtime = 137
+
+		return repos.Movies.Update(ctx, stored)
+	})
+
+	if err != nil {
+		t.Fatalf("want error to be %v; got %v", nil, err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("want the unit of work to be retried once; got %d attempts", attempts)
+	}
+
+	stored, err := repo.Get(ctx, int64(movie.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Title != "Aliens" || stored.Runtime != 137 || stored.Version != 3 {
+		t.Errorf("want both updates to be saved; got %+v", stored)
+	}
+}
+
+func TestTransactionDuplicateEmail(t *testing.T) {
+
+	ctx := context.Background()
+	store := NewStore()
+	users := NewUserRepository(store)
+
+	err := NewUnitOfWork(store).Do(ctx, func(repos unitofwork.Repositories) error {
+		if err := repos.Users.Insert(ctx, &user_entities.User{Name: "Alice", Email: "alice@example.com"}); err != nil {
+			return err
+		}
+
+		// another signup with the same email commits first
+		return users.Insert(ctx, &user_entities.User{Name: "Alice", Email: "ALICE@example.com"})
+	})
+
+	if !errors.Is(err, data.ErrDuplicateEmail) {
+		t.Errorf("want error %v; got %v", data.ErrDuplicateEmail, err)
+	}
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/src/users/entities"
+	"github.com/terdia/greenlight/src/users/repositories"
+)
+
+type tokenRepository struct {
+	conn conn
+}
+
+func NewTokenRepository(store *Store) repositories.TokenRepository {
+	return &tokenRepository{conn: store}
+}
+
+// Create stores the token by hash, the plain text is never stored.
+func (repo *tokenRepository) Create(ctx context.Context, token *entities.Token) error {
+	return repo.conn.run(ctx, func(t *tables) error {
+		stored := *token
+		stored.Plaintext = ""
+		stored.Hash = cloneBytes(token.Hash)
+
+		t.tokens[string(token.Hash)] = stored
+
+		return nil
+	})
+}
+
+func (repo *tokenRepository) DeleteAllForUserByScope(ctx context.Context, scope string, userID custom_type.ID) error {
+	return repo.conn.run(ctx, func(t *tables) error {
+		for hash, token := range t.tokens {
+			if token.Scope == scope && token.UserId == userID {
+				delete(t.tokens, hash)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (repo *tokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+
+	var deleted int64
+
+	err := repo.conn.run(ctx, func(t *tables) error {
+		now := time.Now()
+
+		for hash, token := range t.tokens {
+			if !token.Expiry.After(now) {
+				delete(t.tokens, hash)
+				deleted++
+			}
+		}
+
+		return nil
+	})
+
+	return deleted, err
+}
+
+func (repo *tokenRepository) Count(ctx context.Context) (entities.TokenCounts, error) {
+
+	counts := entities.TokenCounts{Active: map[string]int{}}
+
+	err := repo.conn.run(ctx, func(t *tables) error {
+		now := time.Now()
+
+		for _, token := range t.tokens {
+			if token.Expiry.After(now) {
+				counts.Active[token.Scope]++
+			} else {
+				counts.Expired++
+			}
+		}
+
+		return nil
+	})
+
+	return counts, err
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/terdia/greenlight/internal/unitofwork"
+)
+
+// maxUnitOfWorkAttempts is how many times a unit of work runs before ErrSerializationFailure
+// is returned to the caller.
+const maxUnitOfWorkAttempts = 3
+
+type unitOfWork struct {
+	store *Store
+}
+
+// NewUnitOfWork returns a unit of work running in a transaction of the store, retried when it
+// conflicts with a concurrent transaction.
+func NewUnitOfWork(store *Store) unitofwork.UnitOfWork {
+	return &unitOfWork{store: store}
+}
+
+func (uow *unitOfWork) Do(ctx context.Context, fn func(repos unitofwork.Repositories) error) error {
+
+	return unitofwork.Retry(ctx, maxUnitOfWorkAttempts, uow.IsRetryable, func() error {
+		return runInTransaction(ctx, uow.store, func(tx conn) error {
+			return fn(unitofwork.Repositories{
+				Movies:      &movieRepository{conn: tx},
+				Users:       &userRepository{conn: tx},
+				Tokens:      &tokenRepository{conn: tx},
+				Permissions: &permissionRepository{conn: tx},
+			})
+		})
+	})
+}
+
+func (uow *unitOfWork) IsRetryable(err error) bool {
+	return errors.Is(err, ErrSerializationFailure)
+}
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"time"
+
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/src/users/entities"
+	"github.com/terdia/greenlight/src/users/repositories"
+)
+
+type userRepository struct {
+	conn conn
+}
+
+func NewUserRepository(store *Store) repositories.UserRepository {
+	return &userRepository{conn: store}
+}
+
+func (repo *userRepository) Insert(ctx context.Context, user *entities.User) error {
+	return repo.conn.run(ctx, func(t *tables) error {
+		if t.emailTaken(user.Email, nil) {
+			return data.ErrDuplicateEmail
+		}
+
+		user.ID = custom_type.ID(repo.conn.root().nextUserID())
+		user.CreatedAt = time.Now().Truncate(time.Second)
+		user.Version = 1
+
+		t.users[int64(user.ID)] = copyUser(user)
+
+		return nil
+	})
+}
+
+func (repo *userRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
+
+	var user entities.User
+
+	err := repo.conn.run(ctx, func(t *tables) error {
+		stored, ok := t.userByEmail(email)
+		if !ok {
+			return data.ErrRecordNotFound
+		}
+
+		user = copyUser(&stored)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// Update saves the user when its version is still the stored one, and returns
+// data.ErrEditConflict otherwise.
+func (repo *userRepository) Update(ctx context.Context, user *entities.User) error {
+	return repo.conn.run(ctx, func(t *tables) error {
+		stored, ok := t.users[int64(user.ID)]
+		if !ok || stored.Version != user.Version {
+			return data.ErrEditConflict
+		}
+
+		if t.emailTaken(user.Email, map[int64]bool{int64(user.ID): true}) {
+			return data.ErrDuplicateEmail
+		}
+
+		updated := copyUser(user)
+		updated.CreatedAt = stored.CreatedAt
+		updated.Version++
+
+		t.users[int64(user.ID)] = updated
+		user.Version = updated.Version
+
+		return nil
+	})
+}
+
+// GetForToken returns the user of an unexpired token of the scope.
+func (repo *userRepository) GetForToken(ctx context.Context, tokenPlainText, scope string) (*entities.User, error) {
+
+	hash := sha256.Sum256([]byte(tokenPlainText))
+
+	var user entities.User
+
+	err := repo.conn.run(ctx, func(t *tables) error {
+		token, ok := t.tokens[string(hash[:])]
+		if !ok || token.Scope != scope || !token.Expiry.After(time.Now()) {
+			return data.ErrRecordNotFound
+		}
+
+		stored, ok := t.users[int64(token.UserId)]
+		if !ok {
+			return data.ErrRecordNotFound
+		}
+
+		user = copyUser(&stored)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// copyUser returns a copy of the user without the plain text password, which is never stored.
+func (repo *userRepository) Count(ctx context.Context) (entities.UserCounts, error) {
+
+	var counts entities.UserCounts
+
+	err := repo.conn.run(ctx, func(t *tables) error {
+		for _, user := range t.users {
+			counts.Total++
+
+			if user.Activated {
+				counts.Activated++
+			}
+		}
+
+		return nil
+	})
+
+	return counts, err
+}
+
+func copyUser(user *entities.User) entities.User {
+
+	copied := *user
+	copied.Password = entities.Password{Hash: cloneBytes(user.Password.Hash)}
+
+	return copied
+}
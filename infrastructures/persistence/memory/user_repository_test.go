@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/src/users/entities"
+)
+
+func TestGetForToken(t *testing.T) {
+
+	ctx := context.Background()
+	store := NewStore()
+	users := NewUserRepository(store)
+	tokens := NewTokenRepository(store)
+
+	user := &entities.User{Name: "Alice", Email: "alice@example.com", Password: entities.Password{Hash: []byte("hash")}}
+	if err := users.Insert(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   *entities.Token
+		scope   string
+		wantErr error
+	}{
+		{
+			name:  "Valid token",
+			token: &entities.Token{Plaintext: "VALIDTOKEN", Expiry: time.Now().Add(time.Hour), Scope: data.TokenScopeActivation},
+			scope: data.TokenScopeActivation,
+		},
+		{
+			name:    "Expired token",
+			token:   &entities.Token{Plaintext: "EXPIREDTOKEN", Expiry: time.Now().Add(-time.Second), Scope: data.TokenScopeActivation},
+			scope:   data.TokenScopeActivation,
+			wantErr: data.ErrRecordNotFound,
+		},
+		{
+			name:    "Other scope",
+			token:   &entities.Token{Plaintext: "OTHERTOKEN", Expiry: time.Now().Add(time.Hour), Scope: data.TokenScopeAuthentication},
+			scope:   data.TokenScopeActivation,
+			wantErr: data.ErrRecordNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hash := sha256.Sum256([]byte(test.token.Plaintext))
+			test.token.Hash = hash[:]
+			test.token.UserId = user.ID
+
+			if err := tokens.Create(ctx, test.token); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := users.GetForToken(ctx, test.token.Plaintext, test.scope)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("want error %v; got %v", test.wantErr, err)
+			}
+
+			if err == nil && got.ID != user.ID {
+				t.Errorf("want user %d; got %d", user.ID, got.ID)
+			}
+		})
+	}
+}
+
+func TestDuplicateEmail(t *testing.T) {
+
+	ctx := context.Background()
+	users := NewUserRepository(NewStore())
+
+	alice := &entities.User{Name: "Alice", Email: "alice@example.com"}
+	bob := &entities.User{Name: "Bob", Email: "bob@example.com"}
+
+	for _, user := range []*entities.User{alice, bob} {
+		if err := users.Insert(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := users.Insert(ctx, &entities.User{Name: "Alice", Email: "Alice@Example.com"}); !errors.Is(err, data.ErrDuplicateEmail) {
+		t.Errorf("want error %v on insert; got %v", data.ErrDuplicateEmail, err)
+	}
+
+	bob.Email = "ALICE@example.com"
+	if err := users.Update(ctx, bob); !errors.Is(err, data.ErrDuplicateEmail) {
+		t.Errorf("want error %v on update; got %v", data.ErrDuplicateEmail, err)
+	}
+}
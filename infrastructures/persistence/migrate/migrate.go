@@ -0,0 +1,268 @@
+// Package migrate applies the embedded SQL migrations of a storage. The version of the schema
+// is kept in the schema_migrations table of the migrate tool, so databases it has migrated can
+// be migrated by the binary and the other way around.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrDirty          = errors.New("migrate: the database is dirty, a migration failed half way, fix the schema and force its version with the migrate tool")
+	ErrUnknownVersion = errors.New("migrate: unknown version")
+)
+
+// Migration is a version of the schema, with the statements upgrading to it from the previous
+// version and back.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration // by version
+	lock       string
+}
+
+// New returns a migrator applying the migrations of files, named like the files of the migrate
+// tool e.g. 000001_create_movies_table.up.sql and 000001_create_movies_table.down.sql. lock is
+// the statement run first by every migration transaction, it takes a lock held until the
+// transaction ends so migrators running concurrently, e.g. by replicas starting together,
+// apply each migration once.
+func New(db *sql.DB, files fs.FS, lock string) (*Migrator, error) {
+
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, name := range names {
+		prefix := strings.TrimSuffix(name, ".sql")
+
+		direction := prefix[strings.LastIndexByte(prefix, '.')+1:]
+		prefix = strings.TrimSuffix(prefix, "."+direction)
+
+		parts := strings.SplitN(prefix, "_", 2)
+
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || version < 1 || len(parts) != 2 || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migrate: invalid migration file name %s", name)
+		}
+
+		statements, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = migration
+		}
+
+		if direction == "up" {
+			migration.Up = string(statements)
+		} else {
+			migration.Down = string(statements)
+		}
+	}
+
+	migrator := &Migrator{db: db, lock: lock}
+
+	for _, migration := range byVersion {
+		migrator.migrations = append(migrator.migrations, *migration)
+	}
+
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+
+	return migrator, nil
+}
+
+// Migrations returns the migrations ordered by version.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Version returns the version of the schema, 0 when no migration has been applied, and
+// whether the last migration applied by the migrate tool failed.
+func (m *Migrator) Version(ctx context.Context) (version int64, dirty bool, err error) {
+
+	if _, err := m.db.ExecContext(ctx, createTable); err != nil {
+		return 0, false, err
+	}
+
+	err = m.db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+// Up applies all the pending migrations. It leaves a schema newer than the migrations as it
+// is, so a replica running the previous release can start once a new release has migrated.
+func (m *Migrator) Up(ctx context.Context) error {
+
+	if len(m.migrations) == 0 {
+		return nil
+	}
+
+	return m.migrate(ctx, m.migrations[len(m.migrations)-1].Version, false)
+}
+
+// Down rolls back the last applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	i, err := m.index(version)
+	if err != nil || version == 0 {
+		return err
+	}
+
+	previous := int64(0)
+	if i > 0 {
+		previous = m.migrations[i-1].Version
+	}
+
+	return m.Goto(ctx, previous)
+}
+
+// Goto applies or rolls back migrations, one transaction each, until the schema is at version,
+// 0 rolling back every migration.
+func (m *Migrator) Goto(ctx context.Context, version int64) error {
+
+	if _, err := m.index(version); err != nil {
+		return err
+	}
+
+	return m.migrate(ctx, version, true)
+}
+
+func (m *Migrator) migrate(ctx context.Context, target int64, rollback bool) error {
+	for {
+		done, err := m.step(ctx, target, rollback)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// step applies the migration following the current version towards target, or rolls back the
+// current version when it is after target and rollback is set. It reports whether there is
+// nothing left to do. The current version is read once the lock is held, a concurrent migrator
+// may have applied the migration meanwhile.
+func (m *Migrator) step(ctx context.Context, target int64, rollback bool) (done bool, err error) {
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	if m.lock != "" {
+		if _, err := tx.ExecContext(ctx, m.lock); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, createTable); err != nil {
+		return false, err
+	}
+
+	var current int64
+	var dirty bool
+
+	err = tx.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations`).Scan(&current, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	if dirty {
+		return false, ErrDirty
+	}
+
+	if current == target || (current > target && !rollback) {
+		return true, nil
+	}
+
+	i, err := m.index(current)
+	if err != nil {
+		return false, err
+	}
+
+	var migration Migration
+	var statements string
+	var next int64
+
+	if current < target {
+		// the migration after the current version, the first one when no migration is applied
+		if current > 0 {
+			i++
+		}
+
+		migration, statements, next = m.migrations[i], m.migrations[i].Up, m.migrations[i].Version
+	} else {
+		migration, statements = m.migrations[i], m.migrations[i].Down
+
+		if i > 0 {
+			next = m.migrations[i-1].Version
+		}
+	}
+
+	if statements == "" {
+		return false, fmt.Errorf("migrate: %d_%s has no migration file towards version %d", migration.Version, migration.Name, next)
+	}
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return false, fmt.Errorf("migrate: %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return false, err
+	}
+
+	if next > 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)`, next); err != nil {
+			return false, err
+		}
+	}
+
+	return false, tx.Commit()
+}
+
+// index returns the index of the migration of version, 0 for version 0.
+func (m *Migrator) index(version int64) (int, error) {
+
+	if version == 0 {
+		return 0, nil
+	}
+
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w %d", ErrUnknownVersion, version)
+}
@@ -0,0 +1,206 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+var testMigrations = fstest.MapFS{
+	"000001_create_movies.up.sql":   {Data: []byte("CREATE TABLE movies (id integer PRIMARY KEY, title text NOT NULL);")},
+	"000001_create_movies.down.sql": {Data: []byte("DROP TABLE movies;")},
+	"000002_add_runs.up.sql":        {Data: []byte("CREATE TABLE runs (id integer PRIMARY KEY); INSERT INTO runs DEFAULT VALUES;")},
+	"000002_add_runs.down.sql":      {Data: []byte("DROP TABLE runs;")},
+	"000005_add_year.up.sql":        {Data: []byte("ALTER TABLE movies ADD COLUMN year integer NOT NULL DEFAULT 0;")},
+	"000005_add_year.down.sql":      {Data: []byte("ALTER TABLE movies DROP COLUMN year;")},
+}
+
+// openTestDB opens a new database whose transactions take the write lock when they begin, like
+// the SQLite storage.
+func openTestDB(t *testing.T, path string) *sql.DB {
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func newTestMigrator(t *testing.T, db *sql.DB, files fstest.MapFS) *Migrator {
+
+	migrator, err := New(db, files, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return migrator
+}
+
+func checkVersion(t *testing.T, migrator *Migrator, want int64) {
+	t.Helper()
+
+	version, dirty, err := migrator.Version(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != want || dirty {
+		t.Errorf("want version %d; got %d (dirty %t)", want, version, dirty)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+
+	ctx := context.Background()
+	migrator := newTestMigrator(t, openTestDB(t, filepath.Join(t.TempDir(), "test.db")), testMigrations)
+
+	checkVersion(t, migrator, 0)
+
+	steps := []struct {
+		name    string
+		run     func() error
+		want    int64
+		wantErr error
+	}{
+		{name: "Up", run: func() error { return migrator.Up(ctx) }, want: 5},
+		{name: "Up again", run: func() error { return migrator.Up(ctx) }, want: 5},
+		{name: "Down", run: func() error { return migrator.Down(ctx) }, want: 2},
+		{name: "Goto first", run: func() error { return migrator.Goto(ctx, 1) }, want: 1},
+		{name: "Goto unknown", run: func() error { return migrator.Goto(ctx, 3) }, want: 1, wantErr: ErrUnknownVersion},
+		{name: "Goto last", run: func() error { return migrator.Goto(ctx, 5) }, want: 5},
+		{name: "Goto zero", run: func() error { return migrator.Goto(ctx, 0) }, want: 0},
+		{name: "Down at zero", run: func() error { return migrator.Down(ctx) }, want: 0},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if err := step.run(); !errors.Is(err, step.wantErr) {
+				t.Fatalf("want error %v; got %v", step.wantErr, err)
+			}
+
+			checkVersion(t, migrator, step.want)
+		})
+	}
+}
+
+func TestMigratorFailure(t *testing.T) {
+
+	ctx := context.Background()
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test.db"))
+
+	files := fstest.MapFS{
+		"000001_create_movies.up.sql":   testMigrations["000001_create_movies.up.sql"],
+		"000001_create_movies.down.sql": testMigrations["000001_create_movies.down.sql"],
+		"000002_broken.up.sql":          {Data: []byte("CREATE TABLE runs (id integer PRIMARY KEY); INSERT INTO missing DEFAULT VALUES;")},
+		"000002_broken.down.sql":        {Data: []byte("DROP TABLE runs;")},
+	}
+
+	migrator := newTestMigrator(t, db, files)
+
+	if err := migrator.Up(ctx); err == nil {
+		t.Fatal("want the broken migration to fail")
+	}
+
+	// the broken migration is rolled back, the first one stays applied
+	checkVersion(t, migrator, 1)
+
+	if _, err := db.ExecContext(ctx, "SELECT 1 FROM runs"); err == nil {
+		t.Error("want the table of the broken migration to be rolled back")
+	}
+
+	if _, err := db.ExecContext(ctx, "UPDATE schema_migrations SET dirty = TRUE"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrator.Goto(ctx, 0); !errors.Is(err, ErrDirty) {
+		t.Errorf("want error %v; got %v", ErrDirty, err)
+	}
+}
+
+func TestMigratorNewerSchema(t *testing.T) {
+
+	ctx := context.Background()
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test.db"))
+
+	if err := newTestMigrator(t, db, testMigrations).Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the previous release doesn't know the last migration
+	previous := fstest.MapFS{}
+	for name, file := range testMigrations {
+		if name[:6] != "000005" {
+			previous[name] = file
+		}
+	}
+
+	migrator := newTestMigrator(t, db, previous)
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Errorf("want error %v; got %v", nil, err)
+	}
+
+	checkVersion(t, migrator, 5)
+
+	if err := migrator.Down(ctx); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("want error %v; got %v", ErrUnknownVersion, err)
+	}
+}
+
+func TestMigratorConcurrent(t *testing.T) {
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	migrators := make([]*Migrator, 4)
+	for i := range migrators {
+		migrators[i] = newTestMigrator(t, openTestDB(t, path), testMigrations)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(migrators))
+
+	for i, migrator := range migrators {
+		wg.Add(1)
+
+		go func(i int, migrator *Migrator) {
+			defer wg.Done()
+			errs[i] = migrator.Up(ctx)
+		}(i, migrator)
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("migrator %d: %v", i, err)
+		}
+	}
+
+	var runs int
+	if err := openTestDB(t, path).QueryRowContext(ctx, "SELECT count(*) FROM runs").Scan(&runs); err != nil {
+		t.Fatal(err)
+	}
+
+	if runs != 1 {
+		t.Errorf("want every migration to be applied once; got %d runs of the second one", runs)
+	}
+}
+
+func TestNewInvalidFileName(t *testing.T) {
+
+	files := fstest.MapFS{"create_movies.up.sql": {Data: []byte("CREATE TABLE movies (id integer);")}}
+
+	if _, err := New(nil, files, ""); err == nil {
+		t.Error("want an error for a file name without version")
+	}
+}
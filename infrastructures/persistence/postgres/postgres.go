@@ -3,13 +3,25 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
 	_ "github.com/lib/pq"
 
 	"github.com/terdia/greenlight/config"
+	"github.com/terdia/greenlight/infrastructures/persistence/migrate"
+	"github.com/terdia/greenlight/migrations"
 )
 
+// migrationLockKey is the key of the advisory lock taken by every migration transaction.
+const migrationLockKey = 4719364037
+
+// NewMigrator returns the migrator of the embedded migrations. A migration transaction holds the
+// advisory lock until it ends, so replicas migrating on startup apply each migration once.
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, migrations.FS, fmt.Sprintf("SELECT pg_advisory_xact_lock(%d)", migrationLockKey))
+}
+
 func OpenDb(cgf config.Db) (*sql.DB, error) {
 
 	db, err := sql.Open("postgres", cgf.Dsn)
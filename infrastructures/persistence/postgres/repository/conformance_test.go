@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/terdia/greenlight/config"
+	"github.com/terdia/greenlight/infrastructures/persistence/postgres"
+	"github.com/terdia/greenlight/internal/repositorytest"
+	"github.com/terdia/greenlight/internal/unitofwork"
+)
+
+// TestConformance runs against the migrated database of GREENLIGHT_TEST_DB_DSN, whose movies,
+// users and tokens are deleted before every test.
+func TestConformance(t *testing.T) {
+
+	dsn := os.Getenv("GREENLIGHT_TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("GREENLIGHT_TEST_DB_DSN is not set")
+	}
+
+	db, err := postgres.OpenDb(config.Db{Dsn: dsn, MaxOpenConns: 4, MaxIdleConns: 4, MaxIdleTime: "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	repositorytest.Run(t, func(t *testing.T) unitofwork.Repositories {
+		_, err := db.ExecContext(context.Background(), "TRUNCATE movies, users RESTART IDENTITY CASCADE")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return unitofwork.Repositories{
+			Movies:      NewMovieRepoitory(db),
+			Users:       NewUserRepoitory(db),
+			Tokens:      NewTokenRepository(db),
+			Permissions: NewPermissionRepository(db),
+		}
+	})
+}
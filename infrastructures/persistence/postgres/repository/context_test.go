@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/terdia/greenlight/infrastructures/persistence/sqlrepository"
+)
+
+// blockingConnector opens connections whose queries run until their context is done, like a
+// slow query on a server that honours cancellation.
+type blockingConnector struct {
+	started chan struct{}
+	aborted chan error
+}
+
+func (c *blockingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &blockingConn{c}, nil
+}
+
+func (c *blockingConnector) Driver() driver.Driver {
+	return nil
+}
+
+type blockingConn struct {
+	connector *blockingConnector
+}
+
+func (conn *blockingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn.connector.started <- struct{}{}
+
+	<-ctx.Done()
+	conn.connector.aborted <- ctx.Err()
+
+	return nil, ctx.Err()
+}
+
+func (conn *blockingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (conn *blockingConn) Close() error {
+	return nil
+}
+
+func (conn *blockingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func TestQueriesAbortWithContext(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		context func() (context.Context, context.CancelFunc, func())
+		wantErr error
+	}{
+		{
+			name: "Cancelled request",
+			context: func() (context.Context, context.CancelFunc, func()) {
+				ctx, cancel := context.WithCancel(context.Background())
+				return ctx, cancel, cancel
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "Request deadline",
+			context: func() (context.Context, context.CancelFunc, func()) {
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				return ctx, cancel, func() {}
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			connector := &blockingConnector{started: make(chan struct{}, 1), aborted: make(chan error, 1)}
+
+			db := sql.OpenDB(connector)
+			defer db.Close()
+
+			repo := NewMovieRepoitory(db)
+
+			ctx, cancel, abort := test.context()
+			defer cancel()
+
+			go func() {
+				<-connector.started
+				abort()
+			}()
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := repo.Get(ctx, 1)
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("want %v; got %v", test.wantErr, err)
+				}
+			case <-time.After(sqlrepository.QueryTimeout):
+				t.Fatal("want the query to be aborted with the request context")
+			}
+
+			if err := <-connector.aborted; !errors.Is(err, test.wantErr) {
+				t.Errorf("want the query to see %v; got %v", test.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/infrastructures/persistence/sqlrepository"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/filter"
+)
+
+const exportFetchSize = 500
+
+// PostgreSQL error codes of transactions aborted because of a concurrent transaction, they
+// succeed when retried.
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// dialect writes the queries of the repositories in PostgreSQL, searching titles with the
+// fulltext search of PostgreSQL and the trigrams of pg_trgm.
+type dialect struct{}
+
+func (dialect) Array(values *[]string) interface{} {
+	return pq.Array(values)
+}
+
+func (dialect) Time(t time.Time) interface{} {
+	return t
+}
+
+func (dialect) InArray(expr, array string) string {
+	return fmt.Sprintf("%s = ANY(%s)", expr, array)
+}
+
+// IsUniqueViolation reports whether err is the violation of the unique constraint PostgreSQL
+// names after column e.g. users_email_key.
+func (dialect) IsUniqueViolation(err error, column string) bool {
+	constraint := strings.ReplaceAll(column, ".", "_") + "_key"
+
+	return err.Error() == fmt.Sprintf("pq: duplicate key value violates unique constraint %q", constraint)
+}
+
+// TxOptions returns serializable transactions, see NewUnitOfWork.
+func (dialect) TxOptions() *sql.TxOptions {
+	return &sql.TxOptions{Isolation: sql.LevelSerializable}
+}
+
+func (dialect) IsRetryable(err error) bool {
+	return isSerializationFailure(err)
+}
+
+func (dialect) CopyIn(table string, columns ...string) (string, bool) {
+	return pq.CopyIn(table, columns...), true
+}
+
+// Stream reads the rows through a server-side cursor, exportFetchSize rows at a time. Each FETCH
+// is bounded by QueryTimeout and its rows are flushed once it is done.
+func (dialect) Stream(ctx context.Context, db sqlrepository.DBTX, query string, args []interface{}, scan func(rows *sql.Rows) error, flush func() error) error {
+
+	return sqlrepository.RunInTransaction(ctx, db, nil, func(tx sqlrepository.DBTX) error {
+
+		queryCtx, cancel := context.WithTimeout(ctx, sqlrepository.QueryTimeout)
+		defer cancel()
+
+		_, err := tx.ExecContext(queryCtx, "DECLARE stream NO SCROLL CURSOR FOR "+query, args...)
+		if err != nil {
+			return err
+		}
+
+		for {
+			fetched, err := fetch(ctx, tx, scan)
+			if err != nil {
+				return err
+			}
+
+			if err := flush(); err != nil {
+				return err
+			}
+
+			if fetched < exportFetchSize {
+				break
+			}
+		}
+
+		queryCtx, cancel = context.WithTimeout(ctx, sqlrepository.QueryTimeout)
+		defer cancel()
+
+		_, err = tx.ExecContext(queryCtx, "CLOSE stream")
+
+		return err
+	})
+}
+
+// fetch scans the next exportFetchSize rows of the cursor.
+func fetch(ctx context.Context, tx sqlrepository.DBTX, scan func(rows *sql.Rows) error) (int, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, sqlrepository.QueryTimeout)
+	defer cancel()
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("FETCH %d FROM stream", exportFetchSize))
+	if err != nil {
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	fetched := 0
+
+	for rows.Next() {
+		fetched++
+
+		if err := scan(rows); err != nil {
+			return 0, err
+		}
+	}
+
+	return fetched, rows.Err()
+}
+
+func (dialect) MovieTable(r dto.ListMovieRequest, args *sqlrepository.Args) string {
+	return "movies"
+}
+
+func (dialect) TitleCondition(r dto.ListMovieRequest, args *sqlrepository.Args) string {
+
+	if r.SearchMode == data.SearchModeFuzzy {
+		return fmt.Sprintf("title %% %s", args.Add(r.Title))
+	}
+
+	return fmt.Sprintf("to_tsvector(language, title) @@ %s", titleTsQuery(r, args))
+}
+
+func (dialect) GenresCondition(mode, genres string) string {
+
+	switch mode {
+	case data.GenresModeAny:
+		return fmt.Sprintf("genres && %s", genres)
+	case data.GenresModeNone:
+		return fmt.Sprintf("NOT genres && %s", genres)
+	default:
+		return fmt.Sprintf("genres @> %s", genres)
+	}
+}
+
+// filterColumns are the columns of the filter expressions compared as text, language is a
+// regconfig.
+var filterColumns = map[string]string{
+	"language": "language::text",
+}
+
+func (dialect) FilterCondition(expr filter.Expr, args *sqlrepository.Args) string {
+	return castFilterColumns(expr).SQL(args.Add)
+}
+
+// castFilterColumns returns a copy of the filter expression comparing the filterColumns as text.
+func castFilterColumns(expr filter.Expr) filter.Expr {
+
+	switch e := expr.(type) {
+	case *filter.Logical:
+		return &filter.Logical{Op: e.Op, Left: castFilterColumns(e.Left), Right: castFilterColumns(e.Right)}
+	case *filter.Not:
+		return &filter.Not{Expr: castFilterColumns(e.Expr)}
+	case *filter.Comparison:
+		if column, ok := filterColumns[e.Field.Column]; ok {
+			cast := *e
+			cast.Field.Column = column
+
+			return &cast
+		}
+	}
+
+	return expr
+}
+
+// Relevance returns ts_rank for fulltext and prefix search and the trigram similarity for fuzzy
+// search.
+func (dialect) Relevance(r dto.ListMovieRequest, args *sqlrepository.Args) string {
+
+	if r.SearchMode == data.SearchModeFuzzy {
+		return fmt.Sprintf("similarity(title, %s)", args.Add(r.Title))
+	}
+
+	return fmt.Sprintf("ts_rank(to_tsvector(language, title), %s)", titleTsQuery(r, args))
+}
+
+// Highlight returns the title highlighted by ts_headline.
+func (dialect) Highlight(r dto.ListMovieRequest, args *sqlrepository.Args) (string, string) {
+
+	// fuzzy search has no tsquery, the words of the search are highlighted when they match exactly
+	query := fmt.Sprintf("plainto_tsquery(language, %s)", args.Add(r.Title))
+	if r.SearchMode != data.SearchModeFuzzy {
+		query = titleTsQuery(r, args)
+	}
+
+	options := args.Add(fmt.Sprintf("StartSel=%s, StopSel=%s, HighlightAll=true", sqlrepository.HighlightStart, sqlrepository.HighlightStop))
+
+	return fmt.Sprintf("ts_headline(language, title, %s, %s)", query, options), ""
+}
+
+func (dialect) ArrayElements(array, alias string) string {
+	return fmt.Sprintf("unnest(%s) AS %s (value)", array, alias)
+}
+
+// titleTsQuery returns the tsquery of the title search. It is parsed with the language of each
+// movie, so words are stemmed the same way as the title of the movie e.g. "running" matches
+// "Run Lola Run" in english.
+func titleTsQuery(r dto.ListMovieRequest, args *sqlrepository.Args) string {
+
+	if r.SearchMode != data.SearchModePrefix {
+		return fmt.Sprintf("plainto_tsquery(language, %s)", args.Add(r.Title))
+	}
+
+	return fmt.Sprintf("to_tsquery(language, %s)", args.Add(prefixQuery(r.Title)))
+}
+
+// prefixQuery returns the title search as a tsquery matching every word as a prefix
+// e.g. "godf par" becomes "godf:* & par:*".
+func prefixQuery(title string) string {
+
+	words := strings.FieldsFunc(title, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/terdia/greenlight/infrastructures/persistence/sqlrepository"
+	"github.com/terdia/greenlight/internal/filter"
+)
+
+func TestIsSerializationFailure(t *testing.T) {
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Serialization failure", err: &pq.Error{Code: serializationFailure}, want: true},
+		{name: "Deadlock", err: &pq.Error{Code: deadlockDetected}, want: true},
+		{name: "Wrapped serialization failure", err: fmt.Errorf("commit: %w", &pq.Error{Code: serializationFailure}), want: true},
+		{name: "Unique violation", err: &pq.Error{Code: "23505"}},
+		{name: "Other error", err: errors.New("connection refused")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isSerializationFailure(test.err); got != test.want {
+				t.Errorf("want %t; got %t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterCondition(t *testing.T) {
+
+	language := filter.Field{Column: "language", Type: filter.Text}
+	year := filter.Field{Column: "year", Type: filter.Number}
+
+	expr := &filter.Logical{
+		Op:    "AND",
+		Left:  &filter.Not{Expr: &filter.Comparison{Field: language, Op: "=", Value: "english"}},
+		Right: &filter.Comparison{Field: year, Op: ">=", Value: int64(2000)},
+	}
+
+	var args sqlrepository.Args
+
+	want := "(NOT (language::text = $1) AND year >= $2)"
+	if got := (dialect{}).FilterCondition(expr, &args); got != want {
+		t.Errorf("want %q; got %q", want, got)
+	}
+
+	if expr.Left.(*filter.Not).Expr.(*filter.Comparison).Field.Column != "language" {
+		t.Error("want the filter expression to be left unchanged")
+	}
+}
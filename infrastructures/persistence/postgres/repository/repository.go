@@ -0,0 +1,34 @@
+// Package repository implements the repositories of the PostgreSQL storage, with the queries of
+// sqlrepository written in the PostgreSQL dialect.
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/terdia/greenlight/infrastructures/persistence/sqlrepository"
+	"github.com/terdia/greenlight/internal/unitofwork"
+	movie_repositories "github.com/terdia/greenlight/src/movies/repositories"
+	"github.com/terdia/greenlight/src/users/repositories"
+)
+
+func NewMovieRepoitory(db *sql.DB) movie_repositories.MovieRepository {
+	return sqlrepository.NewMovieRepository(db, dialect{})
+}
+
+func NewUserRepoitory(db *sql.DB) repositories.UserRepository {
+	return sqlrepository.NewUserRepository(db, dialect{})
+}
+
+func NewTokenRepository(db *sql.DB) repositories.TokenRepository {
+	return sqlrepository.NewTokenRepository(db, dialect{})
+}
+
+func NewPermissionRepository(db *sql.DB) repositories.PermissionRepository {
+	return sqlrepository.NewPermissionRepository(db, dialect{})
+}
+
+// NewUnitOfWork returns a unit of work running serializable transactions, so reads made by a
+// unit of work, e.g. the duplicate email check of signups, stay true until it is committed.
+func NewUnitOfWork(db *sql.DB) unitofwork.UnitOfWork {
+	return sqlrepository.NewUnitOfWork(db, dialect{})
+}
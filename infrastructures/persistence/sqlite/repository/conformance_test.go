@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/terdia/greenlight/config"
+	"github.com/terdia/greenlight/infrastructures/persistence/sqlite"
+	"github.com/terdia/greenlight/internal/repositorytest"
+	"github.com/terdia/greenlight/internal/unitofwork"
+)
+
+// newTestDB returns a new migrated database in a temporary directory, removed when the test ends.
+func newTestDB(t *testing.T) *sql.DB {
+
+	db, err := sqlite.OpenDb(config.Db{Dsn: "sqlite://" + filepath.Join(t.TempDir(), "greenlight.db"), MaxOpenConns: 4, MaxIdleConns: 4, MaxIdleTime: "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := sqlite.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) unitofwork.Repositories {
+		db := newTestDB(t)
+
+		return unitofwork.Repositories{
+			Movies:      NewMovieRepository(db),
+			Users:       NewUserRepository(db),
+			Tokens:      NewTokenRepository(db),
+			Permissions: NewPermissionRepository(db),
+		}
+	})
+}
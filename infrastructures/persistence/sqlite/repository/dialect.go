@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/infrastructures/persistence/sqlrepository"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/filter"
+	"github.com/terdia/greenlight/internal/trigram"
+)
+
+// highlightMarkers are the arguments of highlight() delimiting the matches with the markers of
+// sqlrepository.
+var highlightMarkers = fmt.Sprintf("char(%d), char(%d)", sqlrepository.HighlightStart[0], sqlrepository.HighlightStop[0])
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// dialect writes the queries of the repositories in SQLite, searching titles with the FTS5 table
+// movies_fts and the similarity function registered by the sqlite package.
+type dialect struct{}
+
+func (dialect) Array(values *[]string) interface{} {
+	return jsonArray(values)
+}
+
+func (dialect) Time(t time.Time) interface{} {
+	return timestamp(t)
+}
+
+func (dialect) InArray(expr, array string) string {
+	return fmt.Sprintf("%s IN (SELECT value FROM json_each(%s))", expr, array)
+}
+
+func (dialect) IsUniqueViolation(err error, column string) bool {
+	return isUniqueViolation(err, column)
+}
+
+// TxOptions returns the default options, SQLite transactions are always serializable.
+func (dialect) TxOptions() *sql.TxOptions {
+	return nil
+}
+
+func (dialect) IsRetryable(err error) bool {
+	return isBusy(err)
+}
+
+// CopyIn returns a prepared INSERT, SQLite has no COPY but is fast at inserting rows once it
+// doesn't commit each of them.
+func (dialect) CopyIn(table string, columns ...string) (string, bool) {
+
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", ")), false
+}
+
+// Stream runs the query directly, SQLite steps through the rows as they are read so each row is
+// flushed once scanned.
+func (dialect) Stream(ctx context.Context, db sqlrepository.DBTX, query string, args []interface{}, scan func(rows *sql.Rows) error, flush func() error) error {
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// MovieTable joins the movies with the titles of movies_fts matching a fulltext or prefix title
+// search, exposing their bm25 score and highlighted title in the search table.
+func (dialect) MovieTable(r dto.ListMovieRequest, args *sqlrepository.Args) string {
+
+	if r.Title == "" || r.SearchMode == data.SearchModeFuzzy || ftsQuery(r.Title, r.SearchMode, " ") == "" {
+		return "movies"
+	}
+
+	return fmt.Sprintf(`movies
+			JOIN (
+				SELECT rowid AS movie_id, bm25(movies_fts) AS score, highlight(movies_fts, 0, %s) AS marked
+				FROM movies_fts
+				WHERE movies_fts MATCH %s
+			) AS search ON search.movie_id = movies.id`, highlightMarkers, args.Add(ftsQuery(r.Title, r.SearchMode, " ")))
+}
+
+// TitleCondition returns the condition of a fuzzy search, fulltext and prefix searches are
+// matched by the join of MovieTable.
+func (dialect) TitleCondition(r dto.ListMovieRequest, args *sqlrepository.Args) string {
+
+	switch {
+	case r.SearchMode == data.SearchModeFuzzy:
+		return fmt.Sprintf("similarity(title, %s) >= %g", args.Add(r.Title), trigram.Threshold)
+	case ftsQuery(r.Title, r.SearchMode, " ") == "":
+		// like plainto_tsquery, a search without words matches nothing
+		return "FALSE"
+	default:
+		return ""
+	}
+}
+
+func (dialect) GenresCondition(mode, genres string) string {
+
+	shared := fmt.Sprintf("SELECT DISTINCT value FROM json_each(genres) WHERE value IN (SELECT value FROM json_each(%s))", genres)
+
+	switch mode {
+	case data.GenresModeAny:
+		return fmt.Sprintf("EXISTS (%s)", shared)
+	case data.GenresModeNone:
+		return fmt.Sprintf("NOT EXISTS (%s)", shared)
+	default:
+		return fmt.Sprintf("(SELECT count(*) FROM (%s)) = (SELECT count(DISTINCT value) FROM json_each(%s))", shared, genres)
+	}
+}
+
+// FilterCondition returns the filter expression as a SQLite condition. filter.Expr.SQL can't be
+// used, it writes PostgreSQL operators for lists and case insensitive matches.
+func (d dialect) FilterCondition(expr filter.Expr, args *sqlrepository.Args) string {
+
+	switch e := expr.(type) {
+	case *filter.Logical:
+		return fmt.Sprintf("(%s %s %s)", d.FilterCondition(e.Left, args), e.Op, d.FilterCondition(e.Right, args))
+	case *filter.Not:
+		return fmt.Sprintf("NOT (%s)", d.FilterCondition(e.Expr, args))
+	case *filter.Comparison:
+		column := e.Field.Column
+
+		value := e.Value
+		if t, ok := value.(time.Time); ok {
+			value = timestamp(t)
+		}
+
+		switch e.Op {
+		case "has":
+			return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) WHERE value = %s)", column, args.Add(value))
+		case "~":
+			// LIKE is case insensitive for ASCII letters, like ILIKE
+			return fmt.Sprintf(`%s LIKE %s ESCAPE '\'`, column, args.Add("%"+likeEscaper.Replace(value.(string))+"%"))
+		case "!=":
+			return fmt.Sprintf("%s <> %s", column, args.Add(value))
+		default:
+			return fmt.Sprintf("%s %s %s", column, e.Op, args.Add(value))
+		}
+	default:
+		panic(fmt.Sprintf("unsupported filter expression %T", expr))
+	}
+}
+
+// Relevance returns the bm25 score for fulltext and prefix search, negated so the best match is
+// the highest, and the trigram similarity for fuzzy search.
+func (dialect) Relevance(r dto.ListMovieRequest, args *sqlrepository.Args) string {
+
+	switch {
+	case r.SearchMode == data.SearchModeFuzzy:
+		return fmt.Sprintf("similarity(title, %s)", args.Add(r.Title))
+	case ftsQuery(r.Title, r.SearchMode, " ") == "":
+		return "0"
+	default:
+		return "-search.score"
+	}
+}
+
+// Highlight returns the title highlighted by MovieTable for fulltext and prefix search. Fuzzy
+// search highlights the words of the search when they match exactly, joining the titles holding
+// one of them.
+func (dialect) Highlight(r dto.ListMovieRequest, args *sqlrepository.Args) (string, string) {
+
+	switch {
+	case r.SearchMode == data.SearchModeFuzzy:
+		if ftsQuery(r.Title, r.SearchMode, " OR ") == "" {
+			return "title", ""
+		}
+
+		return "coalesce(highlight.marked, title)", fmt.Sprintf(`
+			LEFT JOIN (
+				SELECT rowid AS movie_id, highlight(movies_fts, 0, %s) AS marked
+				FROM movies_fts
+				WHERE movies_fts MATCH %s
+			) AS highlight ON highlight.movie_id = movies.id`, highlightMarkers, args.Add(ftsQuery(r.Title, r.SearchMode, " OR ")))
+	case ftsQuery(r.Title, r.SearchMode, " ") == "":
+		return "title", ""
+	default:
+		return "search.marked", ""
+	}
+}
+
+func (dialect) ArrayElements(array, alias string) string {
+	return fmt.Sprintf("json_each(%s) AS %s", array, alias)
+}
+
+// ftsQuery returns the title search as an FTS5 query, the words of the search quoted and joined
+// by operator, an implicit AND when it is a space. Every word of a prefix search matches as a
+// prefix e.g. "godf par" becomes "godf"* "par"*. It returns an empty string when the search has
+// no words.
+func ftsQuery(title, searchMode, operator string) string {
+
+	words := strings.FieldsFunc(title, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+
+		if searchMode == data.SearchModePrefix {
+			words[i] += "*"
+		}
+	}
+
+	return strings.Join(words, operator)
+}
+
+// isBusy reports whether err is raised because another connection held a lock on the database
+// for longer than the busy timeout, the transaction succeeds when retried.
+func isBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	// the primary result code is the low byte of an extended result code
+	code := sqliteErr.Code() & 0xff
+
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/filter"
+	"github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/movies/repositories"
+)
+
+var sortSafelist = []string{"id", "title", "year", "runtime", "relevance", "-id", "-title", "-year", "-runtime", "-relevance"}
+
+func newTestRepository(t *testing.T) repositories.MovieRepository {
+
+	repo := NewMovieRepository(newTestDB(t))
+
+	movies := []entities.Movie{
+		{Title: "Casablanca", Year: 1942, Runtime: 102, Genres: []string{"drama", "romance"}},
+		{Title: "Alien", Year: 1979, Runtime: 117, Genres: []string{"horror", "sci-fi"}},
+		{Title: "Aliens", Year: 1986, Runtime: 137, Genres: []string{"action", "sci-fi"}},
+		{Title: "The Breakfast Club", Year: 1985, Runtime: 97, Genres: []string{"comedy", "drama"}},
+		{Title: "Heat", Year: 1995, Runtime: 170, Genres: []string{"action", "crime", "drama"}},
+		{Title: "<b>100%</b> Club & Co", Year: 1999, Runtime: 139, Genres: []string{"drama"}},
+	}
+
+	for i := range movies {
+		if err := repo.Insert(context.Background(), &movies[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return repo
+}
+
+func titles(movies []*entities.Movie) []string {
+	result := []string{}
+	for _, movie := range movies {
+		result = append(result, movie.Title)
+	}
+
+	return result
+}
+
+func listRequest(r dto.ListMovieRequest, sort string) dto.ListMovieRequest {
+	r.Filters = data.Filters{Page: 1, PageSize: 20, Sort: sort, SortSafelist: sortSafelist, IncludeTotal: true}
+	return r
+}
+
+// TestGetAll checks the conditions written by the dialect: genres in JSON arrays, filter
+// expressions without the PostgreSQL operators and title search with FTS5 and trigrams.
+func TestGetAll(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		request    dto.ListMovieRequest
+		wantTitles []string
+	}{
+		{
+			name:       "All genres",
+			request:    listRequest(dto.ListMovieRequest{Genres: []string{"action", "drama"}}, "id"),
+			wantTitles: []string{"Heat"},
+		},
+		{
+			name:       "Any genre",
+			request:    listRequest(dto.ListMovieRequest{Genres: []string{"romance", "horror"}, GenresMode: data.GenresModeAny}, "id"),
+			wantTitles: []string{"Casablanca", "Alien"},
+		},
+		{
+			name:       "No genre",
+			request:    listRequest(dto.ListMovieRequest{Genres: []string{"drama"}, GenresMode: data.GenresModeNone}, "id"),
+			wantTitles: []string{"Alien", "Aliens"},
+		},
+		{
+			name: "Filter expression on genres and created_at",
+			request: listRequest(dto.ListMovieRequest{
+				Filter: &filter.Logical{
+					Op:    "AND",
+					Left:  &filter.Comparison{Field: filter.Field{Column: "genres", Type: filter.List}, Op: "has", Value: "sci-fi"},
+					Right: &filter.Not{Expr: &filter.Comparison{Field: filter.Field{Column: "created_at", Type: filter.Time}, Op: ">", Value: time.Now().Add(time.Hour)}},
+				},
+			}, "id"),
+			wantTitles: []string{"Alien", "Aliens"},
+		},
+		{
+			name: "Filter expression matching a LIKE wildcard",
+			request: listRequest(dto.ListMovieRequest{
+				Filter: &filter.Comparison{Field: filter.Field{Column: "title", Type: filter.Text}, Op: "~", Value: "0%"},
+			}, "id"),
+			wantTitles: []string{"<b>100%</b> Club & Co"},
+		},
+		{
+			name:       "Fulltext search",
+			request:    listRequest(dto.ListMovieRequest{Title: "alien"}, "id"),
+			wantTitles: []string{"Alien"},
+		},
+		{
+			name:       "Prefix search by relevance",
+			request:    listRequest(dto.ListMovieRequest{Title: "the br", SearchMode: data.SearchModePrefix}, "-relevance"),
+			wantTitles: []string{"The Breakfast Club"},
+		},
+		{
+			name:       "Fuzzy search",
+			request:    listRequest(dto.ListMovieRequest{Title: "aliens", SearchMode: data.SearchModeFuzzy}, "-relevance"),
+			wantTitles: []string{"Aliens", "Alien"},
+		},
+		{
+			name:       "Search without words",
+			request:    listRequest(dto.ListMovieRequest{Title: "&"}, "id"),
+			wantTitles: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepository(t)
+
+			movies, metadata, err := repo.GetAll(context.Background(), test.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := titles(movies); !reflect.DeepEqual(got, test.wantTitles) {
+				t.Errorf("want movies %v; got %v", test.wantTitles, got)
+			}
+
+			if metadata.TotalRecords != len(test.wantTitles) {
+				t.Errorf("want %d total records; got %d", len(test.wantTitles), metadata.TotalRecords)
+			}
+		})
+	}
+}
+
+// TestGetAllHighlight checks that the titles highlighted by FTS5 are escaped before their matches
+// are marked.
+func TestGetAllHighlight(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		request dto.ListMovieRequest
+		want    []string
+	}{
+		{
+			name:    "Fulltext search",
+			request: listRequest(dto.ListMovieRequest{Title: "club"}, "id"),
+			want:    []string{"The Breakfast <mark>Club</mark>", "&lt;b&gt;100%&lt;/b&gt; <mark>Club</mark> &amp; Co"},
+		},
+		{
+			name:    "Fuzzy search",
+			request: listRequest(dto.ListMovieRequest{Title: "club", SearchMode: data.SearchModeFuzzy}, "id"),
+			want:    []string{"&lt;b&gt;100%&lt;/b&gt; <mark>Club</mark> &amp; Co"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepository(t)
+
+			movies, _, err := repo.GetAll(context.Background(), test.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(movies) != len(test.want) {
+				t.Fatalf("want %d movies; got %v", len(test.want), titles(movies))
+			}
+
+			for i, movie := range movies {
+				if movie.Highlight != test.want[i] {
+					t.Errorf("want highlight %q; got %q", test.want[i], movie.Highlight)
+				}
+			}
+		})
+	}
+}
+
+func TestFacets(t *testing.T) {
+
+	repo := newTestRepository(t)
+
+	facets, err := repo.Facets(context.Background(), dto.ListMovieRequest{Genres: []string{"sci-fi"}}, []string{data.FacetGenres, data.FacetDecade, data.FacetRuntimeBucket})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := data.Facets{
+		data.FacetGenres:        {{Value: "sci-fi", Count: 2}, {Value: "action", Count: 1}, {Value: "horror", Count: 1}},
+		data.FacetDecade:        {{Value: "1970s", Count: 1}, {Value: "1980s", Count: 1}},
+		data.FacetRuntimeBucket: {{Value: "90-119", Count: 1}, {Value: "120-149", Count: 1}},
+	}
+
+	if !reflect.DeepEqual(facets, want) {
+		t.Errorf("want facets %v; got %v", want, facets)
+	}
+}
@@ -0,0 +1,35 @@
+// Package repository implements the repositories of the SQLite storage, with the queries of
+// sqlrepository written in the SQLite dialect.
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/terdia/greenlight/infrastructures/persistence/sqlrepository"
+	"github.com/terdia/greenlight/internal/unitofwork"
+	movie_repositories "github.com/terdia/greenlight/src/movies/repositories"
+	"github.com/terdia/greenlight/src/users/repositories"
+)
+
+func NewMovieRepository(db *sql.DB) movie_repositories.MovieRepository {
+	return sqlrepository.NewMovieRepository(db, dialect{})
+}
+
+func NewUserRepository(db *sql.DB) repositories.UserRepository {
+	return sqlrepository.NewUserRepository(db, dialect{})
+}
+
+func NewTokenRepository(db *sql.DB) repositories.TokenRepository {
+	return sqlrepository.NewTokenRepository(db, dialect{})
+}
+
+func NewPermissionRepository(db *sql.DB) repositories.PermissionRepository {
+	return sqlrepository.NewPermissionRepository(db, dialect{})
+}
+
+// NewUnitOfWork returns a unit of work running SQLite transactions, which are always
+// serializable: the database has a single writer and transactions take the write lock when
+// they begin.
+func NewUnitOfWork(db *sql.DB) unitofwork.UnitOfWork {
+	return sqlrepository.NewUnitOfWork(db, dialect{})
+}
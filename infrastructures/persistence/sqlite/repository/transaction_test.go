@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/movies/repositories"
+)
+
+var errRollback = errors.New("rollback")
+
+func newTestMovie(title string) *entities.Movie {
+	return &entities.Movie{Title: title, Year: 2000, Runtime: 100, Genres: []string{"drama"}}
+}
+
+func TestTransactions(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		fn         func(repo repositories.MovieRepository) error
+		wantErr    error
+		wantTitles []string
+	}{
+		{
+			name: "Commit",
+			fn: func(repo repositories.MovieRepository) error {
+				return repo.Insert(ctx, newTestMovie("Heat"))
+			},
+			wantTitles: []string{"Alien", "Heat"},
+		},
+		{
+			name: "Rollback",
+			fn: func(repo repositories.MovieRepository) error {
+				if err := repo.Insert(ctx, newTestMovie("Heat")); err != nil {
+					return err
+				}
+				return errRollback
+			},
+			wantErr:    errRollback,
+			wantTitles: []string{"Alien"},
+		},
+		{
+			name: "Rolled back savepoint",
+			fn: func(repo repositories.MovieRepository) error {
+				if err := repo.Insert(ctx, newTestMovie("Heat")); err != nil {
+					return err
+				}
+
+				err := repo.WithinTransaction(ctx, func(repo repositories.MovieRepository) error {
+					if err := repo.Delete(ctx, 1); err != nil {
+						return err
+					}
+					return errRollback
+				})
+
+				if !errors.Is(err, errRollback) {
+					t.Errorf("want savepoint error %v; got %v", errRollback, err)
+				}
+
+				return nil
+			},
+			wantTitles: []string{"Alien", "Heat"},
+		},
+		{
+			name: "Released savepoint",
+			fn: func(repo repositories.MovieRepository) error {
+				return repo.WithinTransaction(ctx, func(repo repositories.MovieRepository) error {
+					return repo.Delete(ctx, 1)
+				})
+			},
+			wantTitles: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := NewMovieRepository(newTestDB(t))
+
+			if err := repo.Insert(ctx, newTestMovie("Alien")); err != nil {
+				t.Fatal(err)
+			}
+
+			err := repo.WithinTransaction(ctx, test.fn)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("want error %v; got %v", test.wantErr, err)
+			}
+
+			titles := []string{}
+			err = repo.Export(ctx, dto.ListMovieRequest{}, func(movie *entities.Movie) error {
+				titles = append(titles, movie.Title)
+				return nil
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(titles, test.wantTitles) {
+				t.Errorf("want movies %v; got %v", test.wantTitles, titles)
+			}
+		})
+	}
+}
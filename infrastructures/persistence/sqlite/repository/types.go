@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// timestampLayout is the layout of CURRENT_TIMESTAMP, the default of the timestamp columns.
+// Times are stored and compared as UTC text in this layout, so they sort like the times.
+const timestampLayout = "2006-01-02 15:04:05"
+
+// timestamp returns t as the text of a timestamp column, to store it or compare it with one.
+func timestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+// stringArray reads and writes a []string as a JSON array, like pq.Array does for text[]
+// columns of PostgreSQL.
+type stringArray struct {
+	values *[]string
+}
+
+func jsonArray(values *[]string) stringArray {
+	return stringArray{values}
+}
+
+func (a stringArray) Value() (driver.Value, error) {
+	if *a.values == nil {
+		return "[]", nil
+	}
+
+	encoded, err := json.Marshal(*a.values)
+
+	return string(encoded), err
+}
+
+func (a stringArray) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(src), a.values)
+	case []byte:
+		return json.Unmarshal(src, a.values)
+	default:
+		return fmt.Errorf("cannot scan %T into a string array", src)
+	}
+}
+
+// isUniqueViolation reports whether err is the violation of the unique constraint of column
+// e.g. users.email.
+func isUniqueViolation(err error, column string) bool {
+	var sqliteErr *sqlite.Error
+
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code() != sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return false
+	}
+
+	return strings.Contains(sqliteErr.Error(), column)
+}
@@ -0,0 +1,89 @@
+// Package sqlite opens the SQLite database of the SQLite storage, for offline demos and
+// single-node deployments without PostgreSQL.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	sqlite_driver "modernc.org/sqlite"
+
+	"github.com/terdia/greenlight/config"
+	"github.com/terdia/greenlight/infrastructures/persistence/migrate"
+	"github.com/terdia/greenlight/internal/trigram"
+	sqlite_migrations "github.com/terdia/greenlight/migrations/sqlite"
+)
+
+// Scheme is the scheme of the DSN of a SQLite database e.g. sqlite://greenlight.db or
+// sqlite:///var/lib/greenlight/greenlight.db.
+const Scheme = "sqlite:"
+
+// pragmas apply to every connection: foreign keys are enforced, readers don't block the
+// writer, and transactions take the write lock when they begin, waiting up to 5 seconds for
+// it, so concurrent transactions wait for each other instead of failing when they first write.
+const pragmas = "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+
+func init() {
+	// similarity is the trigram similarity of pg_trgm, used by fuzzy title search
+	sqlite_driver.MustRegisterDeterministicScalarFunction("similarity", 2, func(ctx *sqlite_driver.FunctionContext, args []driver.Value) (driver.Value, error) {
+		a, _ := args[0].(string)
+		b, _ := args[1].(string)
+
+		return trigram.Similarity(a, b), nil
+	})
+}
+
+// IsDsn reports whether dsn is the DSN of a SQLite database.
+func IsDsn(dsn string) bool {
+	return strings.HasPrefix(dsn, Scheme)
+}
+
+// OpenDb opens the SQLite database of the DSN, creating it if needed.
+func OpenDb(cgf config.Db) (*sql.DB, error) {
+
+	path := strings.TrimPrefix(strings.TrimPrefix(cgf.Dsn, Scheme), "//")
+	if path == "" {
+		return nil, fmt.Errorf("sqlite dsn %q has no database path", cgf.Dsn)
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+separator+pragmas)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(cgf.MaxOpenConns)
+	db.SetMaxIdleConns(cgf.MaxIdleConns)
+
+	maxIdleTime, err := time.ParseDuration(cgf.MaxIdleTime)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	db.SetConnMaxIdleTime(maxIdleTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// NewMigrator returns the migrator of the embedded migrations. Migration transactions take the
+// write lock of the database when they begin, so processes migrating the same database apply each
+// migration once.
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, sqlite_migrations.FS, "")
+}
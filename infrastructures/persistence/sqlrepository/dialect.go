@@ -0,0 +1,93 @@
+// Package sqlrepository implements the repositories of the SQL databases. The queries are written
+// once, in the SQL that PostgreSQL and SQLite share, and each database provides a Dialect for the
+// rest e.g. array columns, title search and the errors of concurrent transactions.
+package sqlrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/filter"
+)
+
+// A Dialect writes the parts of the queries that differ between databases. Conditions on the
+// title search are only asked for requests searching a title.
+type Dialect interface {
+	// Array returns the argument reading or writing values as an array column e.g. genres.
+	Array(values *[]string) interface{}
+
+	// Time returns the argument storing t in a timestamp column, or comparing t with one.
+	Time(t time.Time) interface{}
+
+	// InArray returns the condition of expr being an element of array, the placeholder of an
+	// Array argument.
+	InArray(expr, array string) string
+
+	// IsUniqueViolation reports whether err is the violation of the unique constraint of column
+	// e.g. users.email.
+	IsUniqueViolation(err error, column string) bool
+
+	// TxOptions returns the options of the transactions of units of work.
+	TxOptions() *sql.TxOptions
+
+	// IsRetryable reports whether a unit of work failed because of a concurrent transaction, it
+	// succeeds when retried.
+	IsRetryable(err error) bool
+
+	// CopyIn returns the statement inserting rows into the columns of table one Exec at a time,
+	// and whether the rows are buffered until an Exec without arguments flushes them.
+	CopyIn(table string, columns ...string) (query string, flush bool)
+
+	// Stream reads the rows of a query reading a table of any size, without holding them all in
+	// memory. scan reads a row into a buffer of the caller and flush empties the buffer once the
+	// rows of a round trip are read, so the time spent in flush doesn't count against the query.
+	// It runs until the rows are read, bounded by the deadline of ctx.
+	Stream(ctx context.Context, db DBTX, query string, args []interface{}, scan func(rows *sql.Rows) error, flush func() error) error
+
+	// MovieTable returns the movies table of the FROM clause, joined with what the title search
+	// of the request reads.
+	MovieTable(r dto.ListMovieRequest, args *Args) string
+
+	// TitleCondition returns the condition of the title search, or an empty string when
+	// MovieTable already matches the movies with the search.
+	TitleCondition(r dto.ListMovieRequest, args *Args) string
+
+	// GenresCondition returns the condition of the genres of a movie matching genres, the
+	// placeholder of an Array argument, in the genres mode of a request.
+	GenresCondition(mode, genres string) string
+
+	// FilterCondition returns the filter expression of a request as a condition.
+	FilterCondition(expr filter.Expr, args *Args) string
+
+	// Relevance returns how well the title of a movie matches the title search, the highest
+	// the best.
+	Relevance(r dto.ListMovieRequest, args *Args) string
+
+	// Highlight returns the title with the words matching the title search between
+	// HighlightStart and HighlightStop, and the join of the tables it reads, if any.
+	Highlight(r dto.ListMovieRequest, args *Args) (column, join string)
+
+	// ArrayElements returns the table of the elements of array, a column, named alias and
+	// holding each element in its value column.
+	ArrayElements(array, alias string) string
+}
+
+// Highlights delimit the matches with control characters, which are turned into <mark> tags
+// once the title has been escaped, see markHighlight.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+// Args collects the arguments of a query built at runtime.
+type Args []interface{}
+
+// Add appends value to the arguments and returns its placeholder e.g. $3.
+func (args *Args) Add(value interface{}) string {
+	*args = append(*args, value)
+
+	return fmt.Sprintf("$%d", len(*args))
+}
@@ -0,0 +1,633 @@
+package sqlrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/movies/repositories"
+)
+
+var highlightMarks = strings.NewReplacer(HighlightStart, "<mark>", HighlightStop, "</mark>")
+
+type movieRepository struct {
+	DB      DBTX
+	dialect Dialect
+}
+
+func NewMovieRepository(db *sql.DB, dialect Dialect) repositories.MovieRepository {
+	return &movieRepository{DB: db, dialect: dialect}
+}
+
+func (repo *movieRepository) WithinTransaction(ctx context.Context, fn func(repo repositories.MovieRepository) error) error {
+	return RunInTransaction(ctx, repo.DB, nil, func(tx DBTX) error {
+		return fn(&movieRepository{DB: tx, dialect: repo.dialect})
+	})
+}
+
+func (repo *movieRepository) Insert(ctx context.Context, movie *entities.Movie) error {
+	query := `INSERT INTO movies (title, year, runtime, genres, language)
+			 VALUES($1, $2, $3, $4, $5)
+			 RETURNING id, created_at, version`
+
+	queryParams := []interface{}{movie.Title, movie.Year, movie.Runtime, repo.dialect.Array(&movie.Genres), movie.Language}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+
+	defer cancel()
+
+	return repo.DB.QueryRowContext(ctx, query, queryParams...).Scan(&movie.ID, &movie.CreatedAt, &movie.Version)
+}
+
+// CopyFrom inserts the movies with a single statement of the dialect e.g. COPY, which is much
+// faster than one INSERT per movie for bulk imports.
+func (repo *movieRepository) CopyFrom(ctx context.Context, movies []*entities.Movie) (int64, error) {
+
+	// COPY must run on a single connection, so it always runs in a transaction
+	// (or a savepoint when the repository is already bound to one).
+	err := RunInTransaction(ctx, repo.DB, nil, func(tx DBTX) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+		defer cancel()
+
+		query, flush := repo.dialect.CopyIn("movies", "title", "year", "runtime", "genres", "language")
+
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		defer stmt.Close()
+
+		for _, movie := range movies {
+			_, err := stmt.ExecContext(ctx, movie.Title, movie.Year, movie.Runtime, repo.dialect.Array(&movie.Genres), movie.Language)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !flush {
+			return nil
+		}
+
+		// flush the buffered rows
+		_, err = stmt.ExecContext(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(movies)), nil
+}
+
+func (repo *movieRepository) Get(ctx context.Context, id int64) (*entities.Movie, error) {
+
+	if id < 1 {
+		return nil, data.ErrRecordNotFound
+	}
+
+	query := `SELECT id, created_at, title, year, runtime, genres, language, version
+			  FROM movies
+			  WHERE id = $1`
+
+	var movie entities.Movie
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, id).Scan(
+		&movie.ID,
+		&movie.CreatedAt,
+		&movie.Title,
+		&movie.Year,
+		&movie.Runtime,
+		repo.dialect.Array(&movie.Genres),
+		&movie.Language,
+		&movie.Version,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &movie, nil
+}
+
+func (repo *movieRepository) Update(ctx context.Context, movie *entities.Movie) error {
+	// To enable Optimistic Concurrency Control (data race condition during edit)
+	//add version to where clause, to ensure first routine to send update request is persisted and  other routine
+	// receive edit error
+	query := `
+			UPDATE movies
+			SET title = $1, year = $2, runtime = $3, genres = $4, language = $5, version = version + 1
+			WHERE id = $6 AND version = $7
+			RETURNING version`
+
+	args := []interface{}{movie.Title, movie.Year, movie.Runtime, repo.dialect.Array(&movie.Genres), movie.Language, movie.ID, movie.Version}
+
+	// Execute the SQL query. If no matching row could be found, we know the movie
+	// version has changed (or the record has been deleted) and we return our custom
+	// ErrEditConflict error.
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(&movie.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return data.ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *movieRepository) Delete(ctx context.Context, id int64) error {
+	if id < 1 {
+		return data.ErrRecordNotFound
+	}
+
+	query := `DELETE FROM movies WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll returns a page of movies, either by page number (LIMIT/OFFSET) or, when the
+// filters hold an after/before cursor, by keyset pagination on (sort columns, id).
+func (repo *movieRepository) GetAll(ctx context.Context, r dto.ListMovieRequest) ([]*entities.Movie, data.Metadata, error) {
+
+	filters := r.Filters
+
+	var args Args
+	from := repo.dialect.MovieTable(r, &args)
+	where := repo.filterClause(r, &args)
+
+	// id is always the last sort key so the order, and the keyset, is unique
+	keys := append(filters.SortKeys(), data.SortKey{Column: "id", Direction: "ASC"})
+
+	columns := make([]string, len(keys))
+	relevance := repo.relevance(r, &args)
+
+	// relevance isn't a column, it is computed from the title search
+	for i, key := range keys {
+		columns[i] = key.Column
+
+		if key.Column == "relevance" {
+			keys[i].Column = relevance
+		}
+	}
+
+	keyset := "TRUE"
+
+	cursor, paginateByCursor := filters.Cursor()
+	backward := filters.Before != ""
+
+	// Reading backward, the rows before the cursor are read in reverse order and
+	// flipped once scanned.
+	if backward {
+		reverse := map[string]string{"ASC": "DESC", "DESC": "ASC"}
+		for i, key := range keys {
+			keys[i].Direction = reverse[key.Direction]
+		}
+	}
+
+	if paginateByCursor {
+		values := make([]interface{}, 0, len(keys))
+		for i, value := range cursor.Values {
+			values = append(values, parseSortValue(columns[i], value))
+		}
+
+		keyset = keysetCondition(keys, append(values, cursor.ID), &args)
+	}
+
+	order := make([]string, 0, len(keys))
+	for _, key := range keys {
+		order = append(order, key.Column+" "+key.Direction)
+	}
+
+	total := "0"
+	if filters.IncludeTotal {
+		total = fmt.Sprintf("(SELECT count(*) FROM %s WHERE %s)", from, where)
+	}
+
+	highlight, highlightJoin := repo.highlight(r, &args)
+
+	// one extra row is read to find out whether there is a next page
+	query := fmt.Sprintf(`
+			SELECT %s, %s, %s, id, created_at, title, year, runtime, genres, language, version
+			FROM %s%s
+			WHERE %s
+			AND %s
+			ORDER BY %s
+			LIMIT %s OFFSET %s`, total, relevance, highlight, from, highlightJoin, where, keyset,
+		strings.Join(order, ", "), args.Add(filters.Limit()+1), args.Add(filters.Offset()))
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	movies := []*entities.Movie{}
+	cursors := [][]string{}
+
+	for rows.Next() {
+		var movie entities.Movie
+		var relevance float64
+
+		err := rows.Scan(
+			&totalRecords,
+			&relevance,
+			&movie.Highlight,
+			&movie.ID,
+			&movie.CreatedAt,
+			&movie.Title,
+			&movie.Year,
+			&movie.Runtime,
+			repo.dialect.Array(&movie.Genres),
+			&movie.Language,
+			&movie.Version,
+		)
+
+		if err != nil {
+			return nil, data.Metadata{}, err
+		}
+
+		movie.Highlight = markHighlight(movie.Highlight)
+
+		movies = append(movies, &movie)
+		cursors = append(cursors, cursorValues(columns[:len(columns)-1], &movie, relevance))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	hasMore := len(movies) > filters.Limit()
+	if hasMore {
+		movies, cursors = movies[:filters.Limit()], cursors[:filters.Limit()]
+	}
+
+	if backward {
+		for i, j := 0, len(movies)-1; i < j; i, j = i+1, j-1 {
+			movies[i], movies[j] = movies[j], movies[i]
+			cursors[i], cursors[j] = cursors[j], cursors[i]
+		}
+	}
+
+	var metadata data.Metadata
+
+	switch {
+	case paginateByCursor:
+		metadata = data.Metadata{PageSize: filters.PageSize, TotalRecords: totalRecords}
+	case filters.IncludeTotal:
+		metadata = data.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	default:
+		metadata = data.Metadata{CurrentPage: filters.Page, PageSize: filters.PageSize, FirstPage: 1}
+	}
+
+	if len(movies) > 0 {
+		first, last := 0, len(movies)-1
+
+		if (backward && hasMore) || (!backward && (paginateByCursor || filters.Offset() > 0)) {
+			metadata.PrevCursor = data.Cursor{Sort: filters.Sort, Values: cursors[first], ID: int64(movies[first].ID)}.Encode()
+		}
+
+		if (!backward && hasMore) || backward {
+			metadata.NextCursor = data.Cursor{Sort: filters.Sort, Values: cursors[last], ID: int64(movies[last].ID)}.Encode()
+		}
+	}
+
+	return movies, metadata, nil
+}
+
+// cursorValues returns the values of the sort columns of a movie as text, for a cursor. They
+// are formatted in Go rather than by the database, SQLite rounds reals to 15 digits.
+func cursorValues(columns []string, movie *entities.Movie, relevance float64) []string {
+
+	values := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		switch column {
+		case "title":
+			values = append(values, movie.Title)
+		case "year":
+			values = append(values, strconv.FormatInt(int64(movie.Year), 10))
+		case "runtime":
+			values = append(values, strconv.FormatInt(int64(movie.Runtime), 10))
+		case "relevance":
+			values = append(values, strconv.FormatFloat(relevance, 'g', -1, 64))
+		default:
+			values = append(values, strconv.FormatInt(int64(movie.ID), 10))
+		}
+	}
+
+	return values
+}
+
+// parseSortValue parses a value of a cursor read from the text of cursorValues, so it compares
+// with the column as a number when the column is one. A value that doesn't parse is compared
+// as text.
+func parseSortValue(column, text string) interface{} {
+
+	switch column {
+	case "title":
+		return text
+	case "relevance":
+		if value, err := strconv.ParseFloat(text, 64); err == nil {
+			return value
+		}
+	default:
+		if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return value
+		}
+	}
+
+	return text
+}
+
+// movieFacetQueries holds, for each facet, the query counting the matching movies by facet value.
+// The queries read the matching CTE and return the facet, the value, the count and the position
+// of the value in the facet. %s is the table of the genres of the matching movies.
+var movieFacetQueries = map[string]string{
+	data.FacetGenres: `
+			SELECT 'genres', genre.value, count(*), row_number() OVER (ORDER BY count(*) DESC, genre.value)
+			FROM matching, %s
+			GROUP BY genre.value`,
+	data.FacetDecade: `
+			SELECT 'decade', CAST(year / 10 * 10 AS text) || 's', count(*), year / 10
+			FROM matching
+			GROUP BY year / 10`,
+	data.FacetRuntimeBucket: `
+			SELECT 'runtime_bucket', CASE bucket WHEN 1 THEN '0-89' WHEN 2 THEN '90-119' WHEN 3 THEN '120-149' ELSE '150+' END, count(*), bucket
+			FROM (SELECT CASE WHEN runtime < 90 THEN 1 WHEN runtime < 120 THEN 2 WHEN runtime < 150 THEN 3 ELSE 4 END AS bucket FROM matching) AS buckets
+			GROUP BY bucket`,
+}
+
+// Facets counts the movies matching the filters of the request by each value of the facets. The
+// movies are read once, in a CTE referenced by the count of every facet so it is materialized.
+func (repo *movieRepository) Facets(ctx context.Context, r dto.ListMovieRequest, facets []string) (data.Facets, error) {
+
+	result := data.Facets{}
+
+	if len(facets) == 0 {
+		return result, nil
+	}
+
+	var args Args
+
+	counts := make([]string, 0, len(facets))
+	for _, facet := range facets {
+		query, exists := movieFacetQueries[facet]
+		if !exists {
+			return nil, fmt.Errorf("unknown facet %q", facet)
+		}
+
+		if facet == data.FacetGenres {
+			query = fmt.Sprintf(query, repo.dialect.ArrayElements("matching.genres", "genre"))
+		}
+
+		counts = append(counts, query)
+		result[facet] = []data.FacetCount{}
+	}
+
+	query := fmt.Sprintf(`
+			WITH matching AS (
+				SELECT genres, year, runtime
+				FROM %s
+				WHERE %s
+			), facets (facet, value, count, position) AS (%s)
+			SELECT facet, value, count FROM facets
+			ORDER BY facet, position`, repo.dialect.MovieTable(r, &args), repo.filterClause(r, &args), strings.Join(counts, "\n\t\t\tUNION ALL"))
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var facet string
+		var count data.FacetCount
+
+		if err := rows.Scan(&facet, &count.Value, &count.Count); err != nil {
+			return nil, err
+		}
+
+		result[facet] = append(result[facet], count)
+	}
+
+	return result, rows.Err()
+}
+
+// keysetCondition returns the condition matching the rows after values in the order of keys,
+// e.g. for keys (year DESC, title ASC, id ASC):
+//
+//	(year < $1) OR (year = $1 AND title > $2) OR (year = $1 AND title = $2 AND id > $3)
+func keysetCondition(keys []data.SortKey, values []interface{}, args *Args) string {
+
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = args.Add(value)
+	}
+
+	disjuncts := make([]string, 0, len(keys))
+
+	for i, key := range keys {
+		op := ">"
+		if key.Direction == "DESC" {
+			op = "<"
+		}
+
+		conjuncts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conjuncts = append(conjuncts, fmt.Sprintf("%s = %s", keys[j].Column, placeholders[j]))
+		}
+
+		conjuncts = append(conjuncts, fmt.Sprintf("%s %s %s", key.Column, op, placeholders[i]))
+		disjuncts = append(disjuncts, "("+strings.Join(conjuncts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(disjuncts, " OR ") + ")"
+}
+
+// Export calls fn for every movie matching the title and genres of the request, ordered by id.
+// The rows are streamed by the dialect, so memory use doesn't grow with the size of the
+// catalogue.
+func (repo *movieRepository) Export(ctx context.Context, r dto.ListMovieRequest, fn func(movie *entities.Movie) error) error {
+
+	var args Args
+
+	query := fmt.Sprintf(`
+			SELECT id, created_at, title, year, runtime, genres, language, version
+			FROM %s
+			WHERE %s
+			ORDER BY id ASC`, repo.dialect.MovieTable(r, &args), repo.filterClause(r, &args))
+
+	var movies []*entities.Movie
+
+	scan := func(rows *sql.Rows) error {
+		var movie entities.Movie
+
+		err := rows.Scan(
+			&movie.ID,
+			&movie.CreatedAt,
+			&movie.Title,
+			&movie.Year,
+			&movie.Runtime,
+			repo.dialect.Array(&movie.Genres),
+			&movie.Language,
+			&movie.Version,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		movies = append(movies, &movie)
+
+		return nil
+	}
+
+	flush := func() error {
+		for _, movie := range movies {
+			if err := fn(movie); err != nil {
+				return err
+			}
+		}
+
+		movies = movies[:0]
+
+		return nil
+	}
+
+	return repo.dialect.Stream(ctx, repo.DB, query, args, scan, flush)
+}
+
+// filterClause returns the WHERE clause shared by GetAll, Facets and Export for the filters of
+// the request, adding its arguments to args.
+func (repo *movieRepository) filterClause(r dto.ListMovieRequest, args *Args) string {
+
+	conditions := []string{}
+
+	if r.Language != "" {
+		conditions = append(conditions, fmt.Sprintf("language = %s", args.Add(r.Language)))
+	}
+
+	if r.Title != "" {
+		if condition := repo.dialect.TitleCondition(r, args); condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	if len(r.Genres) > 0 {
+		conditions = append(conditions, repo.dialect.GenresCondition(r.GenresMode, args.Add(repo.dialect.Array(&r.Genres))))
+	}
+
+	if r.YearMin != 0 {
+		conditions = append(conditions, fmt.Sprintf("year >= %s", args.Add(r.YearMin)))
+	}
+
+	if r.YearMax != 0 {
+		conditions = append(conditions, fmt.Sprintf("year <= %s", args.Add(r.YearMax)))
+	}
+
+	if r.RuntimeMin != 0 {
+		conditions = append(conditions, fmt.Sprintf("runtime >= %s", args.Add(r.RuntimeMin)))
+	}
+
+	if r.RuntimeMax != 0 {
+		conditions = append(conditions, fmt.Sprintf("runtime <= %s", args.Add(r.RuntimeMax)))
+	}
+
+	if !r.CreatedAfter.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("created_at > %s", args.Add(repo.dialect.Time(r.CreatedAfter))))
+	}
+
+	if !r.CreatedBefore.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("created_at < %s", args.Add(repo.dialect.Time(r.CreatedBefore))))
+	}
+
+	if r.Filter != nil {
+		conditions = append(conditions, repo.dialect.FilterCondition(r.Filter, args))
+	}
+
+	if len(conditions) == 0 {
+		return "TRUE"
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+// relevance returns how well the title of a movie matches the title search of the request, 0
+// when the request has no title search.
+func (repo *movieRepository) relevance(r dto.ListMovieRequest, args *Args) string {
+
+	if r.Title == "" {
+		return "0"
+	}
+
+	return repo.dialect.Relevance(r, args)
+}
+
+// highlight returns the title with the words matching the title search between the highlight
+// markers, or an empty string when the request has no title search, and the join it reads.
+func (repo *movieRepository) highlight(r dto.ListMovieRequest, args *Args) (column, join string) {
+
+	if r.Title == "" {
+		return "''", ""
+	}
+
+	return repo.dialect.Highlight(r, args)
+}
+
+// markHighlight escapes a highlighted title, so it can be rendered as HTML, and marks its matches
+// with <mark> tags.
+func markHighlight(highlight string) string {
+	return highlightMarks.Replace(html.EscapeString(highlight))
+}
@@ -0,0 +1,28 @@
+package sqlrepository
+
+import "testing"
+
+func TestMarkHighlight(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		highlight string
+		want      string
+	}{
+		{name: "No search", highlight: "", want: ""},
+		{name: "Match", highlight: "The Breakfast \x02Club\x03", want: "The Breakfast <mark>Club</mark>"},
+		{
+			name:      "Markup in the title",
+			highlight: "<script>alert('\x02Club\x03')</script> & \"friends\"",
+			want:      "&lt;script&gt;alert(&#39;<mark>Club</mark>&#39;)&lt;/script&gt; &amp; &#34;friends&#34;",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := markHighlight(test.highlight); got != test.want {
+				t.Errorf("want %q; got %q", test.want, got)
+			}
+		})
+	}
+}
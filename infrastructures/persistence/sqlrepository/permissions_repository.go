@@ -0,0 +1,91 @@
+package sqlrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/src/users/repositories"
+)
+
+type permissionRepository struct {
+	DB      DBTX
+	dialect Dialect
+}
+
+func NewPermissionRepository(db *sql.DB, dialect Dialect) repositories.PermissionRepository {
+	return &permissionRepository{DB: db, dialect: dialect}
+}
+
+func (p *permissionRepository) GetAllForUser(ctx context.Context, userID custom_type.ID) (data.Permissions, error) {
+
+	query := `
+			SELECT permissions.code
+			FROM permissions
+			INNER JOIN users_permissions ON users_permissions.permission_id = permissions.id
+			INNER JOIN users ON users_permissions.user_id = users.id
+			WHERE users.id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	rows, err := p.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var permissions data.Permissions
+
+	for rows.Next() {
+		var permission string
+
+		err := rows.Scan(&permission)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// AddForUser grants the permissions to the user, unknown codes and permissions the user already
+// has are ignored.
+func (p *permissionRepository) AddForUser(ctx context.Context, userID custom_type.ID, codes ...string) error {
+	query := fmt.Sprintf(`
+		INSERT INTO users_permissions
+		SELECT $1, permissions.id FROM permissions WHERE %s
+		ON CONFLICT DO NOTHING`, p.dialect.InArray("permissions.code", "$2"))
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	_, err := p.DB.ExecContext(ctx, query, userID, p.dialect.Array(&codes))
+
+	return err
+}
+
+// RemoveForUser revokes the permissions of the user, permissions the user doesn't have are
+// ignored.
+func (p *permissionRepository) RemoveForUser(ctx context.Context, userID custom_type.ID, codes ...string) error {
+	query := fmt.Sprintf(`
+		DELETE FROM users_permissions
+		WHERE user_id = $1
+		AND permission_id IN (SELECT permissions.id FROM permissions WHERE %s)`, p.dialect.InArray("permissions.code", "$2"))
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	_, err := p.DB.ExecContext(ctx, query, userID, p.dialect.Array(&codes))
+
+	return err
+}
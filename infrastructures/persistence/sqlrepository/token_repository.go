@@ -0,0 +1,101 @@
+package sqlrepository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/src/users/entities"
+	"github.com/terdia/greenlight/src/users/repositories"
+)
+
+type tokenRepository struct {
+	DB      DBTX
+	dialect Dialect
+}
+
+func NewTokenRepository(db *sql.DB, dialect Dialect) repositories.TokenRepository {
+	return &tokenRepository{DB: db, dialect: dialect}
+}
+
+func (repo *tokenRepository) Create(ctx context.Context, token *entities.Token) error {
+
+	query := `
+			INSERT INTO tokens (hash, user_id, expiry, scope)
+			VALUES ($1, $2, $3, $4)`
+
+	args := []interface{}{token.Hash, token.UserId, repo.dialect.Time(token.Expiry), token.Scope}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	_, err := repo.DB.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+func (repo *tokenRepository) DeleteAllForUserByScope(ctx context.Context, scope string, userID custom_type.ID) error {
+
+	query := `
+			DELETE FROM tokens
+			WHERE scope = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	_, err := repo.DB.ExecContext(ctx, query, scope, userID)
+
+	return err
+}
+
+func (repo *tokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+
+	query := `
+			DELETE FROM tokens
+			WHERE expiry <= $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, repo.dialect.Time(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (repo *tokenRepository) Count(ctx context.Context) (entities.TokenCounts, error) {
+
+	query := `
+			SELECT scope, count(*) FILTER (WHERE expiry > $1), count(*) FILTER (WHERE expiry <= $1)
+			FROM tokens
+			GROUP BY scope`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, repo.dialect.Time(time.Now()))
+	if err != nil {
+		return entities.TokenCounts{}, err
+	}
+
+	defer rows.Close()
+
+	counts := entities.TokenCounts{Active: map[string]int{}}
+
+	for rows.Next() {
+		var scope string
+		var active, expired int
+
+		if err := rows.Scan(&scope, &active, &expired); err != nil {
+			return entities.TokenCounts{}, err
+		}
+
+		counts.Active[scope] = active
+		counts.Expired += expired
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,77 @@
+package sqlrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const savepoint = "greenlight_savepoint"
+
+// DBTX is satisfied by both *sql.DB and *sql.Tx, so repositories can run their
+// queries either directly against the pool or inside a transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// RunInTransaction calls fn within a new transaction, or within a savepoint when db is
+// already a transaction. The work done by fn is committed if it returns nil and rolled
+// back otherwise, a new transaction is also rolled back when ctx is done. opts only apply to a
+// new transaction, a savepoint runs with the options of the enclosing transaction.
+func RunInTransaction(ctx context.Context, db DBTX, opts *sql.TxOptions, fn func(tx DBTX) error) error {
+
+	switch conn := db.(type) {
+	case *sql.DB:
+		tx, err := conn.BeginTx(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		// Rollback is a no-op once the transaction has been committed, this takes care
+		// of both errors and panics raised by fn.
+		defer tx.Rollback()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+
+	case *sql.Tx:
+		if err := execSavepoint(ctx, conn, "SAVEPOINT"); err != nil {
+			return err
+		}
+
+		defer func() {
+			if p := recover(); p != nil {
+				execSavepoint(ctx, conn, "ROLLBACK TO SAVEPOINT")
+				panic(p)
+			}
+		}()
+
+		if err := fn(conn); err != nil {
+			if rollbackErr := execSavepoint(ctx, conn, "ROLLBACK TO SAVEPOINT"); rollbackErr != nil {
+				return fmt.Errorf("%w (rollback to savepoint: %s)", err, rollbackErr)
+			}
+
+			return err
+		}
+
+		return execSavepoint(ctx, conn, "RELEASE SAVEPOINT")
+
+	default:
+		return fmt.Errorf("unsupported database handle %T", db)
+	}
+}
+
+func execSavepoint(ctx context.Context, tx *sql.Tx, command string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, command+" "+savepoint)
+
+	return err
+}
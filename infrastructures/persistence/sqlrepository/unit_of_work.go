@@ -0,0 +1,41 @@
+package sqlrepository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/terdia/greenlight/internal/unitofwork"
+)
+
+// maxUnitOfWorkAttempts is how many times a unit of work runs before the failure caused by a
+// concurrent transaction is returned to the caller.
+const maxUnitOfWorkAttempts = 3
+
+type unitOfWork struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewUnitOfWork returns a unit of work running the repositories in transactions with the options
+// of the dialect, retried while they fail because of a concurrent transaction.
+func NewUnitOfWork(db *sql.DB, dialect Dialect) unitofwork.UnitOfWork {
+	return &unitOfWork{db: db, dialect: dialect}
+}
+
+func (uow *unitOfWork) Do(ctx context.Context, fn func(repos unitofwork.Repositories) error) error {
+
+	return unitofwork.Retry(ctx, maxUnitOfWorkAttempts, uow.IsRetryable, func() error {
+		return RunInTransaction(ctx, uow.db, uow.dialect.TxOptions(), func(tx DBTX) error {
+			return fn(unitofwork.Repositories{
+				Movies:      &movieRepository{DB: tx, dialect: uow.dialect},
+				Users:       &userRepository{DB: tx, dialect: uow.dialect},
+				Tokens:      &tokenRepository{DB: tx, dialect: uow.dialect},
+				Permissions: &permissionRepository{DB: tx, dialect: uow.dialect},
+			})
+		})
+	})
+}
+
+func (uow *unitOfWork) IsRetryable(err error) bool {
+	return uow.dialect.IsRetryable(err)
+}
@@ -1,4 +1,4 @@
-package repository
+package sqlrepository
 
 import (
 	"context"
@@ -17,14 +17,15 @@ const (
 )
 
 type userRepository struct {
-	*sql.DB
+	DB      DBTX
+	dialect Dialect
 }
 
-func NewUserRepoitory(db *sql.DB) repositories.UserRepository {
-	return &userRepository{db}
+func NewUserRepository(db *sql.DB, dialect Dialect) repositories.UserRepository {
+	return &userRepository{DB: db, dialect: dialect}
 }
 
-func (repo *userRepository) Insert(user *entities.User) error {
+func (repo *userRepository) Insert(ctx context.Context, user *entities.User) error {
 	query := `
 			INSERT INTO users (name, email, password_hash, activated)
 			VALUES ($1, $2, $3, $4)
@@ -32,18 +33,23 @@ func (repo *userRepository) Insert(user *entities.User) error {
 
 	queryParams := []interface{}{user.Name, user.Email, user.Password.Hash, user.Activated}
 
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
 	defer cancel()
 
-	err := repo.QueryRowContext(ctx, query, queryParams...).Scan(&user.ID, &user.CreatedAt, &user.Version)
+	err := repo.DB.QueryRowContext(ctx, query, queryParams...).Scan(&user.ID, &user.CreatedAt, &user.Version)
 	if err != nil {
-		return err
+		switch {
+		case repo.dialect.IsUniqueViolation(err, "users.email"):
+			return data.ErrDuplicateEmail
+		default:
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (repo *userRepository) GetByEmail(email string) (*entities.User, error) {
+func (repo *userRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
 
 	query := `
 			SELECT id, created_at, name, email, password_hash, activated, version
@@ -52,10 +58,10 @@ func (repo *userRepository) GetByEmail(email string) (*entities.User, error) {
 
 	var user entities.User
 
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
 	defer cancel()
 
-	err := repo.QueryRowContext(ctx, query, email).Scan(
+	err := repo.DB.QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
 		&user.CreatedAt,
 		&user.Name,
@@ -77,7 +83,7 @@ func (repo *userRepository) GetByEmail(email string) (*entities.User, error) {
 	return &user, nil
 }
 
-func (repo *userRepository) Update(user *entities.User) error {
+func (repo *userRepository) Update(ctx context.Context, user *entities.User) error {
 	query := `
 			UPDATE users
 			SET name = $1, email = $2, password_hash = $3, activated = $4, version = version + 1
@@ -89,14 +95,14 @@ func (repo *userRepository) Update(user *entities.User) error {
 	// Execute the SQL query. If no matching row could be found, we know the user
 	// version has changed (or the record has been deleted) and we return our custom
 	// ErrEditConflict error.
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
 
 	defer cancel()
 
 	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(&user.Version)
 	if err != nil {
 		switch {
-		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
+		case repo.dialect.IsUniqueViolation(err, "users.email"):
 			return data.ErrDuplicateEmail
 		case errors.Is(err, sql.ErrNoRows):
 			return data.ErrEditConflict
@@ -108,12 +114,12 @@ func (repo *userRepository) Update(user *entities.User) error {
 	return nil
 }
 
-func (repo *userRepository) GetForToken(tokenPlainText, scope string) (*entities.User, error) {
+func (repo *userRepository) GetForToken(ctx context.Context, tokenPlainText, scope string) (*entities.User, error) {
 
 	hash := sha256.Sum256([]byte(tokenPlainText))
 
 	query := `
-			SELECT users.id, users.created_at, users.name, users.email, 
+			SELECT users.id, users.created_at, users.name, users.email,
 			users.password_hash, users.activated, users.version
 			FROM users
 			INNER JOIN tokens
@@ -122,9 +128,9 @@ func (repo *userRepository) GetForToken(tokenPlainText, scope string) (*entities
 			AND tokens.scope = $2
 			AND tokens.expiry > $3`
 
-	args := []interface{}{hash[:], scope, time.Now()}
+	args := []interface{}{hash[:], scope, repo.dialect.Time(time.Now())}
 
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
 	defer cancel()
 
 	var user entities.User
@@ -151,3 +157,19 @@ func (repo *userRepository) GetForToken(tokenPlainText, scope string) (*entities
 	return &user, nil
 
 }
+
+func (repo *userRepository) Count(ctx context.Context) (entities.UserCounts, error) {
+
+	query := `
+			SELECT count(*), count(*) FILTER (WHERE activated)
+			FROM users`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var counts entities.UserCounts
+
+	err := repo.DB.QueryRowContext(ctx, query).Scan(&counts.Total, &counts.Activated)
+
+	return counts, err
+}
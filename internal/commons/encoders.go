@@ -0,0 +1,461 @@
+package commons
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// encodeJSON writes the envelope indented with tabs, or compact with pretty=false.
+func encodeJSON(w io.Writer, envelop ResponseObject, params map[string]string) error {
+
+	var js []byte
+	var err error
+
+	if params["pretty"] == "false" {
+		js, err = json.Marshal(envelop)
+	} else {
+		js, err = json.MarshalIndent(envelop, "", "\t")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(js, '\n'))
+
+	return err
+}
+
+// The XML, CSV and MessagePack encoders are written against the JSON representation of the
+// envelope, so every media type has the same field names and values.
+
+// object is a JSON object with the order of its members preserved.
+type object []member
+
+type member struct {
+	key   string
+	value interface{}
+}
+
+// toTree returns the JSON representation of v as object, []interface{}, string, json.Number,
+// bool and nil values.
+func toTree(v interface{}) (interface{}, error) {
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(js))
+	decoder.UseNumber()
+
+	return readTree(decoder)
+}
+
+func readTree(decoder *json.Decoder) (interface{}, error) {
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		o := object{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := readTree(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			o = append(o, member{key.(string), value})
+		}
+
+		_, err = decoder.Token()
+
+		return o, err
+
+	case json.Delim('['):
+		a := []interface{}{}
+		for decoder.More() {
+			value, err := readTree(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			a = append(a, value)
+		}
+
+		_, err = decoder.Token()
+
+		return a, err
+
+	default:
+		return token, nil
+	}
+}
+
+// encodeXML writes the envelope in a response element, object members are written as elements
+// named after their key and array values as item elements e.g.
+//
+//	<response><status>success</status><data><movie><genres><item>drama</item></genres></movie></data></response>
+func encodeXML(w io.Writer, envelop ResponseObject, params map[string]string) error {
+
+	tree, err := toTree(envelop)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	if params["pretty"] != "false" {
+		encoder.Indent("", "\t")
+	}
+
+	if err := writeXMLElement(encoder, "response", tree); err != nil {
+		return err
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+
+	return err
+}
+
+func writeXMLElement(encoder *xml.Encoder, name string, value interface{}) error {
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	// keys which aren't valid element names e.g. "0-89" are written as an attribute
+	if !isXMLName(name) {
+		start = xml.StartElement{
+			Name: xml.Name{Local: "entry"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+		}
+	}
+
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case object:
+		for _, m := range v {
+			if err := writeXMLElement(encoder, m.key, m.value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := writeXMLElement(encoder, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := encoder.EncodeToken(xml.CharData(scalarString(v))); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+func isXMLName(name string) bool {
+
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+
+	for i, c := range name {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || (!unicode.IsDigit(c) && c != '-' && c != '.')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodeCSV writes the first array of objects of the response data, e.g. data.movies, with a
+// header row of the object keys. Arrays of values are joined with commas and nested objects
+// are written as JSON. Other responses return ErrUnsupportedEnvelope.
+func encodeCSV(w io.Writer, envelop ResponseObject, params map[string]string) error {
+
+	tree, err := toTree(envelop.Data)
+	if err != nil {
+		return err
+	}
+
+	rows, ok := csvRows(tree)
+	if !ok {
+		return ErrUnsupportedEnvelope
+	}
+
+	header := []string{}
+	columns := map[string]int{}
+
+	for _, row := range rows {
+		for _, m := range row {
+			if _, exists := columns[m.key]; !exists {
+				columns[m.key] = len(header)
+				header = append(header, m.key)
+			}
+		}
+	}
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(header))
+
+		for _, m := range row {
+			if record[columns[m.key]], err = csvValue(m.value); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func csvRows(data interface{}) ([]object, bool) {
+
+	o, ok := data.(object)
+	if !ok {
+		return nil, false
+	}
+
+	for _, m := range o {
+		values, ok := m.value.([]interface{})
+		if !ok {
+			continue
+		}
+
+		rows := make([]object, 0, len(values))
+		for _, value := range values {
+			row, ok := value.(object)
+			if !ok {
+				return nil, false
+			}
+
+			rows = append(rows, row)
+		}
+
+		return rows, true
+	}
+
+	return nil, false
+}
+
+func csvValue(value interface{}) (string, error) {
+
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case object:
+		return treeJSON(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, nested := item.(object); nested {
+				return treeJSON(v)
+			}
+			values = append(values, scalarString(item))
+		}
+
+		return strings.Join(values, ","), nil
+	default:
+		return scalarString(v), nil
+	}
+}
+
+func treeJSON(value interface{}) (string, error) {
+
+	var b strings.Builder
+
+	switch v := value.(type) {
+	case object:
+		b.WriteByte('{')
+		for i, m := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+
+			key, _ := json.Marshal(m.key)
+			b.Write(key)
+			b.WriteByte(':')
+
+			js, err := treeJSON(m.value)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(js)
+		}
+		b.WriteByte('}')
+
+	case []interface{}:
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+
+			js, err := treeJSON(item)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(js)
+		}
+		b.WriteByte(']')
+
+	default:
+		js, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		b.Write(js)
+	}
+
+	return b.String(), nil
+}
+
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return ""
+	}
+}
+
+// encodeMessagePack writes the envelope in the MessagePack format (https://msgpack.org).
+func encodeMessagePack(w io.Writer, envelop ResponseObject, params map[string]string) error {
+
+	tree, err := toTree(envelop)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	writeMessagePack(&b, tree)
+
+	_, err = w.Write(b.Bytes())
+
+	return err
+}
+
+func writeMessagePack(b *bytes.Buffer, value interface{}) {
+
+	switch v := value.(type) {
+	case nil:
+		b.WriteByte(0xc0)
+
+	case bool:
+		if v {
+			b.WriteByte(0xc3)
+		} else {
+			b.WriteByte(0xc2)
+		}
+
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			writeMessagePackInt(b, i)
+			return
+		}
+
+		f, _ := v.Float64()
+		b.WriteByte(0xcb)
+		binary.Write(b, binary.BigEndian, math.Float64bits(f))
+
+	case string:
+		writeMessagePackHeader(b, len(v), 0xa0, 31, 0xd9, 0xda, 0xdb)
+		b.WriteString(v)
+
+	case []interface{}:
+		writeMessagePackHeader(b, len(v), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			writeMessagePack(b, item)
+		}
+
+	case object:
+		writeMessagePackHeader(b, len(v), 0x80, 15, 0, 0xde, 0xdf)
+		for _, m := range v {
+			writeMessagePack(b, m.key)
+			writeMessagePack(b, m.value)
+		}
+	}
+}
+
+// writeMessagePackHeader writes the type and length of a string, array or map: the fix format
+// when n is at most fixMax, otherwise the 8 (strings only), 16 or 32 bit length format.
+func writeMessagePackHeader(b *bytes.Buffer, n int, fix byte, fixMax int, format8, format16, format32 byte) {
+	switch {
+	case n <= fixMax:
+		b.WriteByte(fix | byte(n))
+	case format8 != 0 && n <= math.MaxUint8:
+		b.WriteByte(format8)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(format16)
+		binary.Write(b, binary.BigEndian, uint16(n))
+	default:
+		b.WriteByte(format32)
+		binary.Write(b, binary.BigEndian, uint32(n))
+	}
+}
+
+func writeMessagePackInt(b *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= 127:
+		b.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		b.WriteByte(byte(i))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		b.WriteByte(0xd0)
+		b.WriteByte(byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		b.WriteByte(0xd1)
+		binary.Write(b, binary.BigEndian, int16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		b.WriteByte(0xd2)
+		binary.Write(b, binary.BigEndian, int32(i))
+	default:
+		b.WriteByte(0xd3)
+		binary.Write(b, binary.BigEndian, i)
+	}
+}
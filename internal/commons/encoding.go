@@ -0,0 +1,148 @@
+package commons
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedEnvelope is returned by an encoder that can't represent a response e.g. CSV
+// for a response that isn't a list.
+var ErrUnsupportedEnvelope = errors.New("the response can't be represented in the media type")
+
+// Encoder writes a response envelope in a media type, params are the parameters of the media
+// type in the Accept header e.g. pretty=false.
+type Encoder interface {
+	Encode(w io.Writer, envelop ResponseObject, params map[string]string) error
+}
+
+// EncoderFunc adapts a function to the Encoder interface.
+type EncoderFunc func(w io.Writer, envelop ResponseObject, params map[string]string) error
+
+func (fn EncoderFunc) Encode(w io.Writer, envelop ResponseObject, params map[string]string) error {
+	return fn(w, envelop, params)
+}
+
+const defaultMediaType = "application/json"
+
+var encoders = struct {
+	sync.RWMutex
+	byMediaType map[string]Encoder
+}{byMediaType: map[string]Encoder{
+	"application/json":      EncoderFunc(encodeJSON),
+	"application/xml":       EncoderFunc(encodeXML),
+	"text/xml":              EncoderFunc(encodeXML),
+	"text/csv":              EncoderFunc(encodeCSV),
+	"application/msgpack":   EncoderFunc(encodeMessagePack),
+	"application/x-msgpack": EncoderFunc(encodeMessagePack),
+}}
+
+// RegisterEncoder adds or replaces the encoder of a media type.
+func RegisterEncoder(mediaType string, encoder Encoder) {
+	encoders.Lock()
+	defer encoders.Unlock()
+
+	encoders.byMediaType[mediaType] = encoder
+}
+
+// negotiation is the media type selected for the responses of a request.
+type negotiation struct {
+	mediaType string
+	params    map[string]string
+	encoder   Encoder
+	problem   bool // errors are written as problem details
+}
+
+type negotiationContextKey struct{}
+
+// NegotiateContent selects the media type of the responses from the Accept header of the
+// request and stores it in the request context, ok is false when no acceptable media type
+// has an encoder. JSON is used when the header is missing or accepts any type, or only
+// accepts problem details.
+func NegotiateContent(r *http.Request) (*http.Request, bool) {
+
+	n, ok := negotiate(r.Header.Get("Accept"))
+	if !ok {
+		return r, false
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), negotiationContextKey{}, n)), true
+}
+
+func negotiationFromRequest(r *http.Request) negotiation {
+	if n, ok := r.Context().Value(negotiationContextKey{}).(negotiation); ok {
+		return n
+	}
+
+	return negotiation{mediaType: defaultMediaType, encoder: EncoderFunc(encodeJSON)}
+}
+
+type acceptedType struct {
+	mediaType string
+	params    map[string]string
+	q         float64
+}
+
+func negotiate(accept string) (negotiation, bool) {
+
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+
+	accepted := []acceptedType{}
+	problem := false
+
+	for _, value := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, exists := params["q"]; exists {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+			delete(params, "q")
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		if mediaType == ProblemMediaType {
+			problem = true
+			continue
+		}
+
+		accepted = append(accepted, acceptedType{mediaType, params, q})
+	}
+
+	if problem && len(accepted) == 0 {
+		return negotiation{mediaType: defaultMediaType, encoder: EncoderFunc(encodeJSON), problem: true}, true
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].q > accepted[j].q })
+
+	encoders.RLock()
+	defer encoders.RUnlock()
+
+	for _, a := range accepted {
+		mediaType := a.mediaType
+		if mediaType == "*/*" || mediaType == "application/*" {
+			mediaType = defaultMediaType
+		}
+
+		if encoder, exists := encoders.byMediaType[mediaType]; exists {
+			return negotiation{mediaType: mediaType, params: a.params, encoder: encoder, problem: problem}, true
+		}
+	}
+
+	return negotiation{problem: problem}, false
+}
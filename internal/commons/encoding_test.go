@@ -0,0 +1,118 @@
+package commons
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/terdia/greenlight/internal/custom_type"
+)
+
+func TestNegotiate(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		accept        string
+		wantMediaType string
+		wantOk        bool
+	}{
+		{"No Accept header", "", "application/json", true},
+		{"Any type", "*/*", "application/json", true},
+		{"Browser", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "application/xml", true},
+		{"Quality", "application/json;q=0.5, text/csv", "text/csv", true},
+		{"MessagePack", "application/msgpack", "application/msgpack", true},
+		{"Excluded type", "application/xml;q=0, text/plain", "", false},
+		{"Unsupported type", "image/png", "", false},
+		{"Problem details only", "application/problem+json", "application/json", true},
+		{"Problem details and XML", "application/problem+json, application/xml", "application/xml", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n, ok := negotiate(test.accept)
+
+			if ok != test.wantOk {
+				t.Fatalf("want ok to be %t; got %t", test.wantOk, ok)
+			}
+
+			if n.mediaType != test.wantMediaType {
+				t.Errorf("want %q; got %q", test.wantMediaType, n.mediaType)
+			}
+		})
+	}
+}
+
+type encodingMovie struct {
+	ID     int64    `json:"id"`
+	Title  string   `json:"title"`
+	Genres []string `json:"genres"`
+}
+
+func TestEncoders(t *testing.T) {
+
+	list := ResponseObject{
+		StatusMsg: custom_type.Success,
+		Data: struct {
+			Movies []encodingMovie `json:"movies"`
+		}{[]encodingMovie{{1, "Casablanca", []string{"drama", "romance"}}, {2, "Alien, the", nil}}},
+	}
+
+	single := ResponseObject{StatusMsg: custom_type.Success, Data: map[string]int{"id": 1}}
+
+	tests := []struct {
+		name    string
+		encoder EncoderFunc
+		params  map[string]string
+		envelop ResponseObject
+		want    string
+	}{
+		{"Compact JSON", encodeJSON, map[string]string{"pretty": "false"}, single, "{\"status\":\"success\",\"data\":{\"id\":1}}\n"},
+		{"Pretty JSON", encodeJSON, nil, single, "{\n\t\"status\": \"success\",\n\t\"data\": {\n\t\t\"id\": 1\n\t}\n}\n"},
+		{"CSV", encodeCSV, nil, list, "id,title,genres\n1,Casablanca,\"drama,romance\"\n2,\"Alien, the\",\n"},
+		{
+			"XML",
+			encodeXML,
+			map[string]string{"pretty": "false"},
+			list,
+			"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response><status>success</status><data><movies>" +
+				"<item><id>1</id><title>Casablanca</title><genres><item>drama</item><item>romance</item></genres></item>" +
+				"<item><id>2</id><title>Alien, the</title><genres></genres></item></movies></data></response>\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			if err := test.encoder(&buf, test.envelop, test.params); err != nil {
+				t.Fatalf("want error to be %v; got %s", nil, err.Error())
+			}
+
+			if buf.String() != test.want {
+				t.Errorf("want %q; got %q", test.want, buf.String())
+			}
+		})
+	}
+
+	t.Run("CSV of a single resource", func(t *testing.T) {
+		if err := encodeCSV(&bytes.Buffer{}, single, nil); !errors.Is(err, ErrUnsupportedEnvelope) {
+			t.Errorf("want %v; got %v", ErrUnsupportedEnvelope, err)
+		}
+	})
+
+	t.Run("MessagePack", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		envelop := ResponseObject{StatusMsg: custom_type.Fail, Data: map[string]interface{}{"n": -200, "ok": true, "x": 1.5}}
+		if err := encodeMessagePack(&buf, envelop, nil); err != nil {
+			t.Fatalf("want error to be %v; got %s", nil, err.Error())
+		}
+
+		// {"status":"fail","data":{"n":-200,"ok":true,"x":1.5}}
+		want := "82a6737461747573a46661696ca46461746183a16ed1ff38a26f6bc3a178cb3ff8000000000000"
+		if got := hex.EncodeToString(buf.Bytes()); got != want {
+			t.Errorf("want %s; got %s", want, got)
+		}
+	})
+}
@@ -1,13 +1,20 @@
 package commons
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/terdia/greenlight/infrastructures/dto"
 	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/validator"
 )
 
+// StatusClientClosedRequest is the status recorded, after nginx, for a request whose client went
+// away before the response was written, so it isn't counted as a success.
+const StatusClientClosedRequest = 499
+
 func (util *sharedUtils) LogErrorWithHttpRequestContext(r *http.Request, err error) {
 	util.logger.PrintError(err, map[string]string{
 		"request_method": r.Method,
@@ -19,11 +26,28 @@ func (util *sharedUtils) LogErrorWithContext(err error, context map[string]strin
 	util.logger.PrintError(err, context)
 }
 
+// ServerErrorResponse responds with a 500 Internal Server Error, unless the error is caused by
+// the request context: a request past its deadline gets a 503 Service Unavailable and only the
+// StatusClientClosedRequest status is written for a client that went away.
 func (util *sharedUtils) ServerErrorResponse(rw http.ResponseWriter, r *http.Request, err error) {
+
+	switch {
+	case errors.Is(r.Context().Err(), context.DeadlineExceeded):
+		util.ErrorResponse(rw, r, http.StatusServiceUnavailable, ResponseObject{
+			Code:    CodeTimeout,
+			Message: "the request took too long to process, please try again later",
+		})
+		return
+	case errors.Is(r.Context().Err(), context.Canceled):
+		rw.WriteHeader(StatusClientClosedRequest)
+		return
+	}
+
 	util.LogErrorWithHttpRequestContext(r, err)
 
 	message := "the server encountered a problem and could not process your request"
 	util.ErrorResponse(rw, r, http.StatusInternalServerError, ResponseObject{
+		Code:    CodeServerError,
 		Message: message,
 	})
 }
@@ -31,6 +55,7 @@ func (util *sharedUtils) ServerErrorResponse(rw http.ResponseWriter, r *http.Req
 func (util *sharedUtils) NotFoundResponse(rw http.ResponseWriter, r *http.Request) {
 
 	util.ErrorResponse(rw, r, http.StatusNotFound, ResponseObject{
+		Code:    CodeNotFound,
 		Message: "the requested resource could not be found",
 	})
 }
@@ -38,27 +63,32 @@ func (util *sharedUtils) NotFoundResponse(rw http.ResponseWriter, r *http.Reques
 func (util *sharedUtils) MethodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
 	message := fmt.Sprintf("the %s method is not supported for this resource", r.Method)
 	util.ErrorResponse(w, r, http.StatusMethodNotAllowed, ResponseObject{
+		Code:    CodeMethodNotAllowed,
 		Message: message,
 	})
 }
 
 func (util *sharedUtils) BadRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
 	util.ErrorResponse(w, r, http.StatusBadRequest, ResponseObject{
+		Code:    CodeBadRequest,
 		Message: err.Error(),
 	})
 }
 
-func (util *sharedUtils) FailedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
+func (util *sharedUtils) FailedValidationResponse(w http.ResponseWriter, r *http.Request, errors validator.Errors) {
 	util.ErrorResponse(w, r, http.StatusUnprocessableEntity, ResponseObject{
 		StatusMsg: custom_type.Fail,
+		Code:      CodeFailedValidation,
 		Data: dto.ValidationError{
-			Errors: errors,
+			Errors:  errors.Flat(),
+			Details: errors.List(),
 		},
 	})
 }
 
 func (util *sharedUtils) EditConflictResponse(w http.ResponseWriter, r *http.Request) {
 	util.ErrorResponse(w, r, http.StatusConflict, ResponseObject{
+		Code:    CodeEditConflict,
 		Message: "unable to update the record due to an edit conflict, please try again",
 	})
 }
@@ -66,6 +96,7 @@ func (util *sharedUtils) EditConflictResponse(w http.ResponseWriter, r *http.Req
 func (util *sharedUtils) RateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
 
 	util.ErrorResponse(w, r, http.StatusTooManyRequests, ResponseObject{
+		Code:    CodeRateLimitExceeded,
 		Message: "rate limit exceeded",
 	})
 }
@@ -74,6 +105,7 @@ func (util *sharedUtils) InvalidCredentialsResponse(w http.ResponseWriter, r *ht
 
 	util.ErrorResponse(w, r, http.StatusUnauthorized, ResponseObject{
 		StatusMsg: custom_type.Fail,
+		Code:      CodeInvalidCredentials,
 		Message:   "invalid authentication credentials",
 	})
 }
@@ -83,24 +115,53 @@ func (util *sharedUtils) InvalidAuthenticationTokenResponse(w http.ResponseWrite
 
 	util.ErrorResponse(w, r, http.StatusUnauthorized, ResponseObject{
 		StatusMsg: custom_type.Fail,
+		Code:      CodeInvalidToken,
 		Message:   "invalid or missing token",
 	})
 }
 
 func (util *sharedUtils) AuthenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
 	util.ErrorResponse(w, r, http.StatusUnauthorized, ResponseObject{
+		Code:    CodeAuthenticationRequired,
 		Message: "you must be authenticated to access this resource",
 	})
 }
 
 func (util *sharedUtils) InactiveAccountResponse(w http.ResponseWriter, r *http.Request) {
 	util.ErrorResponse(w, r, http.StatusForbidden, ResponseObject{
+		Code:    CodeInactiveAccount,
 		Message: "your user account must be activated to access this resource",
 	})
 }
 
 func (util *sharedUtils) NotPermittedRResponse(w http.ResponseWriter, r *http.Request) {
 	util.ErrorResponse(w, r, http.StatusForbidden, ResponseObject{
+		Code:    CodeNotPermitted,
 		Message: "your user account doesn't have the necessary permissions to perform this operation",
 	})
 }
+
+func (util *sharedUtils) UnsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request) {
+	message := fmt.Sprintf("the %s content type is not supported for this resource", r.Header.Get("Content-Type"))
+	util.ErrorResponse(w, r, http.StatusUnsupportedMediaType, ResponseObject{
+		Code:    CodeUnsupportedMediaType,
+		Message: message,
+	})
+}
+
+func (util *sharedUtils) NotAcceptableResponse(w http.ResponseWriter, r *http.Request) {
+	message := fmt.Sprintf("the %s media type is not available for this resource, use one of application/json, application/xml, application/msgpack or text/csv for lists", r.Header.Get("Accept"))
+
+	// the response is written as JSON, the default media type, or as problem details when the
+	// request accepts them
+	n, _ := negotiate(r.Header.Get("Accept"))
+	r = r.WithContext(context.WithValue(r.Context(), negotiationContextKey{}, negotiation{
+		mediaType: defaultMediaType,
+		encoder:   EncoderFunc(encodeJSON),
+		problem:   n.problem,
+	}))
+	util.ErrorResponse(w, r, http.StatusNotAcceptable, ResponseObject{
+		Code:    CodeNotAcceptable,
+		Message: message,
+	})
+}
@@ -59,6 +59,43 @@ func (util *sharedUtils) ReadInt(qs url.Values, key string, defaultValue int, v
 
 }
 
+func (util *sharedUtils) ReadBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+
+	return b
+
+}
+
+// ReadTime accepts a RFC 3339 timestamp e.g. 2021-10-01T15:04:05Z or a date e.g. 2021-10-01.
+func (util *sharedUtils) ReadTime(qs url.Values, key string, defaultValue time.Time, v *validator.Validator) time.Time {
+
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	v.AddError(key, "must be a RFC 3339 timestamp or a date e.g. 2021-10-01")
+
+	return defaultValue
+
+}
+
 func (util *sharedUtils) ReadCSV(qs url.Values, key string, defaultValue []string) []string {
 
 	csv := qs.Get(key)
@@ -0,0 +1,59 @@
+package commons
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/i18n"
+	"github.com/terdia/greenlight/internal/validator"
+)
+
+type languageContextKey struct{}
+
+// NegotiateLanguage selects the language of the error messages from the Accept-Language header
+// of the request and stores it in the request context.
+func NegotiateLanguage(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), languageContextKey{}, i18n.Negotiate(r.Header.Get("Accept-Language"))))
+}
+
+func languageFromRequest(r *http.Request) string {
+	if language, ok := r.Context().Value(languageContextKey{}).(string); ok {
+		return language
+	}
+
+	return i18n.DefaultLanguage
+}
+
+// Translate returns the message in the language negotiated for the request, code is the error
+// code of the message if it has one.
+func (util *sharedUtils) Translate(r *http.Request, code, message string) string {
+	return i18n.Translate(languageFromRequest(r), code, message)
+}
+
+func translateEnvelope(r *http.Request, envelop ResponseObject) ResponseObject {
+
+	language := languageFromRequest(r)
+	if language == i18n.DefaultLanguage {
+		return envelop
+	}
+
+	envelop.Message = i18n.Translate(language, envelop.Code, envelop.Message)
+
+	if validationError, ok := envelop.Data.(dto.ValidationError); ok {
+		errors := make(map[string]string, len(validationError.Errors))
+		for key, message := range validationError.Errors {
+			errors[key] = i18n.Translate(language, "", message)
+		}
+
+		details := make([]validator.FieldError, len(validationError.Details))
+		for i, detail := range validationError.Details {
+			detail.Message = i18n.Translate(language, "", detail.Message)
+			details[i] = detail
+		}
+
+		envelop.Data = dto.ValidationError{Errors: errors, Details: details}
+	}
+
+	return envelop
+}
@@ -0,0 +1,98 @@
+package commons
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/i18n"
+	"github.com/terdia/greenlight/internal/validator"
+)
+
+// ProblemMediaType is the media type of RFC 7807 problem details. Clients accepting it get
+// error responses as problem details and other responses in the negotiated media type.
+const ProblemMediaType = "application/problem+json"
+
+// Codes of the error responses, they don't change with the messages so clients can match on them.
+const (
+	CodeServerError            = "server_error"
+	CodeNotFound               = "not_found"
+	CodeMethodNotAllowed       = "method_not_allowed"
+	CodeBadRequest             = "bad_request"
+	CodeFailedValidation       = "failed_validation"
+	CodeEditConflict           = "edit_conflict"
+	CodeRateLimitExceeded      = "rate_limit_exceeded"
+	CodeInvalidCredentials     = "invalid_credentials"
+	CodeInvalidToken           = "invalid_token"
+	CodeAuthenticationRequired = "authentication_required"
+	CodeInactiveAccount        = "inactive_account"
+	CodeNotPermitted           = "not_permitted"
+	CodeUnsupportedMediaType   = "unsupported_media_type"
+	CodeNotAcceptable          = "not_acceptable"
+	CodeTimeout                = "timeout"
+	CodeBatchFailed            = "batch_failed"
+)
+
+// problemTypePrefix is prefixed to the error code to build the problem type URI.
+const problemTypePrefix = "urn:greenlight:problem:"
+
+// Problem is an RFC 7807 problem details document, extended with the error code, the
+// validation errors by field and the data of other error responses e.g. the results of a
+// failed batch.
+type Problem struct {
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail,omitempty"`
+	Instance string                 `json:"instance,omitempty"`
+	Code     string                 `json:"code"`
+	Errors   map[string]string      `json:"errors,omitempty"`
+	Details  []validator.FieldError `json:"details,omitempty"`
+	Data     interface{}            `json:"data,omitempty"`
+}
+
+func newProblem(r *http.Request, status int, envelop ResponseObject) Problem {
+
+	problem := Problem{
+		Type:     problemTypePrefix + envelop.Code,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   envelop.Message,
+		Instance: r.URL.RequestURI(),
+		Code:     envelop.Code,
+	}
+
+	if validationError, ok := envelop.Data.(dto.ValidationError); ok {
+		problem.Errors = validationError.Errors
+		problem.Details = validationError.Details
+
+		if problem.Detail == "" {
+			problem.Detail = i18n.Translate(languageFromRequest(r), "", "the request has invalid fields, see errors")
+		}
+	} else {
+		problem.Data = envelop.Data
+	}
+
+	return problem
+}
+
+func encodeProblem(w io.Writer, problem Problem, params map[string]string) error {
+
+	var js []byte
+	var err error
+
+	if params["pretty"] == "false" {
+		js, err = json.Marshal(problem)
+	} else {
+		js, err = json.MarshalIndent(problem, "", "\t")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(js, '\n'))
+
+	return err
+}
@@ -0,0 +1,207 @@
+package commons
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/validator"
+)
+
+func TestProblemResponse(t *testing.T) {
+
+	util := NewUtil(nil, nil)
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+	}{
+		{"JSend by default", "application/json", "application/json"},
+		{"Problem details", "application/problem+json, application/json", ProblemMediaType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/movies?debug=1", nil)
+			r.Header.Set("Accept", test.accept)
+
+			r, ok := NegotiateContent(r)
+			if !ok {
+				t.Fatal("want the Accept header to be negotiated")
+			}
+
+			rw := httptest.NewRecorder()
+			v := validator.New()
+			v.Struct(struct {
+				Title string `json:"title" validate:"required"`
+			}{})
+
+			util.FailedValidationResponse(rw, r, v.Errors)
+
+			if got := rw.Header().Get("Content-Type"); got != test.wantContentType {
+				t.Fatalf("want Content-Type %q; got %q", test.wantContentType, got)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(rw.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+
+			if body["code"] != CodeFailedValidation {
+				t.Errorf("want code %q; got %v", CodeFailedValidation, body["code"])
+			}
+
+			if test.wantContentType != ProblemMediaType {
+				return
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(rw.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+
+			want := Problem{
+				Type:     "urn:greenlight:problem:failed_validation",
+				Title:    "Unprocessable Entity",
+				Status:   http.StatusUnprocessableEntity,
+				Instance: "/v1/movies?debug=1",
+			}
+
+			if problem.Type != want.Type || problem.Title != want.Title || problem.Status != want.Status || problem.Instance != want.Instance {
+				t.Errorf("want %+v; got %+v", want, problem)
+			}
+
+			if problem.Errors["title"] != "must be provided" {
+				t.Errorf("want the title error; got %v", problem.Errors)
+			}
+
+			if len(problem.Details) != 1 || problem.Details[0].Pointer != "/title" || problem.Details[0].Code != "required" {
+				t.Errorf("want the title required detail; got %+v", problem.Details)
+			}
+		})
+	}
+}
+
+func TestErrorResponseLanguage(t *testing.T) {
+
+	util := NewUtil(nil, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/movies", nil)
+	r.Header.Set("Accept-Language", "fr-FR, en;q=0.5")
+	r, _ = NegotiateContent(NegotiateLanguage(r))
+
+	rw := httptest.NewRecorder()
+	rw.Header().Set("Vary", "Origin") // set by the CORS middleware
+
+	v := validator.New()
+	v.AddError("title", "must not be more than 500 bytes long")
+
+	util.FailedValidationResponse(rw, r, v.Errors)
+
+	if got := rw.Header().Get("Content-Language"); got != "fr" {
+		t.Errorf("want Content-Language %q; got %q", "fr", got)
+	}
+
+	if want, got := "Origin, Accept-Language, Accept", strings.Join(rw.Header().Values("Vary"), ", "); got != want {
+		t.Errorf("want Vary %q; got %q", want, got)
+	}
+
+	var body struct {
+		Data dto.ValidationError `json:"data"`
+	}
+
+	if err := json.Unmarshal(rw.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "ne doit pas dépasser 500 octets"; body.Data.Errors["title"] != want || body.Data.Details[0].Message != want {
+		t.Errorf("want %q; got %+v", want, body.Data)
+	}
+}
+
+func TestNotAcceptableProblemResponse(t *testing.T) {
+
+	util := NewUtil(nil, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/movies", nil)
+	r.Header.Set("Accept", "text/html, application/problem+json")
+
+	r, ok := NegotiateContent(r)
+	if ok {
+		t.Fatal("want text/html not to be negotiated")
+	}
+
+	rw := httptest.NewRecorder()
+	util.NotAcceptableResponse(rw, r)
+
+	if got := rw.Header().Get("Content-Type"); got != ProblemMediaType {
+		t.Fatalf("want Content-Type %q; got %q", ProblemMediaType, got)
+	}
+
+	var problem Problem
+	if err := json.Unmarshal(rw.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+
+	if problem.Status != http.StatusNotAcceptable || problem.Code != CodeNotAcceptable {
+		t.Errorf("want a %d %s problem; got %+v", http.StatusNotAcceptable, CodeNotAcceptable, problem)
+	}
+}
+
+func TestProblemResponseData(t *testing.T) {
+
+	util := NewUtil(nil, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/movies/batch", nil)
+	r.Header.Set("Accept", ProblemMediaType)
+	r, _ = NegotiateContent(r)
+
+	rw := httptest.NewRecorder()
+	util.ErrorResponse(rw, r, http.StatusUnprocessableEntity, ResponseObject{
+		Code:    CodeBatchFailed,
+		Message: "the batch was rolled back, see the results of its operations",
+		Data:    dto.BatchMovieResponse{Mode: "atomic", Results: []dto.BatchMovieResult{{Index: 0, Op: "create"}}},
+	})
+
+	var problem struct {
+		Code string `json:"code"`
+		Data struct {
+			Mode    string        `json:"mode"`
+			Results []interface{} `json:"results"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(rw.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+
+	if problem.Code != CodeBatchFailed || problem.Data.Mode != "atomic" || len(problem.Data.Results) != 1 {
+		t.Errorf("want the batch results in the problem; got %s", rw.Body.String())
+	}
+}
+
+func TestServerErrorResponseCancelled(t *testing.T) {
+
+	util := NewUtil(nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/movies", nil).WithContext(ctx)
+	rw := httptest.NewRecorder()
+
+	util.ServerErrorResponse(rw, r, ctx.Err())
+
+	if rw.Code != StatusClientClosedRequest {
+		t.Errorf("want %d; got %d", StatusClientClosedRequest, rw.Code)
+	}
+
+	if rw.Body.Len() != 0 {
+		t.Errorf("want no body; got %s", rw.Body)
+	}
+}
@@ -1,6 +1,7 @@
 package commons
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,22 +12,53 @@ import (
 	"github.com/terdia/greenlight/internal/custom_type"
 )
 
-func (util *sharedUtils) WriteJson(rw http.ResponseWriter, status int, envelop ResponseObject, headers http.Header) error {
+// WriteResponse writes the envelope in the media type negotiated from the Accept header of the
+// request. Error responses the media type can't represent, e.g. CSV, are written as JSON and
+// other responses as 406 Not Acceptable. Error responses with a code are written as problem
+// details when the request accepts application/problem+json.
+func (util *sharedUtils) WriteResponse(rw http.ResponseWriter, r *http.Request, status int, envelop ResponseObject, headers http.Header) error {
+
+	n := negotiationFromRequest(r)
+
+	var buf bytes.Buffer
+	var err error
+
+	if n.problem && envelop.Code != "" {
+		n.mediaType = ProblemMediaType
+		err = encodeProblem(&buf, newProblem(r, status, envelop), n.params)
+	} else {
+		err = n.encoder.Encode(&buf, envelop, n.params)
+	}
+
+	if errors.Is(err, ErrUnsupportedEnvelope) {
+		if envelop.StatusMsg == custom_type.Success {
+			util.NotAcceptableResponse(rw, r)
+			return nil
+		}
+
+		n = negotiation{mediaType: defaultMediaType, params: n.params, encoder: EncoderFunc(encodeJSON)}
+		buf.Reset()
+		err = n.encoder.Encode(&buf, envelop, n.params)
+	}
 
-	js, err := json.MarshalIndent(envelop, "", "\t")
 	if err != nil {
 		return err
 	}
 
-	js = append(js, '\n')
-
 	for key, value := range headers {
+		// the Vary values are added to the ones set by the middleware e.g. Origin
+		if key == "Vary" {
+			rw.Header()[key] = append(rw.Header()[key], value...)
+			continue
+		}
+
 		rw.Header()[key] = value
 	}
 
-	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Content-Type", n.mediaType)
+	rw.Header().Add("Vary", "Accept")
 	rw.WriteHeader(status)
-	rw.Write(js)
+	rw.Write(buf.Bytes())
 
 	return nil
 }
@@ -37,7 +69,11 @@ func (util *sharedUtils) ErrorResponse(rw http.ResponseWriter, r *http.Request,
 		envelop.setStatus(custom_type.Error)
 	}
 
-	err := util.WriteJson(rw, status, envelop, nil)
+	headers := http.Header{}
+	headers.Set("Content-Language", languageFromRequest(r))
+	headers.Set("Vary", "Accept-Language")
+
+	err := util.WriteResponse(rw, r, status, translateEnvelope(r, envelop), headers)
 	if err != nil {
 
 		util.LogErrorWithHttpRequestContext(r, err)
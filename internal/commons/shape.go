@@ -0,0 +1,157 @@
+package commons
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/terdia/greenlight/internal/validator"
+)
+
+// Shape is the sparse fieldset and the embedded relations of a resource requested with the
+// fields and include query parameters e.g. ?fields=title,year&include=similar
+type Shape struct {
+	Fields   []string // JSON field names to keep, every field when empty
+	Includes []string // relations to embed
+}
+
+// ReadShape reads the fields and include query parameters of a resource. Fields must be JSON
+// field names of resource, a DTO struct, and includes must be one of relations; unknown values
+// are reported as validation errors.
+func (util *sharedUtils) ReadShape(qs url.Values, resource interface{}, relations []string, v *validator.Validator) Shape {
+
+	shape := Shape{
+		Fields:   util.ReadCSV(qs, "fields", []string{}),
+		Includes: util.ReadCSV(qs, "include", []string{}),
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(resource))
+
+	for _, field := range shape.Fields {
+		if !validator.In(field, known...) || validator.In(field, relations...) {
+			v.AddError("fields", fmt.Sprintf("unknown field %q, must be a list of %s", field, strings.Join(withoutValues(known, relations), ", ")))
+			break
+		}
+	}
+
+	for _, relation := range shape.Includes {
+		if !validator.In(relation, relations...) {
+			v.AddError("include", fmt.Sprintf("unknown relation %q", relation))
+			break
+		}
+	}
+
+	return shape
+}
+
+// Include reports whether the relation was requested.
+func (shape Shape) Include(relation string) bool {
+	return validator.In(relation, shape.Includes...)
+}
+
+// Apply trims the resource held at key of data, an object or an array of objects, to the
+// requested fields. The id and the included relations are always kept. data is returned as
+// is when every field was requested.
+func (shape Shape) Apply(data interface{}, key string) (interface{}, error) {
+
+	if len(shape.Fields) == 0 {
+		return data, nil
+	}
+
+	js, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(js, &envelope); err != nil {
+		return nil, err
+	}
+
+	keep := append([]string{"id"}, shape.Fields...)
+	keep = append(keep, shape.Includes...)
+
+	resource := envelope[key]
+
+	if trimmed := strings.TrimSpace(string(resource)); strings.HasPrefix(trimmed, "[") {
+		var objects []map[string]json.RawMessage
+		if err := json.Unmarshal(resource, &objects); err != nil {
+			return nil, err
+		}
+
+		for _, object := range objects {
+			trimFields(object, keep)
+		}
+
+		envelope[key], err = json.Marshal(objects)
+	} else {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(resource, &object); err != nil {
+			return nil, err
+		}
+
+		trimFields(object, keep)
+
+		envelope[key], err = json.Marshal(object)
+	}
+
+	return envelope, err
+}
+
+func trimFields(object map[string]json.RawMessage, keep []string) {
+	for field := range object {
+		if !validator.In(field, keep...) {
+			delete(object, field)
+		}
+	}
+}
+
+// jsonFieldNames returns the names of the fields of a struct type once encoded to JSON.
+func jsonFieldNames(t reflect.Type) []string {
+
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	names := []string{}
+
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func withoutValues(values []string, excluded []string) []string {
+
+	result := []string{}
+
+	for _, value := range values {
+		if !validator.In(value, excluded...) {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,107 @@
+package commons
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/terdia/greenlight/internal/validator"
+)
+
+type shapeResource struct {
+	ID      int64           `json:"id"`
+	Title   string          `json:"title"`
+	Year    int32           `json:"year,omitempty"`
+	Related []shapeResource `json:"related,omitempty"`
+	secret  string
+}
+
+type shapeEnvelope struct {
+	Resource  shapeResource   `json:"resource"`
+	Resources []shapeResource `json:"resources"`
+	Total     int             `json:"total"`
+}
+
+func TestReadShape(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		query   string
+		wantKey string
+	}{
+		{"No fields", "", ""},
+		{"Known fields and relation", "fields=title,year&include=related", ""},
+		{"Unknown field", "fields=title,rating", "fields"},
+		{"Unexported field", "fields=secret", "fields"},
+		{"Relation as a field", "fields=related", "fields"},
+		{"Unknown relation", "include=sequels", "include"},
+	}
+
+	util := NewUtil(nil, nil)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			qs, _ := url.ParseQuery(test.query)
+
+			v := validator.New()
+			util.ReadShape(qs, shapeResource{}, []string{"related"}, v)
+
+			if test.wantKey == "" && !v.Valid() {
+				t.Errorf("want no errors; got %v", v.Errors)
+			}
+
+			if _, exists := v.Errors[test.wantKey]; test.wantKey != "" && !exists {
+				t.Errorf("want error for %s; got %v", test.wantKey, v.Errors)
+			}
+		})
+	}
+}
+
+func TestShapeApply(t *testing.T) {
+
+	data := shapeEnvelope{
+		Resource:  shapeResource{ID: 1, Title: "Casablanca", Year: 1942, Related: []shapeResource{{ID: 2, Title: "Alien"}}},
+		Resources: []shapeResource{{ID: 1, Title: "Casablanca", Year: 1942}, {ID: 2, Title: "Alien", Year: 1979}},
+		Total:     2,
+	}
+
+	tests := []struct {
+		name  string
+		shape Shape
+		key   string
+		want  string
+	}{
+		{
+			"Every field",
+			Shape{},
+			"resource",
+			`{"resource":{"id":1,"title":"Casablanca","year":1942,"related":[{"id":2,"title":"Alien"}]},"resources":[{"id":1,"title":"Casablanca","year":1942},{"id":2,"title":"Alien","year":1979}],"total":2}`,
+		},
+		{
+			"Object",
+			Shape{Fields: []string{"year"}, Includes: []string{"related"}},
+			"resource",
+			`{"resource":{"id":1,"related":[{"id":2,"title":"Alien"}],"year":1942},"resources":[{"id":1,"title":"Casablanca","year":1942},{"id":2,"title":"Alien","year":1979}],"total":2}`,
+		},
+		{
+			"Array",
+			Shape{Fields: []string{"title"}},
+			"resources",
+			`{"resource":{"id":1,"title":"Casablanca","year":1942,"related":[{"id":2,"title":"Alien"}]},"resources":[{"id":1,"title":"Casablanca"},{"id":2,"title":"Alien"}],"total":2}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shaped, err := test.shape.Apply(data, test.key)
+			if err != nil {
+				t.Fatalf("want error to be %v; got %s", nil, err.Error())
+			}
+
+			js, _ := json.Marshal(shaped)
+			if string(js) != test.want {
+				t.Errorf("want %s; got %s", test.want, js)
+			}
+		})
+	}
+}
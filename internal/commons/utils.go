@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/url"
 	"sync"
+	"time"
 
 	"github.com/terdia/greenlight/infrastructures/logger"
 	"github.com/terdia/greenlight/internal/custom_type"
@@ -18,14 +19,17 @@ type SharedUtil interface {
 	EditConflictResponse(rw http.ResponseWriter, r *http.Request)
 	MethodNotAllowedResponse(w http.ResponseWriter, r *http.Request)
 	BadRequestResponse(w http.ResponseWriter, r *http.Request, err error)
-	FailedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string)
-	WriteJson(rw http.ResponseWriter, status int, envelop ResponseObject, headers http.Header) error
+	FailedValidationResponse(w http.ResponseWriter, r *http.Request, errors validator.Errors)
+	WriteResponse(rw http.ResponseWriter, r *http.Request, status int, envelop ResponseObject, headers http.Header) error
 	ReadJson(rw http.ResponseWriter, r *http.Request, dst interface{}) error
 	ErrorResponse(rw http.ResponseWriter, r *http.Request, status int, envelop ResponseObject)
 	ExtractIdParamFromContext(r *http.Request) (int64, error)
 	ReadString(qs url.Values, key, defaultValue string) string
 	ReadInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int
+	ReadBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool
+	ReadTime(qs url.Values, key string, defaultValue time.Time, v *validator.Validator) time.Time
 	ReadCSV(qs url.Values, key string, defaultValue []string) []string
+	ReadShape(qs url.Values, resource interface{}, relations []string, v *validator.Validator) Shape
 	RateLimitExceededResponse(w http.ResponseWriter, r *http.Request)
 	Background(fn func())
 	InvalidCredentialsResponse(w http.ResponseWriter, r *http.Request)
@@ -33,6 +37,9 @@ type SharedUtil interface {
 	InactiveAccountResponse(w http.ResponseWriter, r *http.Request)
 	AuthenticationRequiredResponse(w http.ResponseWriter, r *http.Request)
 	NotPermittedRResponse(w http.ResponseWriter, r *http.Request)
+	UnsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request)
+	NotAcceptableResponse(w http.ResponseWriter, r *http.Request)
+	Translate(r *http.Request, code, message string) string
 }
 
 type sharedUtils struct {
@@ -46,7 +53,8 @@ func NewUtil(log *logger.Logger, wg *sync.WaitGroup) SharedUtil {
 
 //based on https://github.com/omniti-labs/jsend
 type ResponseObject struct {
-	StatusMsg custom_type.StatusMessage `json:"status"` //(success|fail|error)
+	StatusMsg custom_type.StatusMessage `json:"status"`         //(success|fail|error)
+	Code      string                    `json:"code,omitempty"` // stable error code of fail and error responses e.g. edit_conflict
 	Message   string                    `json:"message,omitempty"`
 	Data      interface{}               `json:"data,omitempty"`
 }
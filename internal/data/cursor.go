@@ -0,0 +1,41 @@
+package data
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at a row of a keyset paginated list. It holds the values of the sort
+// columns and the id of the row, so the next page can be read with a WHERE clause on
+// (sort columns, id) instead of an OFFSET.
+type Cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"` // one value per sort key
+	ID     int64    `json:"i"`
+}
+
+// Encode returns the cursor as an opaque url safe string.
+func (c Cursor) Encode() string {
+	js, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+
+	var c Cursor
+
+	js, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(js, &c); err != nil || c.ID < 1 {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
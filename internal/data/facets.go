@@ -0,0 +1,16 @@
+package data
+
+const (
+	FacetGenres        = "genres"
+	FacetDecade        = "decade"
+	FacetRuntimeBucket = "runtime_bucket"
+)
+
+// FacetCount is the number of records having a value of a facet e.g. 12 movies in the 1990s.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets holds the counts of each requested facet, keyed by facet name.
+type Facets map[string][]FacetCount
@@ -1,25 +1,42 @@
 package data
 
 import (
+	"fmt"
 	"math"
 	"strings"
 
 	"github.com/terdia/greenlight/internal/validator"
 )
 
+// MaxSortKeys is the maximum number of comma separated keys in a sort value e.g. -year,title.
+const MaxSortKeys = 3
+
 type Filters struct {
 	Page         int
 	PageSize     int
-	Sort         string
+	Sort         string // comma separated list of keys from SortSafelist, - prefix for descending
 	SortSafelist []string
+	After        string // opaque cursor, when set the page starts after the cursor row and Page is ignored
+	Before       string // opaque cursor, when set the page ends before the cursor row and Page is ignored
+	IncludeTotal bool
+}
+
+// SortKey is a column of a multi-key sort.
+type SortKey struct {
+	Column    string
+	Direction string // ASC or DESC
 }
 
 type Metadata struct {
-	CurrentPage  int `json:"current_page,omitempty"`
-	PageSize     int `json:"page_size,omitempty"`
-	FirstPage    int `json:"first_page,omitempty"`
-	LastPage     int `json:"last_page,omitempty"`
-	TotalRecords int `json:"total_records,omitempty"`
+	CurrentPage  int    `json:"current_page,omitempty"`
+	PageSize     int    `json:"page_size,omitempty"`
+	FirstPage    int    `json:"first_page,omitempty"`
+	LastPage     int    `json:"last_page,omitempty"`
+	TotalRecords int    `json:"total_records,omitempty"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
+	Next         string `json:"next,omitempty"` // link to the next page using NextCursor
+	Prev         string `json:"prev,omitempty"` // link to the previous page using PrevCursor
 }
 
 func (f Filters) ValidateFilters(v *validator.Validator) {
@@ -29,25 +46,74 @@ func (f Filters) ValidateFilters(v *validator.Validator) {
 	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
 	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
 
-	// Check that the sort parameter matches a value in the safelist.
-	v.Check(validator.In(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
-}
+	// Check that every key of the sort parameter matches a value in the safelist.
+	keys := strings.Split(f.Sort, ",")
+	columns := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		v.Check(validator.In(key, f.SortSafelist...), "sort", "invalid sort value")
+		columns = append(columns, strings.TrimPrefix(key, "-"))
+	}
+
+	v.Check(len(keys) <= MaxSortKeys, "sort", fmt.Sprintf("must not contain more than %d keys", MaxSortKeys))
+	v.Check(validator.UniqueStringSlice(columns), "sort", "must not contain the same column twice")
 
-func (f Filters) SortColumn() string {
-	for _, safeValue := range f.SortSafelist {
-		if f.Sort == safeValue {
-			return strings.TrimPrefix(f.Sort, "-")
+	v.Check(f.After == "" || f.Before == "", "after", "must not be used together with before")
+
+	for key, value := range map[string]string{"after": f.After, "before": f.Before} {
+		if value == "" {
+			continue
+		}
+
+		cursor, err := DecodeCursor(value)
+		if err != nil {
+			v.AddError(key, "invalid cursor")
+			continue
 		}
+
+		v.Check(cursor.Sort == f.Sort && len(cursor.Values) == len(keys), key, "cursor was created for a different sort value")
+	}
+}
+
+// Cursor returns the decoded After or Before cursor, ok is false for page number pagination.
+func (f Filters) Cursor() (cursor Cursor, ok bool) {
+
+	value := f.After
+	if value == "" {
+		value = f.Before
+	}
+
+	if value == "" {
+		return Cursor{}, false
 	}
-	panic("unsafe sort paramater: " + f.Sort)
+
+	cursor, err := DecodeCursor(value)
+	if err != nil {
+		panic("unsafe cursor parameter: " + value)
+	}
+
+	return cursor, true
 }
 
-func (f Filters) SortDirection() string {
-	if strings.HasPrefix(f.Sort, "-") {
-		return "DESC"
+// SortKeys returns the columns and directions of the sort value, in order.
+func (f Filters) SortKeys() []SortKey {
+
+	keys := []SortKey{}
+
+	for _, key := range strings.Split(f.Sort, ",") {
+		if !validator.In(key, f.SortSafelist...) {
+			panic("unsafe sort paramater: " + f.Sort)
+		}
+
+		direction := "ASC"
+		if strings.HasPrefix(key, "-") {
+			direction = "DESC"
+		}
+
+		keys = append(keys, SortKey{Column: strings.TrimPrefix(key, "-"), Direction: direction})
 	}
 
-	return "ASC"
+	return keys
 }
 
 func (f Filters) Limit() int {
@@ -55,6 +121,10 @@ func (f Filters) Limit() int {
 }
 
 func (f Filters) Offset() int {
+	if _, ok := f.Cursor(); ok {
+		return 0
+	}
+
 	return (f.Page - 1) * f.PageSize
 }
 
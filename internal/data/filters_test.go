@@ -0,0 +1,114 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/terdia/greenlight/internal/validator"
+)
+
+func TestCursor(t *testing.T) {
+
+	cursor := Cursor{Sort: "-title,year", Values: []string{"Casablanca", "1942"}, ID: 42}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("want error to be %v; got %s", nil, err.Error())
+	}
+
+	if !reflect.DeepEqual(decoded, cursor) {
+		t.Errorf("want %v; got %v", cursor, decoded)
+	}
+
+	for _, invalid := range []string{"", "not-base64!", Cursor{Sort: "id"}.Encode()} {
+		if _, err := DecodeCursor(invalid); err != ErrInvalidCursor {
+			t.Errorf("want %v for %q; got %v", ErrInvalidCursor, invalid, err)
+		}
+	}
+}
+
+func TestValidateFiltersCursor(t *testing.T) {
+
+	titleCursor := Cursor{Sort: "title", Values: []string{"Casablanca"}, ID: 42}.Encode()
+
+	tests := []struct {
+		name    string
+		filters Filters
+		wantKey string
+	}{
+		{"Valid after cursor", Filters{Sort: "title", After: titleCursor}, ""},
+		{"Valid before cursor", Filters{Sort: "title", Before: titleCursor}, ""},
+		{"After and before", Filters{Sort: "title", After: titleCursor, Before: titleCursor}, "after"},
+		{"Invalid cursor", Filters{Sort: "title", Before: "xyz"}, "before"},
+		{"Cursor for another sort", Filters{Sort: "-year", After: titleCursor}, "after"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.filters.Page = 1
+			test.filters.PageSize = 10
+			test.filters.SortSafelist = []string{"title", "-year"}
+
+			v := validator.New()
+			test.filters.ValidateFilters(v)
+
+			if test.wantKey == "" && !v.Valid() {
+				t.Errorf("want no errors; got %v", v.Errors)
+			}
+
+			if _, exists := v.Errors[test.wantKey]; test.wantKey != "" && !exists {
+				t.Errorf("want error for %s; got %v", test.wantKey, v.Errors)
+			}
+
+			if v.Valid() {
+				if _, ok := test.filters.Cursor(); !ok {
+					t.Errorf("want cursor pagination")
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFiltersSort(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		sort     string
+		wantKeys []SortKey
+	}{
+		{"Single key", "-year", []SortKey{{"year", "DESC"}}},
+		{"Multiple keys", "-year,title", []SortKey{{"year", "DESC"}, {"title", "ASC"}}},
+		{"Unknown key", "-year,rating", nil},
+		{"Same column twice", "year,-year", nil},
+		{"Too many keys", "year,title,runtime,id", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filters := Filters{
+				Page:         1,
+				PageSize:     10,
+				Sort:         test.sort,
+				SortSafelist: []string{"id", "title", "year", "runtime", "-id", "-title", "-year", "-runtime"},
+			}
+
+			v := validator.New()
+			filters.ValidateFilters(v)
+
+			if test.wantKeys == nil {
+				if _, exists := v.Errors["sort"]; !exists {
+					t.Errorf("want error for sort; got %v", v.Errors)
+				}
+				return
+			}
+
+			if !v.Valid() {
+				t.Fatalf("want no errors; got %v", v.Errors)
+			}
+
+			if keys := filters.SortKeys(); !reflect.DeepEqual(keys, test.wantKeys) {
+				t.Errorf("want %v; got %v", test.wantKeys, keys)
+			}
+		})
+	}
+}
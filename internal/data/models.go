@@ -15,3 +15,25 @@ const (
 	TokenScopeActivation     = "activation"
 	TokenScopeAuthentication = "authentication"
 )
+
+const (
+	GenresModeAll  = "all"
+	GenresModeAny  = "any"
+	GenresModeNone = "none"
+)
+
+// DefaultLanguage is the text search configuration of movies created without a language,
+// it doesn't stem words.
+const DefaultLanguage = "simple"
+
+// Languages are the PostgreSQL text search configurations a movie title can be indexed with.
+var Languages = []string{
+	"simple", "arabic", "danish", "dutch", "english", "finnish", "french", "german", "greek", "hungarian",
+	"indonesian", "italian", "norwegian", "portuguese", "romanian", "russian", "spanish", "swedish", "turkish",
+}
+
+const (
+	SearchModeFulltext = "fulltext"
+	SearchModePrefix   = "prefix"
+	SearchModeFuzzy    = "fuzzy"
+)
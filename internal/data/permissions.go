@@ -1,5 +1,8 @@
 package data
 
+// PermissionCodes are the permissions users can be granted, as seeded by the migrations.
+var PermissionCodes = []string{"movies:read", "movies:write"}
+
 type Permissions []string
 
 func (p Permissions) Includes(code string) bool {
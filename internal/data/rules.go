@@ -0,0 +1,33 @@
+package data
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/terdia/greenlight/internal/validator"
+)
+
+// Rules are the validate tag rules of the domain, a validator checking the DTOs which use them
+// e.g. dto.MovieRequest must be built with validator.New().Use(data.Rules).
+var Rules = validator.Rules{
+	"language":  validateLanguage,
+	"notfuture": validateNotFuture,
+}
+
+// validateLanguage is the language rule, the field must be one of Languages.
+func validateLanguage(field reflect.Value, param string) string {
+	if !validator.In(field.String(), Languages...) {
+		return "must be a supported language e.g. english"
+	}
+
+	return ""
+}
+
+// validateNotFuture is the notfuture rule, the field is a year that must not be after the current year.
+func validateNotFuture(field reflect.Value, param string) string {
+	if field.Int() > int64(time.Now().Year()) {
+		return "must not be in the future"
+	}
+
+	return ""
+}
@@ -0,0 +1,154 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bind adds value to the arguments of the query and returns its placeholder e.g. $3.
+type Bind func(value interface{}) string
+
+// Values returns the value of a column of a record, an int64 for Number fields, a string for
+// Text fields, a time.Time for Time fields and a []string for List fields.
+type Values func(column string) interface{}
+
+// Expr is a node of a parsed filter expression.
+type Expr interface {
+	// SQL returns the expression as a SQL condition, binding every value with bind.
+	SQL(bind Bind) string
+	// Match reports whether the record holding values matches the expression, for storages
+	// that can't run SQL.
+	Match(values Values) bool
+}
+
+// Logical is an AND or OR of two expressions.
+type Logical struct {
+	Op          string
+	Left, Right Expr
+}
+
+func (e *Logical) SQL(bind Bind) string {
+	return fmt.Sprintf("(%s %s %s)", e.Left.SQL(bind), e.Op, e.Right.SQL(bind))
+}
+
+func (e *Logical) Match(values Values) bool {
+	if e.Op == "OR" {
+		return e.Left.Match(values) || e.Right.Match(values)
+	}
+
+	return e.Left.Match(values) && e.Right.Match(values)
+}
+
+// Not negates an expression.
+type Not struct {
+	Expr Expr
+}
+
+func (e *Not) SQL(bind Bind) string {
+	return fmt.Sprintf("NOT (%s)", e.Expr.SQL(bind))
+}
+
+func (e *Not) Match(values Values) bool {
+	return !e.Expr.Match(values)
+}
+
+// Comparison compares a field with a value of the field type.
+type Comparison struct {
+	Field Field
+	Op    string
+	Value interface{}
+}
+
+func (e *Comparison) SQL(bind Bind) string {
+
+	switch e.Op {
+	case "has":
+		return fmt.Sprintf("%s = ANY(%s)", bind(e.Value), e.Field.Column)
+	case "~":
+		return fmt.Sprintf("%s ILIKE %s", e.Field.Column, bind("%"+escapeLike(e.Value.(string))+"%"))
+	case "!=":
+		return fmt.Sprintf("%s <> %s", e.Field.Column, bind(e.Value))
+	default:
+		return fmt.Sprintf("%s %s %s", e.Field.Column, e.Op, bind(e.Value))
+	}
+}
+
+func (e *Comparison) Match(values Values) bool {
+
+	value := values(e.Field.Column)
+
+	switch e.Op {
+	case "has":
+		for _, element := range value.([]string) {
+			if element == e.Value {
+				return true
+			}
+		}
+
+		return false
+	case "~":
+		return strings.Contains(strings.ToLower(value.(string)), strings.ToLower(e.Value.(string)))
+	}
+
+	order := compare(value, e.Value)
+
+	switch e.Op {
+	case "=":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	default:
+		return order >= 0
+	}
+}
+
+// compare returns -1, 0 or 1 when a is less than, equal to or greater than b. Numbers are
+// compared as float64 since the value of a Number field is either an int64 or a float64.
+func compare(a, b interface{}) int {
+
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case time.Time:
+		switch b := b.(time.Time); {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		default:
+			return 0
+		}
+	default:
+		x, y := toFloat(a), toFloat(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		default:
+			return 0
+		}
+	}
+}
+
+func toFloat(value interface{}) float64 {
+	if f, ok := value.(float64); ok {
+		return f
+	}
+
+	return float64(value.(int64))
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,264 @@
+// Package filter implements the filter query parameter of list endpoints, a small
+// expression language such as
+//
+//	year >= 2000 and (genres has "drama" or not runtime < 90)
+//
+// Expressions are parsed into a tree, checked against the fields an endpoint exposes
+// and compiled to a parameterized SQL condition, so values never end up in the query text, or
+// matched against records held in memory.
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxLength is the maximum length of an expression and maxDepth the maximum nesting of
+// parentheses and not, to keep both parsing and the generated query cheap.
+const (
+	MaxLength = 1024
+	maxDepth  = 32
+)
+
+// Type is the type of a filterable field, it decides the operators and values allowed.
+type Type int
+
+const (
+	Number Type = iota // =, !=, <, <=, >, >= with a number
+	Text               // =, != or ~ (contains, case insensitive) with a string
+	Time               // =, !=, <, <=, >, >= with an RFC 3339 timestamp or a date string
+	List               // has with a string, for text array columns
+)
+
+// Field maps a field name of the expression language to a column.
+type Field struct {
+	Column string
+	Type   Type
+}
+
+// Schema is the set of fields an endpoint can be filtered by, keyed by field name.
+type Schema map[string]Field
+
+// Error reports an invalid expression and the position where it went wrong.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+var operators = map[Type][]string{
+	Number: {"=", "!=", "<", "<=", ">", ">="},
+	Text:   {"=", "!=", "~"},
+	Time:   {"=", "!=", "<", "<=", ">", ">="},
+	List:   {"has"},
+}
+
+// Parse parses and type checks the expression against the fields of schema.
+func Parse(input string, schema Schema) (Expr, error) {
+
+	if len(input) > MaxLength {
+		return nil, &Error{Pos: MaxLength, Msg: fmt.Sprintf("expression must not be more than %d bytes long", MaxLength)}
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, schema: schema}
+
+	expr, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, &Error{Pos: next.pos, Msg: "unexpected " + next.text}
+	}
+
+	return expr, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	schema Schema
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// keyword consumes the next token when it is the keyword, ignoring case.
+func (p *parser) keyword(keyword string) bool {
+	if t := p.peek(); t.kind == tokenIdent && strings.EqualFold(t.text, keyword) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+// parseOr parses: and { "or" and }
+func (p *parser) parseOr(depth int) (Expr, error) {
+
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("or") {
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &Logical{Op: "OR", Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// parseAnd parses: unary { "and" unary }
+func (p *parser) parseAnd(depth int) (Expr, error) {
+
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("and") {
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &Logical{Op: "AND", Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// parseUnary parses: "not" unary | "(" or ")" | comparison
+func (p *parser) parseUnary(depth int) (Expr, error) {
+
+	if depth > maxDepth {
+		return nil, &Error{Pos: p.peek().pos, Msg: "expression is nested too deeply"}
+	}
+
+	if p.keyword("not") {
+		expr, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Not{Expr: expr}, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, &Error{Pos: t.pos, Msg: "missing )"}
+		}
+
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+// parseComparison parses: field operator value
+func (p *parser) parseComparison() (Expr, error) {
+
+	name := p.next()
+	if name.kind != tokenIdent {
+		return nil, &Error{Pos: name.pos, Msg: "expected a field name"}
+	}
+
+	field, ok := p.schema[name.text]
+	if !ok {
+		return nil, &Error{Pos: name.pos, Msg: "unknown field " + name.text}
+	}
+
+	op := p.next()
+	if op.kind == tokenIdent {
+		op.text = strings.ToLower(op.text)
+	}
+
+	if (op.kind != tokenOperator && op.kind != tokenIdent) || !contains(operators[field.Type], op.text) {
+		return nil, &Error{Pos: op.pos, Msg: fmt.Sprintf("%s must be followed by one of %s", name.text, strings.Join(operators[field.Type], " "))}
+	}
+
+	literal := p.next()
+
+	value, err := parseValue(field.Type, literal)
+	if err != nil {
+		return nil, &Error{Pos: literal.pos, Msg: fmt.Sprintf("invalid value for %s: %s", name.text, err.Error())}
+	}
+
+	return &Comparison{Field: field, Op: op.text, Value: value}, nil
+}
+
+func parseValue(typ Type, t token) (interface{}, error) {
+
+	switch typ {
+	case Number:
+		if t.kind != tokenNumber {
+			return nil, fmt.Errorf("must be a number")
+		}
+
+		if strings.Contains(t.text, ".") {
+			return strconv.ParseFloat(t.text, 64)
+		}
+
+		return strconv.ParseInt(t.text, 10, 64)
+
+	case Time:
+		if t.kind == tokenString {
+			for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+				if value, err := time.Parse(layout, t.text); err == nil {
+					return value, nil
+				}
+			}
+		}
+
+		return nil, fmt.Errorf("must be a quoted RFC 3339 timestamp or date")
+
+	default:
+		if t.kind != tokenString {
+			return nil, fmt.Errorf("must be a quoted string")
+		}
+
+		return t.text, nil
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
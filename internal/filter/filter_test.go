@@ -0,0 +1,144 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var schema = Schema{
+	"title":      {Column: "title", Type: Text},
+	"year":       {Column: "year", Type: Number},
+	"genres":     {Column: "genres", Type: List},
+	"created_at": {Column: "created_at", Type: Time},
+}
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		input    string
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{"Comparison", `year>=2000`, "year >= $1", []interface{}{int64(2000)}},
+		{"Not equal", `title != "Heat"`, "title <> $1", []interface{}{"Heat"}},
+		{"Has", `genres has "drama"`, "$1 = ANY(genres)", []interface{}{"drama"}},
+		{"Contains", `title ~ "50%_off\\"`, "title ILIKE $1", []interface{}{`%50\%\_off\\%`}},
+		{"Time", `created_at < "2021-10-01"`, "created_at < $1", []interface{}{time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)}},
+		{
+			"And binds tighter than or",
+			`year > 1990 or year < 1950 and genres has "drama"`,
+			"(year > $1 OR (year < $2 AND $3 = ANY(genres)))",
+			[]interface{}{int64(1990), int64(1950), "drama"},
+		},
+		{
+			"Parentheses and not",
+			`NOT (year = 2000 OR year = 2001) and title ~ "the"`,
+			"(NOT ((year = $1 OR year = $2)) AND title ILIKE $3)",
+			[]interface{}{int64(2000), int64(2001), "%the%"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			expr, err := Parse(test.input, schema)
+			if err != nil {
+				t.Fatalf("want error to be %v; got %s", nil, err.Error())
+			}
+
+			args := []interface{}{}
+			sql := expr.SQL(func(value interface{}) string {
+				args = append(args, value)
+				return fmt.Sprintf("$%d", len(args))
+			})
+
+			if sql != test.wantSQL {
+				t.Errorf("want %q; got %q", test.wantSQL, sql)
+			}
+
+			if !reflect.DeepEqual(args, test.wantArgs) {
+				t.Errorf("want args %v; got %v", test.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Empty", ``},
+		{"Unknown field", `rating > 3`},
+		{"Operator not allowed for type", `genres = "drama"`},
+		{"Number for text field", `title = 3`},
+		{"String for number field", `year = "2000"`},
+		{"Invalid time", `created_at > "yesterday"`},
+		{"Unterminated string", `title = "Heat`},
+		{"Missing parenthesis", `(year = 2000`},
+		{"Trailing tokens", `year = 2000 2001`},
+		{"Injection", `year = 2000; DROP TABLE movies`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.input, schema)
+
+			if _, ok := err.(*Error); !ok {
+				t.Errorf("want *Error; got %v", err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+
+	record := map[string]interface{}{
+		"title":      "The Breakfast Club",
+		"year":       int64(1985),
+		"genres":     []string{"comedy", "drama"},
+		"created_at": time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	values := func(column string) interface{} {
+		return record[column]
+	}
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{`year = 1985`, true},
+		{`year != 1985`, false},
+		{`year < 1985.5`, true},
+		{`year >= 1990`, false},
+		{`title = "The Breakfast Club"`, true},
+		{`title = "the breakfast club"`, false},
+		{`title ~ "BREAKFAST"`, true},
+		{`genres has "drama"`, true},
+		{`genres has "horror"`, false},
+		{`created_at > "2021-10-01"`, true},
+		{`created_at < "2021-10-01T12:00:00Z"`, false},
+		{`year > 1990 or genres has "comedy"`, true},
+		{`year > 1990 and genres has "comedy"`, false},
+		{`not (year > 1990)`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+
+			expr, err := Parse(test.input, schema)
+			if err != nil {
+				t.Fatalf("want error to be %v; got %s", nil, err.Error())
+			}
+
+			if got := expr.Match(values); got != test.want {
+				t.Errorf("want %t; got %t", test.want, got)
+			}
+		})
+	}
+}
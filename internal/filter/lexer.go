@@ -0,0 +1,114 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string // for strings, the unquoted value
+	pos  int
+}
+
+// lex splits the expression into tokens, the last token is always tokenEOF.
+func lex(input string) ([]token, error) {
+
+	tokens := []token{}
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+
+		case strings.ContainsRune("=!<>~", r):
+			start := i
+			i++
+			if i < len(runes) && runes[i] == '=' && r != '=' && r != '~' {
+				i++
+			}
+
+			op := string(runes[start:i])
+			if op == "!" {
+				return nil, &Error{Pos: start, Msg: "unexpected !"}
+			}
+
+			tokens = append(tokens, token{tokenOperator, op, start})
+
+		case r == '"':
+			start := i
+			var b strings.Builder
+
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, &Error{Pos: start, Msg: "unterminated string"}
+				}
+
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					b.WriteRune(runes[i])
+					continue
+				}
+
+				if runes[i] == '"' {
+					i++
+					break
+				}
+
+				b.WriteRune(runes[i])
+			}
+
+			tokens = append(tokens, token{tokenString, b.String(), start})
+
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			number := string(runes[start:i])
+			if number == "-" || strings.Count(number, ".") > 1 || strings.HasSuffix(number, ".") {
+				return nil, &Error{Pos: start, Msg: "invalid number " + number}
+			}
+
+			tokens = append(tokens, token{tokenNumber, number, start})
+
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+
+			tokens = append(tokens, token{tokenIdent, string(runes[start:i]), start})
+
+		default:
+			return nil, &Error{Pos: i, Msg: "unexpected " + string(r)}
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
@@ -0,0 +1,228 @@
+// Package i18n translates the messages of the error responses. Catalogues are JSON files in
+// locales, one per language, with the messages of the error responses keyed by error code and
+// the other messages, e.g. validation errors, keyed by their English message. Messages can have
+// parameters in braces e.g. "must not be more than {max} bytes long", they are extracted from
+// the English message and substituted in the translation.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is the language of the messages in the code, it's used when a request
+// accepts no language we have a catalogue for.
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+type catalogue struct {
+	Errors    map[string]string `json:"errors"`   // messages of the error responses by error code
+	Messages  map[string]string `json:"messages"` // other messages by English message
+	templates []*template       // keys of Messages with parameters, most specific first
+	codes     map[string]*template
+}
+
+var catalogues = mustLoadCatalogues()
+
+func mustLoadCatalogues() map[string]*catalogue {
+
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	catalogues := make(map[string]*catalogue, len(files))
+
+	for _, file := range files {
+		content, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		c := &catalogue{}
+		if err := json.Unmarshal(content, c); err != nil {
+			panic(fmt.Errorf("i18n: %s: %w", file.Name(), err))
+		}
+
+		c.codes = make(map[string]*template, len(c.Errors))
+		for code, message := range c.Errors {
+			c.codes[code] = compile(message)
+		}
+
+		for message := range c.Messages {
+			if t := compile(message); len(t.params) > 0 {
+				c.templates = append(c.templates, t)
+			}
+		}
+
+		sort.Slice(c.templates, func(i, j int) bool {
+			if c.templates[i].literal != c.templates[j].literal {
+				return c.templates[i].literal > c.templates[j].literal
+			}
+			return c.templates[i].message < c.templates[j].message
+		})
+
+		catalogues[strings.TrimSuffix(file.Name(), ".json")] = c
+	}
+
+	return catalogues
+}
+
+// Languages returns the languages with a catalogue, sorted.
+func Languages() []string {
+
+	languages := make([]string, 0, len(catalogues))
+	for language := range catalogues {
+		languages = append(languages, language)
+	}
+
+	sort.Strings(languages)
+
+	return languages
+}
+
+// Negotiate selects the language of the messages from an Accept-Language header, regional
+// variants use the catalogue of their language e.g. fr-CA is answered in fr.
+func Negotiate(acceptLanguage string) string {
+
+	type acceptedLanguage struct {
+		tag string
+		q   float64
+	}
+
+	accepted := []acceptedLanguage{}
+
+	for _, value := range strings.Split(acceptLanguage, ",") {
+		parts := strings.Split(value, ";")
+		tag := strings.ToLower(strings.TrimSpace(parts[0]))
+
+		q := 1.0
+		for _, param := range parts[1:] {
+			if value := strings.TrimSpace(param); strings.HasPrefix(value, "q=") {
+				var err error
+				if q, err = strconv.ParseFloat(strings.TrimPrefix(value, "q="), 64); err != nil {
+					q = 0
+				}
+			}
+		}
+
+		if tag != "" && q > 0 {
+			accepted = append(accepted, acceptedLanguage{tag, q})
+		}
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].q > accepted[j].q })
+
+	for _, a := range accepted {
+		if a.tag == "*" {
+			return DefaultLanguage
+		}
+
+		if i := strings.IndexByte(a.tag, '-'); i >= 0 {
+			a.tag = a.tag[:i]
+		}
+
+		if _, exists := catalogues[a.tag]; exists {
+			return a.tag
+		}
+	}
+
+	return DefaultLanguage
+}
+
+// Translate returns the message in the language. The message of the error code is used when
+// the message is the English message of the code, the message is looked up in the other
+// messages otherwise. Messages without a translation are returned unchanged.
+func Translate(language, code, message string) string {
+
+	c, exists := catalogues[language]
+	if !exists || language == DefaultLanguage {
+		return message
+	}
+
+	if translation, exists := c.Errors[code]; exists {
+		if params, ok := catalogues[DefaultLanguage].codes[code].match(message); ok {
+			return substitute(translation, params)
+		}
+	}
+
+	if translation, exists := c.Messages[message]; exists {
+		return translation
+	}
+
+	for _, t := range c.templates {
+		if params, ok := t.match(message); ok {
+			return substitute(c.Messages[t.message], params)
+		}
+	}
+
+	return message
+}
+
+var paramRX = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// template matches messages against an English message with parameters.
+type template struct {
+	message string
+	rx      *regexp.Regexp
+	params  []string
+	literal int // length of the message without the parameters
+}
+
+func compile(message string) *template {
+
+	t := &template{message: message, literal: len(message)}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	last := 0
+	for _, loc := range paramRX.FindAllStringSubmatchIndex(message, -1) {
+		pattern.WriteString(regexp.QuoteMeta(message[last:loc[0]]))
+		pattern.WriteString("(.+?)")
+
+		t.params = append(t.params, message[loc[2]:loc[3]])
+		t.literal -= loc[1] - loc[0]
+		last = loc[1]
+	}
+
+	pattern.WriteString(regexp.QuoteMeta(message[last:]))
+	pattern.WriteString("$")
+
+	t.rx = regexp.MustCompile(pattern.String())
+
+	return t
+}
+
+func (t *template) match(message string) (map[string]string, bool) {
+
+	if t == nil {
+		return nil, false
+	}
+
+	values := t.rx.FindStringSubmatch(message)
+	if values == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string, len(t.params))
+	for i, param := range t.params {
+		params[param] = values[i+1]
+	}
+
+	return params, true
+}
+
+func substitute(message string, params map[string]string) string {
+	return paramRX.ReplaceAllStringFunc(message, func(param string) string {
+		return params[param[1:len(param)-1]]
+	})
+}
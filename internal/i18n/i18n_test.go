@@ -0,0 +1,98 @@
+package i18n
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "en"},
+		{"fr", "fr"},
+		{"fr-CA,fr;q=0.9,en;q=0.8", "fr"},
+		{"de, es;q=0.5", "es"},
+		{"en;q=0.5, es", "es"},
+		{"es;q=0, fr;q=0.1", "fr"},
+		{"*", "en"},
+		{"de", "en"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.acceptLanguage, func(t *testing.T) {
+			if got := Negotiate(test.acceptLanguage); got != test.want {
+				t.Errorf("want %q; got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		language string
+		code     string
+		message  string
+		want     string
+	}{
+		{"Default language", "en", "", "must be provided", "must be provided"},
+		{"Unknown language", "de", "", "must be provided", "must be provided"},
+		{"Message", "fr", "", "must be provided", "doit être renseigné"},
+		{"Parameter", "es", "", "must not be more than 500 bytes long", "no debe tener más de 500 bytes"},
+		{"Most specific template", "fr", "", "must be at least 8 bytes long", "doit contenir au moins 8 octets"},
+		{"Exact message before template", "fr", "", "must be greater than zero", "doit être supérieur à zéro"},
+		{"Several parameters", "fr", "", "must be one of csv, ndjson or json", "doit être l'une des valeurs csv, ndjson ou json"},
+		{"Error code", "es", "not_found", "the requested resource could not be found", "no se pudo encontrar el recurso solicitado"},
+		{"Error code with parameter", "fr", "method_not_allowed", "the PUT method is not supported for this resource", "la méthode PUT n'est pas prise en charge pour cette ressource"},
+		{"Error code with another message", "fr", "bad_request", "body contains badly-formed JSON", "le corps contient du JSON mal formé"},
+		{"Missing translation", "fr", "", "title is required", "title is required"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Translate(test.language, test.code, test.message); got != test.want {
+				t.Errorf("want %q; got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCatalogues(t *testing.T) {
+
+	english := catalogues[DefaultLanguage]
+
+	for _, language := range Languages() {
+		c := catalogues[language]
+
+		for code, message := range english.Errors {
+			translation, exists := c.Errors[code]
+			if !exists {
+				t.Errorf("%s: missing error code %q", language, code)
+				continue
+			}
+
+			if want, got := params(message), params(translation); want != got {
+				t.Errorf("%s: error code %q has parameters %s; want %s", language, code, got, want)
+			}
+		}
+
+		for message, translation := range c.Messages {
+			if want, got := params(message), params(translation); want != got {
+				t.Errorf("%s: %q has parameters %s; want %s", language, message, got, want)
+			}
+		}
+	}
+}
+
+func params(message string) string {
+
+	params := paramRX.FindAllString(message, -1)
+	sort.Strings(params)
+
+	return strings.Join(params, ",")
+}
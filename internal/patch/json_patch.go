@@ -0,0 +1,378 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPatch is a RFC 6902 JSON Patch document, a list of operations applied in order.
+type JSONPatch []Operation
+
+type Operation struct {
+	Op    string          `json:"op"`   // add|remove|replace|move|copy|test
+	Path  string          `json:"path"` // JSON pointer e.g. /genres/0
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+func (p JSONPatch) Apply(doc []byte) ([]byte, error) {
+
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, operation := range p {
+		target, err = operation.apply(target)
+		if err != nil {
+			return nil, fmt.Errorf("%w (operation %d)", err, i)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func (o Operation) apply(doc interface{}) (interface{}, error) {
+
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case "add":
+		value, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+
+	case "replace":
+		value, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		doc, _, err := remove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+
+	case "move":
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) > len(from) && isPrefix(from, path) {
+			return nil, fmt.Errorf("%w: cannot move %q into one of its children", ErrInvalidPatch, o.From)
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+
+	case "copy":
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+
+	case "test":
+		value, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(current, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported op %q", ErrInvalidPatch, o.Op)
+	}
+}
+
+func (o Operation) value() (interface{}, error) {
+
+	if o.Value == nil {
+		return nil, fmt.Errorf("%w: op %q requires a value", ErrInvalidPatch, o.Op)
+	}
+
+	return decode(o.Value)
+}
+
+// parsePointer splits a RFC 6901 JSON pointer into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tokens[i], "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, pathNotFound(token)
+			}
+			doc = value
+
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+
+		default:
+			return nil, pathNotFound(token)
+		}
+	}
+
+	return doc, nil
+}
+
+// add returns doc with value added at path. Slices may be reallocated, so the
+// returned value must always replace doc.
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, last := path[0], len(path) == 1
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if last {
+			node[token] = value
+			return node, nil
+		}
+
+		child, ok := node[token]
+		if !ok {
+			return nil, pathNotFound(token)
+		}
+
+		child, err := add(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+
+		return node, nil
+
+	case []interface{}:
+		if last {
+			i := len(node)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+
+			return node, nil
+		}
+
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := add(node[i], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = child
+
+		return node, nil
+
+	default:
+		return nil, pathNotFound(token)
+	}
+}
+
+// remove returns doc without the value at path, together with the removed value.
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	token, last := path[0], len(path) == 1
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, pathNotFound(token)
+		}
+
+		if last {
+			delete(node, token)
+			return node, child, nil
+		}
+
+		child, removed, err := remove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = child
+
+		return node, removed, nil
+
+	case []interface{}:
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if last {
+			removed := node[i]
+			return append(node[:i], node[i+1:]...), removed, nil
+		}
+
+		child, removed, err := remove(node[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[i] = child
+
+		return node, removed, nil
+
+	default:
+		return nil, nil, pathNotFound(token)
+	}
+}
+
+func arrayIndex(token string, max int) (int, error) {
+
+	// leading zeros are not allowed by RFC 6901
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	return i, nil
+}
+
+func pathNotFound(token string) error {
+	return fmt.Errorf("%w: path segment %q does not exist", ErrInvalidPatch, token)
+}
+
+func isPrefix(prefix, path []string) bool {
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func deepCopy(value interface{}) interface{} {
+
+	switch node := value.(type) {
+	case map[string]interface{}:
+		cp := make(map[string]interface{}, len(node))
+		for key, child := range node {
+			cp[key] = deepCopy(child)
+		}
+		return cp
+
+	case []interface{}:
+		cp := make([]interface{}, len(node))
+		for i, child := range node {
+			cp[i] = deepCopy(child)
+		}
+		return cp
+
+	default:
+		return value
+	}
+}
+
+// equal compares two decoded JSON values, treating numbers by value so 1 and 1.0 are equal.
+func equal(a, b interface{}) bool {
+
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, ok := y[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if x == y {
+			return true
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,81 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	MergePatchMediaType = "application/merge-patch+json"
+	JSONPatchMediaType  = "application/json-patch+json"
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch document")
+	ErrTestFailed   = errors.New("patch test operation failed")
+)
+
+// Patch is a document describing changes to a JSON resource, see
+// https://datatracker.ietf.org/doc/html/rfc7396 and https://datatracker.ietf.org/doc/html/rfc6902
+type Patch interface {
+	Apply(doc []byte) ([]byte, error)
+}
+
+// MergePatch is a RFC 7396 JSON Merge Patch document. Keys set to null are removed
+// from the target, every other value replaces (or is merged into) the target value.
+type MergePatch json.RawMessage
+
+func (p MergePatch) Apply(doc []byte) ([]byte, error) {
+
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := decode(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatch(target, patch))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+// decode unmarshals a JSON document keeping numbers as json.Number, so that values
+// which are not touched by a patch are written back exactly as they were read.
+func decode(doc []byte) (interface{}, error) {
+
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	return value, nil
+}
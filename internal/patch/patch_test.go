@@ -0,0 +1,127 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"Replace value", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"Add value", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"Remove value", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"Replace array", `{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{"Merge nested object", `{"a":{"b":"c","d":"e"}}`, `{"a":{"d":null,"f":"g"}}`, `{"a":{"b":"c","f":"g"}}`},
+		{"Patch is not an object", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"Keep numbers untouched", `{"year":2021,"title":"x"}`, `{"title":"y"}`, `{"title":"y","year":2021}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := MergePatch(test.patch).Apply([]byte(test.doc))
+			if err != nil {
+				t.Fatalf("want error to be %v; got %s", nil, err.Error())
+			}
+
+			assertJSONEqual(t, test.want, string(got))
+		})
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"Add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"Add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"Append array element", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"qux"}]`, `{"foo":["bar","qux"]}`},
+		{"Remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"Replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"Replace with null", `{"baz":"qux"}`, `[{"op":"replace","path":"/baz","value":null}]`, `{"baz":null}`},
+		{"Move value", `{"foo":{"bar":"baz"},"qux":{}}`, `[{"op":"move","from":"/foo/bar","path":"/qux/thud"}]`, `{"foo":{},"qux":{"thud":"baz"}}`},
+		{"Move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"Copy value", `{"foo":["a"]}`, `[{"op":"copy","from":"/foo","path":"/bar"},{"op":"add","path":"/bar/-","value":"b"}]`, `{"foo":["a"],"bar":["a","b"]}`},
+		{"Escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/m~0n"}]`, `{}`},
+		{"Test passes", `{"year":2000}`, `[{"op":"test","path":"/year","value":2000.0},{"op":"replace","path":"/year","value":2001}]`, `{"year":2001}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var p JSONPatch
+			if err := json.Unmarshal([]byte(test.patch), &p); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := p.Apply([]byte(test.doc))
+			if err != nil {
+				t.Fatalf("want error to be %v; got %s", nil, err.Error())
+			}
+
+			assertJSONEqual(t, test.want, string(got))
+		})
+	}
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		wantErr error
+	}{
+		{"Unknown op", `{}`, `[{"op":"merge","path":"/a"}]`, ErrInvalidPatch},
+		{"Missing value", `{}`, `[{"op":"add","path":"/a"}]`, ErrInvalidPatch},
+		{"Remove missing member", `{}`, `[{"op":"remove","path":"/a"}]`, ErrInvalidPatch},
+		{"Add to missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, ErrInvalidPatch},
+		{"Array index out of bounds", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":1}]`, ErrInvalidPatch},
+		{"Array index with leading zero", `{"a":[1,2]}`, `[{"op":"remove","path":"/a/01"}]`, ErrInvalidPatch},
+		{"Pointer without slash", `{"a":1}`, `[{"op":"remove","path":"a"}]`, ErrInvalidPatch},
+		{"Move into own child", `{"a":{"b":{}}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, ErrInvalidPatch},
+		{"Test fails", `{"a":"b"}`, `[{"op":"test","path":"/a","value":"c"}]`, ErrTestFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var p JSONPatch
+			if err := json.Unmarshal([]byte(test.patch), &p); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := p.Apply([]byte(test.doc))
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("want %v; got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func assertJSONEqual(t *testing.T, want, got string) {
+	t.Helper()
+
+	var w, g interface{}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatal(err)
+	}
+
+	wantJSON, _ := json.Marshal(w)
+	gotJSON, _ := json.Marshal(g)
+
+	if string(wantJSON) != string(gotJSON) {
+		t.Errorf("want %s; got %s", wantJSON, gotJSON)
+	}
+}
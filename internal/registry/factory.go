@@ -1,11 +1,9 @@
 package registry
 
 import (
-	"database/sql"
 	"sync"
 
 	"github.com/terdia/greenlight/infrastructures/logger"
-	"github.com/terdia/greenlight/infrastructures/persistence/postgres/repository"
 	"github.com/terdia/greenlight/internal/commons"
 	"github.com/terdia/greenlight/internal/mailer"
 	"github.com/terdia/greenlight/src/movies/handlers"
@@ -22,7 +20,9 @@ type Registry struct {
 
 type Services struct {
 	SharedUtil           commons.SharedUtil
+	MovieService         services.MovieService
 	UserService          user_services.UserService
+	AdminService         user_services.AdminService
 	UserRepository       user_repository.UserRepository
 	PermissionRepository user_repository.PermissionRepository
 }
@@ -33,29 +33,30 @@ type Handlers struct {
 }
 
 //todo clean up, split into domains and aggregate here
-func NewRegistry(db *sql.DB, logger *logger.Logger, mailer mailer.Mailer, wg *sync.WaitGroup) Registry {
+func NewRegistry(storage Storage, logger *logger.Logger, mailer mailer.Mailer, wg *sync.WaitGroup) Registry {
 
-	userRepository := repository.NewUserRepoitory(db)
-	permissionRepository := repository.NewPermissionRepository(db)
+	userRepository := storage.Users
+	permissionRepository := storage.Permissions
 
 	utils := commons.NewUtil(logger, wg)
-	movieService := services.NewMovieService(repository.NewMovieRepoitory(db))
+	movieService := services.NewMovieService(storage.Movies, storage.UnitOfWork)
 
-	tokenService := user_services.NewTokenService(
-		repository.NewTokenRepository(db),
-	)
+	tokenService := user_services.NewTokenService(storage.Tokens)
 
 	userService := user_services.NewUserService(
 		userRepository,
 		user_services.NewPasswordService(),
 		mailer,
 		tokenService,
+		storage.UnitOfWork,
 	)
 
-	services := newServices(utils, userService, userRepository, permissionRepository)
+	adminService := user_services.NewAdminService(userRepository, storage.Tokens, user_services.NewPasswordService(), storage.UnitOfWork)
+
+	services := newServices(utils, movieService, userService, adminService, userRepository, permissionRepository)
 
 	movieHandler := handlers.NewMovieHandler(utils, movieService)
-	userHandler := user_handler.NewUserHandler(utils, userService, tokenService, permissionRepository)
+	userHandler := user_handler.NewUserHandler(utils, userService)
 
 	handlers := newHandlers(movieHandler, userHandler)
 
@@ -67,13 +68,17 @@ func NewRegistry(db *sql.DB, logger *logger.Logger, mailer mailer.Mailer, wg *sy
 
 func newServices(
 	sharedUtil commons.SharedUtil,
+	movieService services.MovieService,
 	userService user_services.UserService,
+	adminService user_services.AdminService,
 	userRepository user_repository.UserRepository,
 	permissionRepository user_repository.PermissionRepository,
 ) *Services {
 	return &Services{
 		SharedUtil:           sharedUtil,
+		MovieService:         movieService,
 		UserService:          userService,
+		AdminService:         adminService,
 		UserRepository:       userRepository,
 		PermissionRepository: permissionRepository,
 	}
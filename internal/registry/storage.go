@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/terdia/greenlight/config"
+	"github.com/terdia/greenlight/infrastructures/logger"
+	"github.com/terdia/greenlight/infrastructures/persistence/memory"
+	"github.com/terdia/greenlight/infrastructures/persistence/migrate"
+	"github.com/terdia/greenlight/infrastructures/persistence/postgres"
+	"github.com/terdia/greenlight/infrastructures/persistence/postgres/repository"
+	"github.com/terdia/greenlight/infrastructures/persistence/sqlite"
+	sqlite_repository "github.com/terdia/greenlight/infrastructures/persistence/sqlite/repository"
+	"github.com/terdia/greenlight/internal/unitofwork"
+)
+
+const (
+	StorageDatabase = "db" // the PostgreSQL or SQLite database of the DSN
+	StorageMemory   = "memory"
+)
+
+// Storage holds the repositories of a storage backend and the unit of work running them in a
+// single transaction.
+type Storage struct {
+	unitofwork.Repositories
+	UnitOfWork unitofwork.UnitOfWork
+}
+
+func NewPostgresStorage(db *sql.DB) Storage {
+	return Storage{
+		Repositories: unitofwork.Repositories{
+			Movies:      repository.NewMovieRepoitory(db),
+			Users:       repository.NewUserRepoitory(db),
+			Tokens:      repository.NewTokenRepository(db),
+			Permissions: repository.NewPermissionRepository(db),
+		},
+		UnitOfWork: repository.NewUnitOfWork(db),
+	}
+}
+
+// NewSQLiteStorage returns a storage keeping everything in a SQLite database, for offline demos
+// and single-node deployments.
+func NewSQLiteStorage(db *sql.DB) Storage {
+	return Storage{
+		Repositories: unitofwork.Repositories{
+			Movies:      sqlite_repository.NewMovieRepository(db),
+			Users:       sqlite_repository.NewUserRepository(db),
+			Tokens:      sqlite_repository.NewTokenRepository(db),
+			Permissions: sqlite_repository.NewPermissionRepository(db),
+		},
+		UnitOfWork: sqlite_repository.NewUnitOfWork(db),
+	}
+}
+
+// NewMemoryStorage returns a storage keeping everything in memory, for development and tests
+// without a database.
+func NewMemoryStorage() Storage {
+
+	store := memory.NewStore()
+
+	return Storage{
+		Repositories: unitofwork.Repositories{
+			Movies:      memory.NewMovieRepository(store),
+			Users:       memory.NewUserRepository(store),
+			Tokens:      memory.NewTokenRepository(store),
+			Permissions: memory.NewPermissionRepository(store),
+		},
+		UnitOfWork: memory.NewUnitOfWork(store),
+	}
+}
+
+// OpenStorage opens the storage backend of the configuration, the database of the DSN unless
+// the memory storage is configured. db and migrator are nil for the memory storage, otherwise
+// the caller closes db.
+func OpenStorage(cfg *config.Config, logger *logger.Logger) (storage Storage, db *sql.DB, migrator *migrate.Migrator, err error) {
+
+	switch {
+	case cfg.Storage == StorageDatabase && sqlite.IsDsn(cfg.Db.Dsn):
+		db, err = sqlite.OpenDb(cfg.Db)
+		if err != nil {
+			return Storage{}, nil, nil, err
+		}
+
+		logger.PrintInfo("sqlite database opened", nil)
+
+		storage = NewSQLiteStorage(db)
+		migrator, err = sqlite.NewMigrator(db)
+	case cfg.Storage == StorageDatabase:
+		db, err = postgres.OpenDb(cfg.Db)
+		if err != nil {
+			return Storage{}, nil, nil, err
+		}
+
+		logger.PrintInfo("database connection pool established", nil)
+
+		storage = NewPostgresStorage(db)
+		migrator, err = postgres.NewMigrator(db)
+	case cfg.Storage == StorageMemory:
+		logger.PrintInfo("using in-memory storage, data is lost on exit", nil)
+
+		return NewMemoryStorage(), nil, nil, nil
+	default:
+		return Storage{}, nil, nil, fmt.Errorf("unknown storage %q, want db or memory", cfg.Storage)
+	}
+
+	if err != nil {
+		db.Close()
+		return Storage{}, nil, nil, err
+	}
+
+	return storage, db, migrator, nil
+}
@@ -0,0 +1,553 @@
+// Package repositorytest is a conformance suite for the implementations of the repository
+// interfaces, so every storage backend behaves the same.
+//
+// A backend runs the suite from its own tests with a function returning the repositories of a
+// new, empty storage:
+//
+//	func TestConformance(t *testing.T) {
+//		repositorytest.Run(t, func(t *testing.T) unitofwork.Repositories {
+//			return newRepositories(t)
+//		})
+//	}
+package repositorytest
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/unitofwork"
+	movie_entities "github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/users/entities"
+	"github.com/terdia/greenlight/src/users/repositories"
+)
+
+// NewRepositories returns the repositories of a new, empty storage.
+type NewRepositories func(t *testing.T) unitofwork.Repositories
+
+// Run runs the conformance tests of every repository.
+func Run(t *testing.T, newRepositories NewRepositories) {
+	t.Run("Movies", func(t *testing.T) { TestMovieRepository(t, newRepositories) })
+	t.Run("Users", func(t *testing.T) { TestUserRepository(t, newRepositories) })
+	t.Run("Tokens", func(t *testing.T) { TestTokenRepository(t, newRepositories) })
+	t.Run("Permissions", func(t *testing.T) { TestPermissionRepository(t, newRepositories) })
+}
+
+// TestMovieRepository checks the CRUD operations of the movie repository, that missing movies
+// aren't found and that updates of a stale version are rejected.
+func TestMovieRepository(t *testing.T, newRepositories NewRepositories) {
+
+	ctx := context.Background()
+
+	t.Run("Insert and get", func(t *testing.T) {
+		movies := newRepositories(t).Movies
+
+		movie := newMovie("Alien")
+		if err := movies.Insert(ctx, movie); err != nil {
+			t.Fatal(err)
+		}
+
+		if movie.ID < 1 || movie.Version != 1 || movie.CreatedAt.IsZero() {
+			t.Errorf("want id, version 1 and creation time to be set; got %+v", movie)
+		}
+
+		got, err := movies.Get(ctx, int64(movie.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkMovie(t, got, movie)
+	})
+
+	t.Run("Get missing", func(t *testing.T) {
+		movies := newRepositories(t).Movies
+
+		for _, id := range []int64{0, 42} {
+			if _, err := movies.Get(ctx, id); !errors.Is(err, data.ErrRecordNotFound) {
+				t.Errorf("want error %v for id %d; got %v", data.ErrRecordNotFound, id, err)
+			}
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		movies := newRepositories(t).Movies
+
+		movie := newMovie("Alien")
+		if err := movies.Insert(ctx, movie); err != nil {
+			t.Fatal(err)
+		}
+
+		movie.Title = "Aliens"
+		movie.Genres = []string{"action", "sci-fi"}
+
+		if err := movies.Update(ctx, movie); err != nil {
+			t.Fatal(err)
+		}
+
+		if movie.Version != 2 {
+			t.Errorf("want version 2; got %d", movie.Version)
+		}
+
+		got, err := movies.Get(ctx, int64(movie.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkMovie(t, got, movie)
+	})
+
+	t.Run("Edit conflict", func(t *testing.T) {
+		movies := newRepositories(t).Movies
+
+		movie := newMovie("Alien")
+		if err := movies.Insert(ctx, movie); err != nil {
+			t.Fatal(err)
+		}
+
+		stale := *movie
+
+		if err := movies.Update(ctx, movie); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := movies.Update(ctx, &stale); !errors.Is(err, data.ErrEditConflict) {
+			t.Errorf("want error %v for a stale version; got %v", data.ErrEditConflict, err)
+		}
+
+		if err := movies.Delete(ctx, int64(movie.ID)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := movies.Update(ctx, movie); !errors.Is(err, data.ErrEditConflict) {
+			t.Errorf("want error %v for a deleted movie; got %v", data.ErrEditConflict, err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		movies := newRepositories(t).Movies
+
+		movie := newMovie("Alien")
+		if err := movies.Insert(ctx, movie); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := movies.Delete(ctx, int64(movie.ID)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := movies.Get(ctx, int64(movie.ID)); !errors.Is(err, data.ErrRecordNotFound) {
+			t.Errorf("want error %v after delete; got %v", data.ErrRecordNotFound, err)
+		}
+
+		for _, id := range []int64{0, int64(movie.ID)} {
+			if err := movies.Delete(ctx, id); !errors.Is(err, data.ErrRecordNotFound) {
+				t.Errorf("want error %v deleting id %d; got %v", data.ErrRecordNotFound, id, err)
+			}
+		}
+	})
+}
+
+// TestUserRepository checks the CRUD operations of the user repository, that emails are unique
+// regardless of case and that updates of a stale version are rejected.
+func TestUserRepository(t *testing.T, newRepositories NewRepositories) {
+
+	ctx := context.Background()
+
+	t.Run("Insert and get by email", func(t *testing.T) {
+		users := newRepositories(t).Users
+
+		user := newUser("alice@example.com")
+		if err := users.Insert(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+
+		if user.ID < 1 || user.Version != 1 || user.CreatedAt.IsZero() {
+			t.Errorf("want id, version 1 and creation time to be set; got %+v", user)
+		}
+
+		got, err := users.GetByEmail(ctx, "Alice@Example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkUser(t, got, user)
+	})
+
+	t.Run("Get missing", func(t *testing.T) {
+		users := newRepositories(t).Users
+
+		if _, err := users.GetByEmail(ctx, "alice@example.com"); !errors.Is(err, data.ErrRecordNotFound) {
+			t.Errorf("want error %v; got %v", data.ErrRecordNotFound, err)
+		}
+	})
+
+	t.Run("Duplicate email", func(t *testing.T) {
+		users := newRepositories(t).Users
+
+		alice, bob := newUser("alice@example.com"), newUser("bob@example.com")
+
+		for _, user := range []*entities.User{alice, bob} {
+			if err := users.Insert(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := users.Insert(ctx, newUser("ALICE@example.com")); !errors.Is(err, data.ErrDuplicateEmail) {
+			t.Errorf("want error %v on insert; got %v", data.ErrDuplicateEmail, err)
+		}
+
+		bob.Email = "Alice@example.com"
+		if err := users.Update(ctx, bob); !errors.Is(err, data.ErrDuplicateEmail) {
+			t.Errorf("want error %v on update; got %v", data.ErrDuplicateEmail, err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		users := newRepositories(t).Users
+
+		user := newUser("alice@example.com")
+		if err := users.Insert(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+
+		user.Email = "alice@example.org"
+		user.Activated = true
+
+		if err := users.Update(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+
+		if user.Version != 2 {
+			t.Errorf("want version 2; got %d", user.Version)
+		}
+
+		got, err := users.GetByEmail(ctx, user.Email)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkUser(t, got, user)
+
+		if _, err := users.GetByEmail(ctx, "alice@example.com"); !errors.Is(err, data.ErrRecordNotFound) {
+			t.Errorf("want error %v for the previous email; got %v", data.ErrRecordNotFound, err)
+		}
+	})
+
+	t.Run("Edit conflict", func(t *testing.T) {
+		users := newRepositories(t).Users
+
+		user := newUser("alice@example.com")
+		if err := users.Insert(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+
+		stale := *user
+
+		if err := users.Update(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := users.Update(ctx, &stale); !errors.Is(err, data.ErrEditConflict) {
+			t.Errorf("want error %v; got %v", data.ErrEditConflict, err)
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		users := newRepositories(t).Users
+
+		checkUserCounts(t, users, entities.UserCounts{})
+
+		alice, bob := newUser("alice@example.com"), newUser("bob@example.com")
+		alice.Activated = true
+
+		for _, user := range []*entities.User{alice, bob} {
+			if err := users.Insert(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		checkUserCounts(t, users, entities.UserCounts{Total: 2, Activated: 1})
+	})
+}
+
+// TestTokenRepository checks that the users of tokens are found until the tokens expire or are
+// deleted, and only for the scope of the tokens, and that expired tokens are counted and purged
+// apart from the others.
+func TestTokenRepository(t *testing.T, newRepositories NewRepositories) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		expiry    time.Duration
+		scope     string
+		plaintext string // plaintext looked up, the token's when empty
+		wantErr   error
+	}{
+		{name: "Valid", expiry: time.Hour, scope: data.TokenScopeActivation},
+		{name: "Expired", expiry: -time.Minute, scope: data.TokenScopeActivation, wantErr: data.ErrRecordNotFound},
+		{name: "Other scope", expiry: time.Hour, scope: data.TokenScopeAuthentication, wantErr: data.ErrRecordNotFound},
+		{name: "Unknown", expiry: time.Hour, scope: data.TokenScopeActivation, plaintext: "UNKNOWNTOKEN", wantErr: data.ErrRecordNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repos := newRepositories(t)
+
+			user := newUser("alice@example.com")
+			if err := repos.Users.Insert(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+
+			token := newToken(user, "TESTTOKEN", test.scope, test.expiry)
+			if err := repos.Tokens.Create(ctx, token); err != nil {
+				t.Fatal(err)
+			}
+
+			plaintext := test.plaintext
+			if plaintext == "" {
+				plaintext = token.Plaintext
+			}
+
+			got, err := repos.Users.GetForToken(ctx, plaintext, data.TokenScopeActivation)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("want error %v; got %v", test.wantErr, err)
+			}
+
+			if err == nil {
+				checkUser(t, got, user)
+			}
+		})
+	}
+
+	t.Run("Delete all for user by scope", func(t *testing.T) {
+		repos := newRepositories(t)
+
+		alice, bob := newUser("alice@example.com"), newUser("bob@example.com")
+
+		for _, user := range []*entities.User{alice, bob} {
+			if err := repos.Users.Insert(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tokens := []*entities.Token{
+			newToken(alice, "ALICEACTIVATION", data.TokenScopeActivation, time.Hour),
+			newToken(alice, "ALICEAUTHENTICATION", data.TokenScopeAuthentication, time.Hour),
+			newToken(bob, "BOBACTIVATION", data.TokenScopeActivation, time.Hour),
+		}
+
+		for _, token := range tokens {
+			if err := repos.Tokens.Create(ctx, token); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := repos.Tokens.DeleteAllForUserByScope(ctx, data.TokenScopeActivation, alice.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		wantErrs := []error{data.ErrRecordNotFound, nil, nil}
+
+		for i, token := range tokens {
+			if _, err := repos.Users.GetForToken(ctx, token.Plaintext, token.Scope); !errors.Is(err, wantErrs[i]) {
+				t.Errorf("want error %v for token %s; got %v", wantErrs[i], token.Plaintext, err)
+			}
+		}
+	})
+
+	t.Run("Count and delete expired", func(t *testing.T) {
+		repos := newRepositories(t)
+
+		checkTokenCounts(t, repos, entities.TokenCounts{})
+
+		alice, bob := newUser("alice@example.com"), newUser("bob@example.com")
+
+		for _, user := range []*entities.User{alice, bob} {
+			if err := repos.Users.Insert(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tokens := []*entities.Token{
+			newToken(alice, "ALICEACTIVATION", data.TokenScopeActivation, time.Hour),
+			newToken(alice, "ALICEAUTHENTICATION", data.TokenScopeAuthentication, -time.Minute),
+			newToken(bob, "BOBACTIVATION", data.TokenScopeActivation, -time.Minute),
+			newToken(bob, "BOBAUTHENTICATION", data.TokenScopeAuthentication, time.Hour),
+		}
+
+		for _, token := range tokens {
+			if err := repos.Tokens.Create(ctx, token); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		active := map[string]int{data.TokenScopeActivation: 1, data.TokenScopeAuthentication: 1}
+
+		checkTokenCounts(t, repos, entities.TokenCounts{Active: active, Expired: 2})
+
+		deleted, err := repos.Tokens.DeleteExpired(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if deleted != 2 {
+			t.Errorf("want 2 deleted tokens; got %d", deleted)
+		}
+
+		checkTokenCounts(t, repos, entities.TokenCounts{Active: active})
+
+		for _, token := range []*entities.Token{tokens[0], tokens[3]} {
+			if _, err := repos.Users.GetForToken(ctx, token.Plaintext, token.Scope); err != nil {
+				t.Errorf("want token %s to be kept; got %v", token.Plaintext, err)
+			}
+		}
+	})
+}
+
+// TestPermissionRepository checks that the permissions of a user are the union of the
+// permissions added to them less the permissions removed, ignoring unknown codes.
+func TestPermissionRepository(t *testing.T, newRepositories NewRepositories) {
+
+	ctx := context.Background()
+	repos := newRepositories(t)
+
+	alice, bob := newUser("alice@example.com"), newUser("bob@example.com")
+
+	for _, user := range []*entities.User{alice, bob} {
+		if err := repos.Users.Insert(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	permissions, err := repos.Permissions.GetAllForUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(permissions) != 0 {
+		t.Errorf("want no permissions for a new user; got %v", permissions)
+	}
+
+	for _, codes := range [][]string{{"movies:read"}, {"movies:read", "movies:write", "movies:unknown"}} {
+		if err := repos.Permissions.AddForUser(ctx, alice.ID, codes...); err != nil {
+			t.Fatalf("add permissions %v: %v", codes, err)
+		}
+	}
+
+	if err := repos.Permissions.AddForUser(ctx, bob.ID, "movies:read"); err != nil {
+		t.Fatal(err)
+	}
+
+	checkPermissions(t, repos, map[*entities.User][]string{
+		alice: {"movies:read", "movies:write"},
+		bob:   {"movies:read"},
+	})
+
+	for _, codes := range [][]string{{"movies:write", "movies:unknown"}, {"movies:write"}} {
+		if err := repos.Permissions.RemoveForUser(ctx, alice.ID, codes...); err != nil {
+			t.Fatalf("remove permissions %v: %v", codes, err)
+		}
+	}
+
+	checkPermissions(t, repos, map[*entities.User][]string{
+		alice: {"movies:read"},
+		bob:   {"movies:read"},
+	})
+}
+
+func checkPermissions(t *testing.T, repos unitofwork.Repositories, want map[*entities.User][]string) {
+	t.Helper()
+
+	for user, codes := range want {
+		permissions, err := repos.Permissions.GetAllForUser(context.Background(), user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []string(permissions)
+		sort.Strings(got)
+
+		if !reflect.DeepEqual(got, codes) {
+			t.Errorf("want permissions %v for %s; got %v", codes, user.Email, got)
+		}
+	}
+}
+
+func checkUserCounts(t *testing.T, users repositories.UserRepository, want entities.UserCounts) {
+	t.Helper()
+
+	got, err := users.Count(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != want {
+		t.Errorf("want user counts %+v; got %+v", want, got)
+	}
+}
+
+// checkTokenCounts compares the counts of tokens, a scope without active tokens may be missing.
+func checkTokenCounts(t *testing.T, repos unitofwork.Repositories, want entities.TokenCounts) {
+	t.Helper()
+
+	got, err := repos.Tokens.Count(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for scope, count := range got.Active {
+		if count != want.Active[scope] {
+			t.Errorf("want %d active %s tokens; got %d", want.Active[scope], scope, count)
+		}
+	}
+
+	for scope, count := range want.Active {
+		if got.Active[scope] != count {
+			t.Errorf("want %d active %s tokens; got %d", count, scope, got.Active[scope])
+		}
+	}
+
+	if got.Expired != want.Expired {
+		t.Errorf("want %d expired tokens; got %d", want.Expired, got.Expired)
+	}
+}
+
+func newMovie(title string) *movie_entities.Movie {
+	return &movie_entities.Movie{Title: title, Year: 1979, Runtime: 117, Genres: []string{"horror", "sci-fi"}, Language: "english"}
+}
+
+func newUser(email string) *entities.User {
+	return &entities.User{Name: "Test", Email: email, Password: entities.Password{Hash: []byte("hash")}}
+}
+
+func newToken(user *entities.User, plaintext, scope string, expiry time.Duration) *entities.Token {
+
+	hash := sha256.Sum256([]byte(plaintext))
+
+	return &entities.Token{Plaintext: plaintext, Hash: hash[:], UserId: user.ID, Expiry: time.Now().Add(expiry), Scope: scope}
+}
+
+func checkMovie(t *testing.T, got, want *movie_entities.Movie) {
+	t.Helper()
+
+	if got.ID != want.ID || got.Title != want.Title || got.Year != want.Year || got.Runtime != want.Runtime ||
+		!reflect.DeepEqual(got.Genres, want.Genres) || got.Language != want.Language || got.Version != want.Version ||
+		!got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("want movie %+v; got %+v", want, got)
+	}
+}
+
+func checkUser(t *testing.T, got, want *entities.User) {
+	t.Helper()
+
+	if got.ID != want.ID || got.Name != want.Name || got.Email != want.Email || got.Activated != want.Activated ||
+		!reflect.DeepEqual(got.Password.Hash, want.Password.Hash) || got.Version != want.Version || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("want user %+v; got %+v", want, got)
+	}
+}
@@ -0,0 +1,322 @@
+// Package seed generates fixture data for development databases: movies, users with various
+// permissions and their tokens. The fixtures only depend on the seed and the scale, and a larger
+// scale generates the fixtures of a smaller one plus new ones, so seeding a database again only
+// adds what is missing.
+package seed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/unitofwork"
+	"github.com/terdia/greenlight/internal/validator"
+	movie_entities "github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/users/entities"
+	"github.com/terdia/greenlight/src/users/services"
+)
+
+const (
+	MoviesPerScale = 100
+	UsersPerScale  = 10
+
+	// Password is the password of the users created by Apply, the users already stored keep
+	// their own.
+	Password = "pa55word"
+
+	// the years of the movies don't depend on the current date, so the fixtures never change
+	minYear = 1920
+	maxYear = 2020
+
+	// authenticationTokenTTL is the TTL of the tokens issued by POST /v1/tokens/authentication.
+	authenticationTokenTTL = 24 * time.Hour
+)
+
+type Options struct {
+	Seed  int64
+	Scale int // MoviesPerScale movies and UsersPerScale users per unit
+}
+
+// User is a seeded user. The first one is an administrator, then users are readers, writers or
+// not activated yet.
+type User struct {
+	Name        string
+	Email       string
+	Activated   bool
+	Permissions []string
+	Token       Token // an authentication token, or an activation token for a user not activated
+}
+
+type Token struct {
+	Plaintext string
+	Scope     string
+	TTL       time.Duration
+}
+
+type Fixtures struct {
+	Movies []*movie_entities.Movie
+	Users  []User
+}
+
+// Result counts the records inserted by Apply and those which were already stored.
+type Result struct {
+	MoviesInserted int
+	MoviesExisting int
+	UsersCreated   int
+	UsersExisting  int
+
+	// Created holds the emails of the users created, the only ones whose password is Password.
+	Created []string
+}
+
+// Generate returns the fixtures of the options. Movies and users are generated from their own
+// source, so the movies of a scale don't depend on the number of users and the other way around.
+func Generate(opts Options) Fixtures {
+	return Fixtures{
+		Movies: generateMovies(rand.New(rand.NewSource(opts.Seed)), opts.Scale*MoviesPerScale),
+		Users:  generateUsers(rand.New(rand.NewSource(opts.Seed+1)), opts.Scale*UsersPerScale),
+	}
+}
+
+func generateMovies(rng *rand.Rand, n int) []*movie_entities.Movie {
+
+	movies := make([]*movie_entities.Movie, 0, n)
+	titles := map[string]int{}
+
+	for len(movies) < n {
+		title := generateTitle(rng)
+
+		// a title generated again is a sequel e.g. The Silent River 2
+		titles[title]++
+		if count := titles[title]; count > 1 {
+			title = fmt.Sprintf("%s %d", title, count)
+		}
+
+		language := "english"
+		if rng.Intn(5) == 0 {
+			language = data.DefaultLanguage
+		}
+
+		movies = append(movies, &movie_entities.Movie{
+			Title:    title,
+			Year:     int32(minYear + rng.Intn(maxYear-minYear+1)),
+			Runtime:  custom_type.Runtime(75 + rng.Intn(120)),
+			Genres:   generateGenres(rng),
+			Language: language,
+		})
+	}
+
+	return movies
+}
+
+func generateTitle(rng *rand.Rand) string {
+
+	adjective := titleAdjectives[rng.Intn(len(titleAdjectives))]
+	noun := titleNouns[rng.Intn(len(titleNouns))]
+
+	switch rng.Intn(4) {
+	case 0:
+		return fmt.Sprintf("The %s %s", adjective, noun)
+	case 1:
+		return fmt.Sprintf("%s of the %s %s", titleNouns[rng.Intn(len(titleNouns))], adjective, noun)
+	case 2:
+		return fmt.Sprintf("%s %s in %s", adjective, noun, titlePlaces[rng.Intn(len(titlePlaces))])
+	default:
+		return fmt.Sprintf("%s %s", adjective, noun)
+	}
+}
+
+// generateGenres returns 1 to 3 distinct genres.
+func generateGenres(rng *rand.Rand) []string {
+
+	picked := rng.Perm(len(genres))[:1+rng.Intn(3)]
+
+	movieGenres := make([]string, 0, len(picked))
+	for _, i := range picked {
+		movieGenres = append(movieGenres, genres[i])
+	}
+
+	return movieGenres
+}
+
+func generateUsers(rng *rand.Rand, n int) []User {
+
+	users := make([]User, 0, n)
+
+	for i := 0; i < n; i++ {
+		first, last := firstNames[rng.Intn(len(firstNames))], lastNames[rng.Intn(len(lastNames))]
+
+		user := User{
+			Name:        first + " " + last,
+			Email:       strings.ToLower(fmt.Sprintf("%s.%s%d@example.com", first, last, i)),
+			Activated:   true,
+			Permissions: []string{"movies:read"},
+			Token:       Token{Scope: data.TokenScopeAuthentication, TTL: authenticationTokenTTL},
+		}
+
+		switch {
+		case i == 0:
+			user.Email = "admin@example.com"
+			user.Permissions = []string{"movies:read", "movies:write"}
+		case i%5 == 4:
+			user.Activated = false
+			user.Token = Token{Scope: data.TokenScopeActivation, TTL: services.ActivationTokenTTL}
+		case i%3 == 1:
+			user.Permissions = []string{"movies:read", "movies:write"}
+		}
+
+		// the plain text of the tokens is like the one of the tokens sent by the API
+		random := make([]byte, 16)
+		rng.Read(random)
+		user.Token.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(random)
+
+		users = append(users, user)
+	}
+
+	return users
+}
+
+type movieKey struct {
+	title string
+	year  int32
+}
+
+// Apply stores the fixtures in a single unit of work. Movies already stored with the same title
+// and year, and users already stored with the same email, are kept. The users are activated when
+// the fixtures are, granted the permissions of the fixtures, and their tokens are issued again,
+// replacing their other tokens of the same scope, so applying the fixtures again only renews the
+// tokens.
+func Apply(ctx context.Context, uow unitofwork.UnitOfWork, fixtures Fixtures) (Result, error) {
+
+	for _, movie := range fixtures.Movies {
+		v := validator.New().Use(data.Rules)
+
+		// the checks of the movies created with the API
+		if v.Struct(dto.MovieRequest{
+			Title:    &movie.Title,
+			Year:     &movie.Year,
+			Runtime:  &movie.Runtime,
+			Genres:   movie.Genres,
+			Language: &movie.Language,
+		}); !v.Valid() {
+			return Result{}, fmt.Errorf("seed: invalid movie %q: %v", movie.Title, v.Errors.Flat())
+		}
+	}
+
+	for _, user := range fixtures.Users {
+		v := validator.New()
+
+		if v.Struct(dto.CreateUserRequest{Name: user.Name, Email: user.Email, Password: Password}); !v.Valid() {
+			return Result{}, fmt.Errorf("seed: invalid user %q: %v", user.Email, v.Errors.Flat())
+		}
+	}
+
+	// hashing is slow, every user has the same password
+	plaintext := Password
+	password := entities.Password{PlainText: &plaintext}
+
+	if err := services.NewPasswordService().Hash(&password); err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+
+	err := uow.Do(ctx, func(repos unitofwork.Repositories) error {
+
+		result = Result{}
+
+		stored := map[movieKey]bool{}
+
+		err := repos.Movies.Export(ctx, dto.ListMovieRequest{}, func(movie *movie_entities.Movie) error {
+			stored[movieKey{movie.Title, movie.Year}] = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		var missing []*movie_entities.Movie
+
+		for _, movie := range fixtures.Movies {
+			if stored[movieKey{movie.Title, movie.Year}] {
+				result.MoviesExisting++
+			} else {
+				missing = append(missing, movie)
+			}
+		}
+
+		if len(missing) > 0 {
+			inserted, err := repos.Movies.CopyFrom(ctx, missing)
+			if err != nil {
+				return err
+			}
+
+			result.MoviesInserted = int(inserted)
+		}
+
+		for _, fixture := range fixtures.Users {
+			if err := applyUser(ctx, repos, fixture, password, &result); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+func applyUser(ctx context.Context, repos unitofwork.Repositories, fixture User, password entities.Password, result *Result) error {
+
+	user, err := repos.Users.GetByEmail(ctx, fixture.Email)
+
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		user = &entities.User{Name: fixture.Name, Email: fixture.Email, Password: password, Activated: fixture.Activated}
+
+		if err := repos.Users.Insert(ctx, user); err != nil {
+			return err
+		}
+
+		result.UsersCreated++
+		result.Created = append(result.Created, fixture.Email)
+	case err != nil:
+		return err
+	default:
+		result.UsersExisting++
+
+		if fixture.Activated && !user.Activated {
+			user.Activated = true
+
+			if err := repos.Users.Update(ctx, user); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := repos.Permissions.AddForUser(ctx, user.ID, fixture.Permissions...); err != nil {
+		return err
+	}
+
+	if err := repos.Tokens.DeleteAllForUserByScope(ctx, fixture.Token.Scope, user.ID); err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(fixture.Token.Plaintext))
+
+	return repos.Tokens.Create(ctx, &entities.Token{
+		Plaintext: fixture.Token.Plaintext,
+		Hash:      hash[:],
+		UserId:    user.ID,
+		Expiry:    time.Now().Add(fixture.Token.TTL),
+		Scope:     fixture.Token.Scope,
+	})
+}
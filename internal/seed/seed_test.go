@@ -0,0 +1,136 @@
+package seed
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/terdia/greenlight/infrastructures/persistence/memory"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/unitofwork"
+)
+
+func TestGenerate(t *testing.T) {
+
+	fixtures := Generate(Options{Seed: 42, Scale: 2})
+
+	if len(fixtures.Movies) != 2*MoviesPerScale || len(fixtures.Users) != 2*UsersPerScale {
+		t.Fatalf("want %d movies and %d users; got %d and %d", 2*MoviesPerScale, 2*UsersPerScale, len(fixtures.Movies), len(fixtures.Users))
+	}
+
+	if again := Generate(Options{Seed: 42, Scale: 2}); !reflect.DeepEqual(again, fixtures) {
+		t.Error("want the same fixtures for the same seed")
+	}
+
+	if other := Generate(Options{Seed: 43, Scale: 2}); reflect.DeepEqual(other.Movies, fixtures.Movies) {
+		t.Error("want other movies for another seed")
+	}
+
+	smaller := Generate(Options{Seed: 42, Scale: 1})
+
+	if !reflect.DeepEqual(smaller.Movies, fixtures.Movies[:MoviesPerScale]) || !reflect.DeepEqual(smaller.Users, fixtures.Users[:UsersPerScale]) {
+		t.Error("want the fixtures of a smaller scale to be the first fixtures of a larger one")
+	}
+
+	titles := map[string]bool{}
+	for _, movie := range fixtures.Movies {
+		if titles[movie.Title] {
+			t.Errorf("want distinct titles; got %q twice", movie.Title)
+		}
+
+		titles[movie.Title] = true
+	}
+
+	emails := map[string]bool{}
+	for _, user := range fixtures.Users {
+		if emails[user.Email] {
+			t.Errorf("want distinct emails; got %q twice", user.Email)
+		}
+
+		emails[user.Email] = true
+	}
+}
+
+func TestApply(t *testing.T) {
+
+	ctx := context.Background()
+
+	store := memory.NewStore()
+	repos := unitofwork.Repositories{
+		Movies:      memory.NewMovieRepository(store),
+		Users:       memory.NewUserRepository(store),
+		Tokens:      memory.NewTokenRepository(store),
+		Permissions: memory.NewPermissionRepository(store),
+	}
+	uow := memory.NewUnitOfWork(store)
+
+	fixtures := Generate(Options{Seed: 1, Scale: 1})
+
+	tests := []struct {
+		name     string
+		fixtures Fixtures
+		want     Result
+	}{
+		{name: "Empty database", fixtures: fixtures, want: Result{MoviesInserted: MoviesPerScale, UsersCreated: UsersPerScale, Created: emails(fixtures.Users)}},
+		{name: "Again", fixtures: fixtures, want: Result{MoviesExisting: MoviesPerScale, UsersExisting: UsersPerScale}},
+		{
+			name:     "Larger scale",
+			fixtures: Generate(Options{Seed: 1, Scale: 2}),
+			want: Result{
+				MoviesInserted: MoviesPerScale, MoviesExisting: MoviesPerScale, UsersCreated: UsersPerScale, UsersExisting: UsersPerScale,
+				Created: emails(Generate(Options{Seed: 1, Scale: 2}).Users[UsersPerScale:]),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Apply(ctx, uow, test.fixtures)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(result, test.want) {
+				t.Errorf("want result %+v; got %+v", test.want, result)
+			}
+		})
+	}
+
+	counts, err := repos.Users.Count(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if counts.Total != 2*UsersPerScale {
+		t.Errorf("want %d users; got %d", 2*UsersPerScale, counts.Total)
+	}
+
+	for _, fixture := range fixtures.Users {
+		user, err := repos.Users.GetForToken(ctx, fixture.Token.Plaintext, fixture.Token.Scope)
+		if err != nil {
+			t.Fatalf("token of %s: %v", fixture.Email, err)
+		}
+
+		if user.Email != fixture.Email || user.Activated != fixture.Activated {
+			t.Errorf("want user %s activated %t; got %s activated %t", fixture.Email, fixture.Activated, user.Email, user.Activated)
+		}
+
+		permissions, err := repos.Permissions.GetAllForUser(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(permissions, data.Permissions(fixture.Permissions)) {
+			t.Errorf("want permissions %v for %s; got %v", fixture.Permissions, fixture.Email, permissions)
+		}
+	}
+}
+
+func emails(users []User) []string {
+	result := []string{}
+	for _, user := range users {
+		result = append(result, user.Email)
+	}
+
+	return result
+}
@@ -0,0 +1,34 @@
+package seed
+
+var genres = []string{
+	"action", "adventure", "animation", "comedy", "crime", "documentary", "drama", "family", "fantasy",
+	"history", "horror", "music", "mystery", "romance", "sci-fi", "thriller", "war", "western",
+}
+
+var titleAdjectives = []string{
+	"Silent", "Broken", "Golden", "Hidden", "Last", "Lost", "Midnight", "Crimson", "Forgotten", "Burning",
+	"Frozen", "Wild", "Distant", "Hollow", "Electric", "Savage", "Quiet", "Endless", "Velvet", "Iron",
+	"Scarlet", "Secret", "Shattered", "Restless", "Fallen", "Eternal", "Bitter", "Wandering", "Northern", "Lonely",
+}
+
+var titleNouns = []string{
+	"River", "City", "Garden", "Empire", "Shadow", "Horizon", "Kingdom", "Island", "Storm", "Harbor",
+	"Mountain", "Station", "Frontier", "Mirror", "Orchard", "Desert", "Lighthouse", "Circus", "Highway", "Forest",
+	"Planet", "Winter", "Summer", "Ocean", "Tower", "Valley", "Machine", "Heart", "Crown", "Signal",
+}
+
+var titlePlaces = []string{
+	"Paris", "Tokyo", "Casablanca", "Berlin", "Havana", "Cairo", "Lagos", "Lisbon", "Vienna", "Bombay",
+	"Chicago", "Marseille", "Shanghai", "Nairobi", "Istanbul",
+}
+
+var firstNames = []string{
+	"Alice", "Bob", "Chidi", "Dana", "Emeka", "Fatima", "Gabriel", "Hana", "Ivan", "Julia",
+	"Kofi", "Lena", "Mateo", "Nadia", "Oscar", "Priya", "Quentin", "Rosa", "Sven", "Tariq",
+	"Uma", "Victor", "Wanjiru", "Xavier", "Yuki", "Zainab",
+}
+
+var lastNames = []string{
+	"Adeyemi", "Bianchi", "Chen", "Dubois", "Eriksson", "Fernandes", "Garcia", "Hoffmann", "Ibrahim", "Jensen",
+	"Kowalski", "Lopez", "Moreau", "Nakamura", "Okafor", "Petrov", "Rossi", "Schmidt", "Tanaka", "Williams",
+}
@@ -0,0 +1,51 @@
+// Package trigram computes the trigram similarity of pg_trgm for the storages that don't run on
+// PostgreSQL.
+package trigram
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Threshold is the minimum similarity of a fuzzy search, the default pg_trgm.similarity_threshold.
+const Threshold = 0.3
+
+// Similarity returns the pg_trgm similarity of a and b, the number of trigrams they share
+// divided by the number of distinct trigrams of both.
+func Similarity(a, b string) float64 {
+
+	x, y := trigrams(a), trigrams(b)
+	if len(x) == 0 || len(y) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for trigram := range x {
+		if y[trigram] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(x)+len(y)-shared)
+}
+
+// trigrams returns the trigrams of the words of s, each word lower cased and padded with two
+// spaces in front and one behind like pg_trgm does.
+func trigrams(s string) map[string]bool {
+
+	result := map[string]bool{}
+
+	words := strings.FieldsFunc(strings.ToLower(s), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+
+	for _, word := range words {
+		runes := []rune("  " + word + " ")
+
+		for i := 0; i+3 <= len(runes); i++ {
+			result[string(runes[i:i+3])] = true
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,29 @@
+package trigram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{name: "Same words", a: "Alien", b: "ALIEN", want: 1},
+		{name: "Typo", a: "Alien", b: "Alein", want: 2.0 / 10.0},
+		{name: "Extra letter", a: "Aliens", b: "Alien", want: 5.0 / 8.0},
+		{name: "Nothing shared", a: "Heat", b: "Club", want: 0},
+		{name: "No words", a: "Heat", b: "!?", want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Similarity(test.a, test.b); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("want similarity %f; got %f", test.want, got)
+			}
+		})
+	}
+}
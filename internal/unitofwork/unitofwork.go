@@ -0,0 +1,53 @@
+// Package unitofwork lets services run calls to several repositories as a single unit of work,
+// committed or rolled back together.
+package unitofwork
+
+import (
+	"context"
+	"time"
+
+	movie_repository "github.com/terdia/greenlight/src/movies/repositories"
+	user_repository "github.com/terdia/greenlight/src/users/repositories"
+)
+
+// RetryDelay is the wait before the second attempt of a unit of work, it grows linearly with
+// every further attempt.
+const RetryDelay = 10 * time.Millisecond
+
+// Repositories are the repositories of a unit of work, all bound to the same transaction.
+type Repositories struct {
+	Movies      movie_repository.MovieRepository
+	Users       user_repository.UserRepository
+	Tokens      user_repository.TokenRepository
+	Permissions user_repository.PermissionRepository
+}
+
+type UnitOfWork interface {
+	// Do calls fn with repositories bound to a single transaction, the work done by fn is
+	// committed if it returns nil and rolled back otherwise. fn is called again when the
+	// transaction conflicts with a concurrent one, so it must not have side effects besides
+	// the calls to the repositories.
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+
+	// IsRetryable reports whether err is caused by a conflict with a concurrent transaction, fn
+	// must return such an error unchanged for Do to run it again.
+	IsRetryable(err error) bool
+}
+
+// Retry calls fn up to attempts times for as long as retryable reports its error as transient,
+// it gives up early when ctx is done.
+func Retry(ctx context.Context, attempts int, retryable func(err error) bool, fn func() error) error {
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * RetryDelay):
+		}
+	}
+}
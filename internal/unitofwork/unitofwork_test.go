@@ -0,0 +1,87 @@
+package unitofwork
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var (
+	errTransient = errors.New("transient")
+	errPermanent = errors.New("permanent")
+)
+
+func TestRetry(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		errs         []error
+		attempts     int
+		cancelled    bool
+		wantErr      error
+		wantAttempts int
+	}{
+		{
+			name:         "Succeeds at once",
+			errs:         []error{nil},
+			attempts:     3,
+			wantAttempts: 1,
+		},
+		{
+			name:         "Succeeds after transient failures",
+			errs:         []error{errTransient, errTransient, nil},
+			attempts:     3,
+			wantAttempts: 3,
+		},
+		{
+			name:         "Gives up after the last attempt",
+			errs:         []error{errTransient, errTransient, errTransient, nil},
+			attempts:     3,
+			wantErr:      errTransient,
+			wantAttempts: 3,
+		},
+		{
+			name:         "Permanent failure",
+			errs:         []error{errPermanent, nil},
+			attempts:     3,
+			wantErr:      errPermanent,
+			wantAttempts: 1,
+		},
+		{
+			name:         "Cancelled context",
+			errs:         []error{errTransient, nil},
+			attempts:     3,
+			cancelled:    true,
+			wantErr:      errTransient,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if test.cancelled {
+				cancel()
+			}
+
+			calls := 0
+
+			err := Retry(ctx, test.attempts, func(err error) bool {
+				return errors.Is(err, errTransient)
+			}, func() error {
+				calls++
+				return test.errs[calls-1]
+			})
+
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("want error %v; got %v", test.wantErr, err)
+			}
+
+			if calls != test.wantAttempts {
+				t.Errorf("want %d attempts; got %d", test.wantAttempts, calls)
+			}
+		})
+	}
+}
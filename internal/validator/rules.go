@@ -0,0 +1,175 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Rule checks a field against the parameter of its rule in the validate tag, e.g. "500" for
+// max=500, and returns the error message of an invalid field or "" when the field is valid.
+// Pointers are dereferenced before the rule is called.
+type Rule func(field reflect.Value, param string) string
+
+// Rules are rules by name.
+type Rules map[string]Rule
+
+// rules are the built-in rules, other rules are added to a validator with Use.
+var rules = Rules{
+	"required": required,
+	"min":      minimum,
+	"max":      maximum,
+	"len":      length,
+	"unique":   unique,
+	"oneof":    oneOf,
+	"email":    email,
+}
+
+func required(field reflect.Value, param string) string {
+	if !field.IsValid() || field.IsZero() {
+		return "must be provided"
+	}
+
+	return ""
+}
+
+func minimum(field reflect.Value, param string) string {
+
+	switch field.Kind() {
+	case reflect.String:
+		if field.Len() < intParam("min", param) {
+			return fmt.Sprintf("must be at least %s bytes long", param)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if n := intParam("min", param); field.Len() < n {
+			return fmt.Sprintf("must contain at least %s %s", param, plural(n, "element"))
+		}
+	default:
+		if number(field) < floatParam("min", param) {
+			return fmt.Sprintf("must be at least %s", param)
+		}
+	}
+
+	return ""
+}
+
+func maximum(field reflect.Value, param string) string {
+
+	switch field.Kind() {
+	case reflect.String:
+		if field.Len() > intParam("max", param) {
+			return fmt.Sprintf("must not be more than %s bytes long", param)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if n := intParam("max", param); field.Len() > n {
+			return fmt.Sprintf("must not contain more than %s %s", param, plural(n, "element"))
+		}
+	default:
+		if number(field) > floatParam("max", param) {
+			return fmt.Sprintf("must be a maximum of %s", param)
+		}
+	}
+
+	return ""
+}
+
+func length(field reflect.Value, param string) string {
+
+	n := intParam("len", param)
+
+	switch field.Kind() {
+	case reflect.String:
+		if field.Len() != n {
+			return fmt.Sprintf("must be %s bytes long", param)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if field.Len() != n {
+			return fmt.Sprintf("must contain %s %s", param, plural(n, "element"))
+		}
+	default:
+		panic(fmt.Sprintf("validator: len rule on a %s field", field.Kind()))
+	}
+
+	return ""
+}
+
+func unique(field reflect.Value, param string) string {
+
+	seen := make(map[interface{}]bool, field.Len())
+
+	for i := 0; i < field.Len(); i++ {
+		value := field.Index(i).Interface()
+		if seen[value] {
+			return "must not contain duplicate values"
+		}
+		seen[value] = true
+	}
+
+	return ""
+}
+
+func oneOf(field reflect.Value, param string) string {
+
+	values := strings.Fields(param)
+
+	if !In(fmt.Sprint(field.Interface()), values...) {
+		if len(values) == 1 {
+			return fmt.Sprintf("must be %s", values[0])
+		}
+
+		return fmt.Sprintf("must be one of %s or %s", strings.Join(values[:len(values)-1], ", "), values[len(values)-1])
+	}
+
+	return ""
+}
+
+func email(field reflect.Value, param string) string {
+	if !Matches(field.String(), EmailRX) {
+		return "must be a valid email address"
+	}
+
+	return ""
+}
+
+func number(field reflect.Value) float64 {
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(field.Uint())
+	case reflect.Float32, reflect.Float64:
+		return field.Float()
+	default:
+		panic(fmt.Sprintf("validator: number rule on a %s field", field.Kind()))
+	}
+}
+
+func intParam(rule, param string) int {
+
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		panic(fmt.Sprintf("validator: invalid %s parameter %q", rule, param))
+	}
+
+	return n
+}
+
+func floatParam(rule, param string) float64 {
+
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validator: invalid %s parameter %q", rule, param))
+	}
+
+	return n
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return noun
+	}
+
+	return noun + "s"
+}
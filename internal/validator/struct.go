@@ -0,0 +1,167 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Struct checks the fields of a struct against the rules of their validate tag, e.g.
+// `validate:"required,max=500"`, and adds the failed rules of a field under the JSON pointer of
+// its json tag, with the rule name as code. Zero fields are only checked by required and the
+// other rules aren't checked when it fails. Nested structs are checked with their pointer
+// prefixed by the parent one, e.g. /director/name, and the rules after dive are applied to each
+// element of a slice, e.g. /genres/2. The messages tag replaces the messages of the rules of a
+// field, e.g. `messages:"min=must contain at least 1 genre;max=must not contain more than 5 genres"`.
+func (v *Validator) Struct(value interface{}) {
+
+	rv := indirect(reflect.ValueOf(value))
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: Struct called with a %s", rv.Kind()))
+	}
+
+	v.validateStruct(rv, "")
+}
+
+func (v *Validator) validateStruct(rv reflect.Value, prefix string) {
+
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key := fieldKey(field)
+		if key == "-" {
+			continue
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+
+		var fieldRules []string
+		if tag != "" {
+			fieldRules = strings.Split(tag, ",")
+		}
+
+		messages := fieldMessages(field.Tag.Get("messages"))
+
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			v.validateField(indirect(rv.Field(i)), prefix, fieldRules, messages)
+			continue
+		}
+
+		v.validateField(indirect(rv.Field(i)), prefix+"/"+escapePointer(key), fieldRules, messages)
+	}
+}
+
+// validateField checks a field against its rules, messages are the messages of the messages tag
+// by rule name.
+func (v *Validator) validateField(field reflect.Value, pointer string, fieldRules []string, messages map[string]string) {
+
+	for i, rule := range fieldRules {
+		if rule == "dive" {
+			if field.IsValid() && (field.Kind() == reflect.Slice || field.Kind() == reflect.Array) {
+				for j := 0; j < field.Len(); j++ {
+					v.validateField(indirect(field.Index(j)), pointer+"/"+strconv.Itoa(j), fieldRules[i+1:], nil)
+				}
+			}
+			return
+		}
+
+		name, param := rule, ""
+		if j := strings.IndexByte(rule, '='); j >= 0 {
+			name, param = rule[:j], rule[j+1:]
+		}
+
+		if name != "required" && (!field.IsValid() || field.IsZero()) {
+			return
+		}
+
+		check, exists := v.rules[name]
+		if !exists {
+			check, exists = rules[name]
+		}
+
+		if !exists {
+			panic(fmt.Sprintf("validator: unknown rule %q", name))
+		}
+
+		if message := check(field, param); message != "" {
+			if override, exists := messages[name]; exists {
+				message = override
+			}
+
+			err := FieldError{Pointer: pointer, Code: name, Message: message}
+			if param != "" {
+				err.Params = map[string]string{name: param}
+			}
+
+			v.Add(err)
+
+			if name == "required" {
+				return
+			}
+		}
+	}
+
+	if field.IsValid() && field.Kind() == reflect.Struct {
+		v.validateStruct(field, pointer)
+	}
+}
+
+// fieldMessages parses a messages tag, a semicolon separated list of rule=message.
+func fieldMessages(tag string) map[string]string {
+
+	if tag == "" {
+		return nil
+	}
+
+	messages := map[string]string{}
+
+	for _, entry := range strings.Split(tag, ";") {
+		name, message := entry, ""
+		if i := strings.IndexByte(entry, '='); i >= 0 {
+			name, message = entry[:i], entry[i+1:]
+		}
+
+		if message == "" {
+			panic(fmt.Sprintf("validator: invalid messages entry %q, want rule=message", entry))
+		}
+
+		messages[strings.TrimSpace(name)] = message
+	}
+
+	return messages
+}
+
+func fieldKey(field reflect.StructField) string {
+
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+// escapePointer escapes a reference token of a JSON pointer, see RFC 6901.
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+
+	return rv
+}
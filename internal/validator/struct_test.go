@@ -0,0 +1,149 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+)
+
+type structCast struct {
+	Name string `json:"name" validate:"required,max=10"`
+}
+
+type structMovie struct {
+	Title    *string      `json:"title" validate:"required,max=10"`
+	Year     int          `json:"year" validate:"min=1888,max=2100"`
+	Genres   []string     `json:"genres" validate:"required,min=1,max=3,unique,dive,required,oneof=drama comedy"`
+	Format   string       `json:"format,omitempty" validate:"oneof=csv ndjson json"`
+	Director structCast   `json:"director"`
+	Cast     []structCast `json:"cast" validate:"max=2,dive"`
+	Rating   int          `json:"rating" validate:"even"`
+	Runtime  int          `json:"runtime" validate:"min=1" messages:"min=must be a positive integer"`
+	internal string       `validate:"required"`
+}
+
+func TestStruct(t *testing.T) {
+
+	even := Rules{"even": func(field reflect.Value, param string) string {
+		if field.Int()%2 != 0 {
+			return "must be even"
+		}
+		return ""
+	}}
+
+	title, long := "Casablanca", "The Godfather Part II"
+
+	tests := []struct {
+		name  string
+		movie structMovie
+		want  map[string]string
+	}{
+		{
+			name:  "Valid",
+			movie: structMovie{Title: &title, Year: 1942, Genres: []string{"drama"}, Director: structCast{"Curtiz"}, Rating: 8},
+			want:  map[string]string{},
+		},
+		{
+			name:  "Required",
+			movie: structMovie{},
+			want: map[string]string{
+				"title":         "must be provided",
+				"genres":        "must be provided",
+				"director/name": "must be provided",
+			},
+		},
+		{
+			name: "Rules",
+			movie: structMovie{
+				Title:    &long,
+				Year:     1700,
+				Genres:   []string{},
+				Format:   "xml",
+				Director: structCast{"Curtiz"},
+				Rating:   3,
+				Runtime:  -5,
+			},
+			want: map[string]string{
+				"title":   "must not be more than 10 bytes long",
+				"year":    "must be at least 1888",
+				"genres":  "must contain at least 1 element",
+				"format":  "must be one of csv, ndjson or json",
+				"rating":  "must be even",
+				"runtime": "must be a positive integer",
+			},
+		},
+		{
+			name: "Dive",
+			movie: structMovie{
+				Title:    &title,
+				Genres:   []string{"drama", "", "horror"},
+				Director: structCast{"Curtiz"},
+				Cast:     []structCast{{"Bogart"}, {"Ingrid Bergman"}},
+			},
+			want: map[string]string{
+				"genres/1":    "must be provided",
+				"genres/2":    "must be one of drama or comedy",
+				"cast/1/name": "must not be more than 10 bytes long",
+			},
+		},
+		{
+			name: "Unique and max elements",
+			movie: structMovie{
+				Title:    &title,
+				Genres:   []string{"drama", "drama"},
+				Director: structCast{"Curtiz"},
+				Cast:     []structCast{{"Bogart"}, {"Bergman"}, {"Rains"}},
+			},
+			want: map[string]string{
+				"genres": "must not contain duplicate values",
+				"cast":   "must not contain more than 2 elements",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := New().Use(even)
+			v.Struct(test.movie)
+
+			if got := v.Errors.Flat(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("want %v; got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+
+	v := New()
+	v.Struct(struct {
+		Password string   `json:"password" validate:"required,min=8"`
+		Tags     []string `json:"tags" validate:"min=3,unique"`
+	}{Password: "secret", Tags: []string{"a", "a"}})
+
+	v.AddError("tags", "must not contain duplicate values")
+	v.AddError("tags", "is too short")
+
+	want := []FieldError{
+		{Pointer: "/password", Code: "min", Params: map[string]string{"min": "8"}, Message: "must be at least 8 bytes long"},
+		{Pointer: "/tags", Code: "min", Params: map[string]string{"min": "3"}, Message: "must contain at least 3 elements"},
+		{Pointer: "/tags", Code: "unique", Message: "must not contain duplicate values"},
+		{Pointer: "/tags", Code: CodeInvalid, Message: "is too short"},
+	}
+
+	if got := v.Errors.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v; got %+v", want, got)
+	}
+}
+
+func TestStructUnknownRule(t *testing.T) {
+
+	defer func() {
+		if recover() == nil {
+			t.Error("want a panic for a rule the validator doesn't use")
+		}
+	}()
+
+	New().Struct(struct {
+		Rating int `json:"rating" validate:"even"`
+	}{Rating: 3})
+}
@@ -2,18 +2,80 @@ package validator
 
 import (
 	"regexp"
+	"sort"
+	"strings"
 )
 
 var (
 	EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 )
 
+// CodeInvalid is the code of the errors added by Check and AddError.
+const CodeInvalid = "invalid"
+
+// FieldError is a failed check of a field.
+type FieldError struct {
+	Pointer string            `json:"pointer"`          // JSON pointer to the field e.g. /genres/2
+	Code    string            `json:"code"`             // rule that failed e.g. max
+	Params  map[string]string `json:"params,omitempty"` // parameters of the rule e.g. max: 500
+	Message string            `json:"message"`
+}
+
+// Errors are the failed checks by field key, the key of a field is its JSON pointer without
+// the leading slash e.g. title or genres/2.
+type Errors map[string][]FieldError
+
+// Flat returns the first message of every field.
+func (errs Errors) Flat() map[string]string {
+
+	flat := make(map[string]string, len(errs))
+	for key, fieldErrors := range errs {
+		flat[key] = fieldErrors[0].Message
+	}
+
+	return flat
+}
+
+// List returns the failed checks sorted by field, in the order they were added for a field.
+func (errs Errors) List() []FieldError {
+
+	keys := make([]string, 0, len(errs))
+	for key := range errs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var list []FieldError
+	for _, key := range keys {
+		list = append(list, errs[key]...)
+	}
+
+	return list
+}
+
 type Validator struct {
-	Errors map[string]string
+	Errors Errors
+	rules  Rules
 }
 
 func New() *Validator {
-	return &Validator{Errors: make(map[string]string)}
+	return &Validator{Errors: make(Errors)}
+}
+
+// Use adds rules to the ones Struct checks, e.g. the rules of the domain, and returns the
+// validator. A rule replaces the built-in rule of the same name.
+func (v *Validator) Use(rules Rules) *Validator {
+
+	if v.rules == nil {
+		v.rules = make(Rules, len(rules))
+	}
+
+	for name, rule := range rules {
+		v.rules[name] = rule
+	}
+
+	return v
 }
 
 func (v *Validator) Valid() bool {
@@ -21,9 +83,22 @@ func (v *Validator) Valid() bool {
 }
 
 func (v *Validator) AddError(key, message string) {
-	if _, exists := v.Errors[key]; !exists {
-		v.Errors[key] = message
+	v.Add(FieldError{Pointer: "/" + key, Code: CodeInvalid, Message: message})
+}
+
+// Add adds a failed check under the key of its pointer, a message already reported for the
+// field is ignored.
+func (v *Validator) Add(err FieldError) {
+
+	key := strings.TrimPrefix(err.Pointer, "/")
+
+	for _, existing := range v.Errors[key] {
+		if existing.Message == err.Message {
+			return
+		}
 	}
+
+	v.Errors[key] = append(v.Errors[key], err)
 }
 
 func (v *Validator) Check(ok bool, key, message string) {
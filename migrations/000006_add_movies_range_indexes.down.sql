@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS movies_year_idx;
+DROP INDEX IF EXISTS movies_runtime_idx;
+DROP INDEX IF EXISTS movies_created_at_idx;
@@ -0,0 +1,3 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS language regconfig NOT NULL DEFAULT 'simple';
+DROP INDEX IF EXISTS movies_title_idx;
+CREATE INDEX IF NOT EXISTS movies_title_language_idx ON movies USING GIN (to_tsvector(language, title));
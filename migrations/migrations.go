@@ -0,0 +1,10 @@
+// Package migrations holds the migrations of the PostgreSQL storage, embedded in the binary
+// which applies them with the migrate subcommand or on startup with -auto-migrate.
+package migrations
+
+import "embed"
+
+// FS holds the migration files.
+//
+//go:embed *.sql
+var FS embed.FS
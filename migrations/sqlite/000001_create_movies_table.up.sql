@@ -0,0 +1,14 @@
+-- genres is a JSON array of strings standing in for the text[] column of PostgreSQL.
+CREATE TABLE IF NOT EXISTS movies (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    title text NOT NULL,
+    year integer NOT NULL,
+    runtime integer NOT NULL,
+    genres text NOT NULL,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT movies_runtime_check CHECK (runtime >= 0),
+    -- the current year can't be used in a CHECK constraint, it is left to validation
+    CONSTRAINT movies_year_check CHECK (year >= 1888),
+    CONSTRAINT genres_length_check CHECK (json_valid(genres) AND json_array_length(genres) BETWEEN 1 AND 5)
+);
@@ -0,0 +1,18 @@
+-- movies_fts indexes the titles for fulltext and prefix search, it stands in for the GIN index
+-- of PostgreSQL and is kept in sync with movies by triggers. There is no index on the genres.
+CREATE VIRTUAL TABLE IF NOT EXISTS movies_fts USING fts5(title, content='movies', content_rowid='id', tokenize='unicode61 remove_diacritics 2');
+
+CREATE TRIGGER IF NOT EXISTS movies_fts_insert AFTER INSERT ON movies BEGIN
+    INSERT INTO movies_fts (rowid, title) VALUES (new.id, new.title);
+END;
+
+CREATE TRIGGER IF NOT EXISTS movies_fts_delete AFTER DELETE ON movies BEGIN
+    INSERT INTO movies_fts (movies_fts, rowid, title) VALUES ('delete', old.id, old.title);
+END;
+
+CREATE TRIGGER IF NOT EXISTS movies_fts_update AFTER UPDATE OF title ON movies BEGIN
+    INSERT INTO movies_fts (movies_fts, rowid, title) VALUES ('delete', old.id, old.title);
+    INSERT INTO movies_fts (rowid, title) VALUES (new.id, new.title);
+END;
+
+INSERT INTO movies_fts (movies_fts) VALUES ('rebuild');
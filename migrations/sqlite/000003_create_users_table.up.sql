@@ -0,0 +1,11 @@
+-- NOCASE stands in for citext, it only folds the case of ASCII letters.
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    name text NOT NULL,
+    email text NOT NULL COLLATE NOCASE,
+    password_hash blob NOT NULL,
+    activated boolean NOT NULL,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT users_email_key UNIQUE (email)
+);
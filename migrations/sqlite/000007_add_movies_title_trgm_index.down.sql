@@ -0,0 +1,2 @@
+-- Fuzzy search compares the titles with the similarity function registered on every connection,
+-- SQLite has no trigram index to speed it up.
@@ -0,0 +1,3 @@
+-- The language of a movie is stored for the API only, FTS5 has a single tokenizer that doesn't
+-- stem, so titles are searched like the 'simple' configuration of PostgreSQL.
+ALTER TABLE movies ADD COLUMN language text NOT NULL DEFAULT 'simple';
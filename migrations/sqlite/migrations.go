@@ -0,0 +1,10 @@
+// Package sqlite holds the migrations of the SQLite storage, the equivalent of the PostgreSQL
+// migrations of the parent directory with the same versions.
+package sqlite
+
+import "embed"
+
+// FS holds the migration files.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -1,6 +1,8 @@
 package mock
 
 import (
+	"context"
+
 	"github.com/terdia/greenlight/infrastructures/dto"
 	"github.com/terdia/greenlight/internal/data"
 	"github.com/terdia/greenlight/src/movies/entities"
@@ -15,11 +17,11 @@ func NewMovieRepoitoryMock(totalRecords int) repositories.MovieRepository {
 	return &movieRepositoryMock{totalRecords: totalRecords}
 }
 
-func (repo *movieRepositoryMock) Insert(movie *entities.Movie) error {
+func (repo *movieRepositoryMock) Insert(ctx context.Context, movie *entities.Movie) error {
 	return nil
 }
 
-func (repo *movieRepositoryMock) Get(id int64) (*entities.Movie, error) {
+func (repo *movieRepositoryMock) Get(ctx context.Context, id int64) (*entities.Movie, error) {
 
 	if id < 1 {
 		return nil, data.ErrRecordNotFound
@@ -30,11 +32,19 @@ func (repo *movieRepositoryMock) Get(id int64) (*entities.Movie, error) {
 	return &movie, nil
 }
 
-func (repo *movieRepositoryMock) Update(movie *entities.Movie) error {
+func (repo *movieRepositoryMock) Export(ctx context.Context, r dto.ListMovieRequest, fn func(movie *entities.Movie) error) error {
 	return nil
 }
 
-func (repo *movieRepositoryMock) Delete(id int64) error {
+func (repo *movieRepositoryMock) CopyFrom(ctx context.Context, movies []*entities.Movie) (int64, error) {
+	return int64(len(movies)), nil
+}
+
+func (repo *movieRepositoryMock) Update(ctx context.Context, movie *entities.Movie) error {
+	return nil
+}
+
+func (repo *movieRepositoryMock) Delete(ctx context.Context, id int64) error {
 	if id < 1 {
 		return data.ErrRecordNotFound
 	}
@@ -42,7 +52,7 @@ func (repo *movieRepositoryMock) Delete(id int64) error {
 	return nil
 }
 
-func (repo *movieRepositoryMock) GetAll(r dto.ListMovieRequest) ([]*entities.Movie, data.Metadata, error) {
+func (repo *movieRepositoryMock) GetAll(ctx context.Context, r dto.ListMovieRequest) ([]*entities.Movie, data.Metadata, error) {
 
 	filters := r.Filters
 
@@ -52,3 +62,18 @@ func (repo *movieRepositoryMock) GetAll(r dto.ListMovieRequest) ([]*entities.Mov
 
 	return movies, metadata, nil
 }
+
+func (repo *movieRepositoryMock) Facets(ctx context.Context, r dto.ListMovieRequest, facets []string) (data.Facets, error) {
+
+	result := data.Facets{}
+
+	for _, facet := range facets {
+		result[facet] = []data.FacetCount{}
+	}
+
+	return result, nil
+}
+
+func (repo *movieRepositoryMock) WithinTransaction(ctx context.Context, fn func(repo repositories.MovieRepository) error) error {
+	return fn(repo)
+}
@@ -1,6 +1,8 @@
 package mock
 
 import (
+	"context"
+
 	"github.com/terdia/greenlight/internal/custom_type"
 	"github.com/terdia/greenlight/internal/data"
 	"github.com/terdia/greenlight/src/users/repositories"
@@ -12,14 +14,19 @@ func NewPermissionRepositoryMock() repositories.PermissionRepository {
 	return &permissionRepositoryMock{}
 }
 
-func (p *permissionRepositoryMock) GetAllForUser(userID custom_type.ID) (data.Permissions, error) {
+func (p *permissionRepositoryMock) GetAllForUser(ctx context.Context, userID custom_type.ID) (data.Permissions, error) {
 
 	var permissions data.Permissions
 
 	return permissions, nil
 }
 
-func (p *permissionRepositoryMock) AddForUser(userID custom_type.ID, codes ...string) error {
+func (p *permissionRepositoryMock) AddForUser(ctx context.Context, userID custom_type.ID, codes ...string) error {
+
+	return nil
+}
+
+func (p *permissionRepositoryMock) RemoveForUser(ctx context.Context, userID custom_type.ID, codes ...string) error {
 
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/terdia/greenlight/internal/commons"
 	"github.com/terdia/greenlight/internal/mailer"
 	"github.com/terdia/greenlight/internal/registry"
+	"github.com/terdia/greenlight/internal/unitofwork"
 	"github.com/terdia/greenlight/src/movies/handlers"
 	"github.com/terdia/greenlight/src/movies/services"
 	user_handler "github.com/terdia/greenlight/src/users/handlers"
@@ -24,23 +25,35 @@ func NewRegistry(logger *logger.Logger, mailer mailer.Mailer, wg *sync.WaitGroup
 
 	userRepository := NewUserRepoitoryMock()
 	permissionRepository := NewPermissionRepositoryMock()
+	movieRepository := NewMovieRepoitoryMock(movieCount)
+	tokenRepository := NewTokenRepositoryMock()
+
+	uow := NewUnitOfWorkMock(unitofwork.Repositories{
+		Movies:      movieRepository,
+		Users:       userRepository,
+		Tokens:      tokenRepository,
+		Permissions: permissionRepository,
+	})
 
 	utils := commons.NewUtil(logger, wg)
-	movieService := services.NewMovieService(NewMovieRepoitoryMock(movieCount))
+	movieService := services.NewMovieService(movieRepository, uow)
 
-	tokenService := user_services.NewTokenService(NewTokenRepositoryMock())
+	tokenService := user_services.NewTokenService(tokenRepository)
 
 	userService := user_services.NewUserService(
 		userRepository,
 		user_services.NewPasswordService(),
 		mailer,
 		tokenService,
+		uow,
 	)
 
-	services := newServices(utils, userService, userRepository, permissionRepository)
+	adminService := user_services.NewAdminService(userRepository, tokenRepository, user_services.NewPasswordService(), uow)
+
+	services := newServices(utils, movieService, userService, adminService, userRepository, permissionRepository)
 
 	movieHandler := handlers.NewMovieHandler(utils, movieService)
-	userHandler := user_handler.NewUserHandler(utils, userService, tokenService, permissionRepository)
+	userHandler := user_handler.NewUserHandler(utils, userService)
 
 	handlers := newHandlers(movieHandler, userHandler)
 
@@ -52,13 +65,17 @@ func NewRegistry(logger *logger.Logger, mailer mailer.Mailer, wg *sync.WaitGroup
 
 func newServices(
 	sharedUtil commons.SharedUtil,
+	movieService services.MovieService,
 	userService user_services.UserService,
+	adminService user_services.AdminService,
 	userRepository user_repository.UserRepository,
 	permissionRepository user_repository.PermissionRepository,
 ) *registry.Services {
 	return &registry.Services{
 		SharedUtil:           sharedUtil,
+		MovieService:         movieService,
 		UserService:          userService,
+		AdminService:         adminService,
 		UserRepository:       userRepository,
 		PermissionRepository: permissionRepository,
 	}
@@ -1,6 +1,8 @@
 package mock
 
 import (
+	"context"
+
 	"github.com/terdia/greenlight/internal/custom_type"
 	"github.com/terdia/greenlight/src/users/entities"
 	tr "github.com/terdia/greenlight/src/users/repositories"
@@ -12,12 +14,22 @@ func NewTokenRepositoryMock() tr.TokenRepository {
 	return &tokenRepositoryMock{}
 }
 
-func (repo *tokenRepositoryMock) Create(token *entities.Token) error {
+func (repo *tokenRepositoryMock) Create(ctx context.Context, token *entities.Token) error {
 
 	return nil
 }
 
-func (repo *tokenRepositoryMock) DeleteAllForUserByScope(scope string, userID custom_type.ID) error {
+func (repo *tokenRepositoryMock) DeleteAllForUserByScope(ctx context.Context, scope string, userID custom_type.ID) error {
 
 	return nil
 }
+
+func (repo *tokenRepositoryMock) DeleteExpired(ctx context.Context) (int64, error) {
+
+	return 0, nil
+}
+
+func (repo *tokenRepositoryMock) Count(ctx context.Context) (entities.TokenCounts, error) {
+
+	return entities.TokenCounts{Active: map[string]int{}}, nil
+}
@@ -0,0 +1,23 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/terdia/greenlight/internal/unitofwork"
+)
+
+type unitOfWorkMock struct {
+	repos unitofwork.Repositories
+}
+
+func NewUnitOfWorkMock(repos unitofwork.Repositories) unitofwork.UnitOfWork {
+	return &unitOfWorkMock{repos: repos}
+}
+
+func (uow *unitOfWorkMock) Do(ctx context.Context, fn func(repos unitofwork.Repositories) error) error {
+	return fn(uow.repos)
+}
+
+func (uow *unitOfWorkMock) IsRetryable(err error) bool {
+	return false
+}
@@ -1,6 +1,8 @@
 package mock
 
 import (
+	"context"
+
 	"github.com/terdia/greenlight/src/users/entities"
 	"github.com/terdia/greenlight/src/users/repositories"
 )
@@ -11,27 +13,32 @@ func NewUserRepoitoryMock() repositories.UserRepository {
 	return &userRepositoryMock{}
 }
 
-func (repo *userRepositoryMock) Insert(user *entities.User) error {
+func (repo *userRepositoryMock) Insert(ctx context.Context, user *entities.User) error {
 
 	return nil
 }
 
-func (repo *userRepositoryMock) GetByEmail(email string) (*entities.User, error) {
+func (repo *userRepositoryMock) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
 
 	var user entities.User
 
 	return &user, nil
 }
 
-func (repo *userRepositoryMock) Update(user *entities.User) error {
+func (repo *userRepositoryMock) Update(ctx context.Context, user *entities.User) error {
 
 	return nil
 }
 
-func (repo *userRepositoryMock) GetForToken(tokenPlainText, scope string) (*entities.User, error) {
+func (repo *userRepositoryMock) GetForToken(ctx context.Context, tokenPlainText, scope string) (*entities.User, error) {
 
 	var user entities.User
 
 	return &user, nil
 
 }
+
+func (repo *userRepositoryMock) Count(ctx context.Context) (entities.UserCounts, error) {
+
+	return entities.UserCounts{}, nil
+}
@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/terdia/greenlight/internal/custom_type"
+)
+
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+type ImportJob struct {
+	ID         custom_type.ID
+	Format     string
+	DryRun     bool
+	Status     string
+	Processed  int
+	Inserted   int
+	Invalid    int
+	Errors     []ImportError
+	Message    string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// ImportError holds the validation errors of a single line of the imported file.
+type ImportError struct {
+	Line   int
+	Errors map[string]string
+}
+
+func (job *ImportJob) Finished() bool {
+	return job.Status == ImportStatusCompleted || job.Status == ImportStatusFailed
+}
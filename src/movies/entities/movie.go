@@ -12,6 +12,8 @@ type Movie struct {
 	Year      int32
 	Runtime   custom_type.Runtime
 	Genres    []string
+	Language  string // text search configuration of the title e.g. english
 	Version   int32
 	CreatedAt time.Time
+	Highlight string // title with the words matching the title search marked, set by searches only
 }
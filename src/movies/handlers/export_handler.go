@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/validator"
+	"github.com/terdia/greenlight/src/movies/entities"
+)
+
+const (
+	exportBufferSize = 32 * 1024
+
+	// exportWriteTimeout bounds the write of each chunk of an export, the export itself has no
+	// deadline so a catalogue of any size reaches a client reading it steadily.
+	exportWriteTimeout = 30 * time.Second
+)
+
+// ExportMovies ... Export movies
+// @Summary Export the movie catalogue
+// @Description stream every movie matching the ListMovie filters ordered by id, as CSV (id,title,year,runtime,genres,language),
+// @Description one JSON object per line (ndjson) or a single JSON document (json)
+// @Tags Movies
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "export format" Enums(csv, ndjson, json) default(json)
+// @Param title query string false "full text search by movie title"
+// @Param search_mode query string false "fulltext matches whole words, prefix partial words and fuzzy tolerates typos" Enums(fulltext, prefix, fuzzy) default(fulltext)
+// @Param language query string false "only movies in the language e.g. english"
+// @Param genres query string false "command seperated list e.g. crime,drama"
+// @Param genres_mode query string false "match movies with all, any or none of the genres" Enums(all, any, none) default(all)
+// @Param year_min query integer false "minimum year, inclusive"
+// @Param year_max query integer false "maximum year, inclusive"
+// @Param runtime_min query integer false "minimum runtime in minutes, inclusive"
+// @Param runtime_max query integer false "maximum runtime in minutes, inclusive"
+// @Param created_after query string false "RFC 3339 timestamp or date e.g. 2021-10-01"
+// @Param created_before query string false "RFC 3339 timestamp or date e.g. 2021-10-01"
+// @Param filter query string false "filter expression on title, year, runtime, genres, language and created_at e.g. year>=2000 and runtime<120"
+// @Param Authorization header string true "Authorization: Bearer XXSGGSSHHSSJSJSSS"
+// @Success 200 {object} commons.ResponseObject{data=dto.ExportMovieResponse}
+// @Failure 422 {object} commons.ResponseObject{data=dto.ValidationError} "status: fail"
+// @Failure 401,403,500 {object} commons.ResponseObject "e.g. status: error, message: the error reason"
+// @Router /movies/export [get]
+func (handler *movieHandler) ExportMovies(rw http.ResponseWriter, r *http.Request) {
+	util := handler.sharedUtil
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	format := util.ReadString(qs, "format", "json")
+	listMoviesRequest := handler.readMovieFilters(qs, v)
+
+	v.Check(validator.In(format, "csv", "ndjson", "json"), "format", "must be one of csv, ndjson or json")
+	if !v.Valid() {
+		util.FailedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	buf := bufio.NewWriterSize(&deadlineWriter{writer: rw, rc: http.NewResponseController(rw)}, exportBufferSize)
+
+	var exporter movieExporter
+	switch format {
+	case "csv":
+		rw.Header().Set("Content-Type", "text/csv")
+		exporter = &csvMovieExporter{writer: csv.NewWriter(buf)}
+	case "ndjson":
+		rw.Header().Set("Content-Type", "application/x-ndjson")
+		exporter = &ndjsonMovieExporter{encoder: json.NewEncoder(buf)}
+	default:
+		rw.Header().Set("Content-Type", "application/json")
+		exporter = &jsonMovieExporter{writer: buf}
+	}
+
+	rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="movies.%s"`, format))
+
+	started := false
+
+	err := handler.service.Export(r.Context(), listMoviesRequest, func(movie *entities.Movie) error {
+		if !started {
+			started = true
+			rw.WriteHeader(http.StatusOK)
+
+			if err := exporter.begin(); err != nil {
+				return err
+			}
+		}
+
+		return exporter.write(getMovieResponse(movie))
+	})
+
+	if err == nil && !started {
+		rw.WriteHeader(http.StatusOK)
+		started = true
+		err = exporter.begin()
+	}
+
+	if err == nil {
+		err = exporter.end()
+	}
+
+	if err == nil {
+		err = buf.Flush()
+	}
+
+	if err != nil {
+		// Once the first row has been written the status code can't be changed anymore,
+		// the connection is closed so the client sees a truncated export.
+		if !started {
+			rw.Header().Del("Content-Disposition")
+			util.ServerErrorResponse(rw, r, err)
+			return
+		}
+
+		util.LogErrorWithHttpRequestContext(r, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// deadlineWriter pushes the write deadline of the connection forward before each chunk is
+// written, so the WriteTimeout of the server only stops an export whose client stalls.
+type deadlineWriter struct {
+	writer io.Writer
+	rc     *http.ResponseController
+}
+
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+
+	err := w.rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return 0, err
+	}
+
+	return w.writer.Write(p)
+}
+
+type movieExporter interface {
+	begin() error
+	write(movie dto.MovieResponse) error
+	end() error
+}
+
+type csvMovieExporter struct {
+	writer *csv.Writer
+}
+
+func (e *csvMovieExporter) begin() error {
+	return e.writer.Write([]string{"id", "title", "year", "runtime", "genres", "language"})
+}
+
+func (e *csvMovieExporter) write(movie dto.MovieResponse) error {
+
+	id, err := custom_type.EncodeId(int(movie.ID))
+	if err != nil {
+		return err
+	}
+
+	return e.writer.Write([]string{
+		id,
+		movie.Title,
+		strconv.Itoa(int(movie.Year)),
+		strconv.Itoa(int(movie.Runtime)),
+		strings.Join(movie.Genres, ","),
+		movie.Language,
+	})
+}
+
+func (e *csvMovieExporter) end() error {
+	e.writer.Flush()
+
+	return e.writer.Error()
+}
+
+type ndjsonMovieExporter struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonMovieExporter) begin() error {
+	return nil
+}
+
+func (e *ndjsonMovieExporter) write(movie dto.MovieResponse) error {
+	return e.encoder.Encode(movie)
+}
+
+func (e *ndjsonMovieExporter) end() error {
+	return nil
+}
+
+// jsonMovieExporter writes the movies as one JSend document, the array is written element
+// by element so the document is never held in memory.
+type jsonMovieExporter struct {
+	writer io.Writer
+	count  int
+}
+
+func (e *jsonMovieExporter) begin() error {
+	_, err := io.WriteString(e.writer, `{"status":"success","data":{"movies":[`)
+
+	return err
+}
+
+func (e *jsonMovieExporter) write(movie dto.MovieResponse) error {
+
+	js, err := json.Marshal(movie)
+	if err != nil {
+		return err
+	}
+
+	if e.count > 0 {
+		js = append([]byte{','}, js...)
+	}
+	e.count++
+
+	_, err = e.writer.Write(js)
+
+	return err
+}
+
+func (e *jsonMovieExporter) end() error {
+	_, err := io.WriteString(e.writer, "]}}\n")
+
+	return err
+}
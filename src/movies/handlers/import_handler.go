@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/commons"
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/validator"
+	"github.com/terdia/greenlight/src/movies/entities"
+)
+
+const (
+	maxImportBytes = 100 << 20
+)
+
+var errImportTooLarge = fmt.Errorf("body must not be larger than %d bytes", maxImportBytes)
+
+// ImportMovies ... Bulk import movies
+// @Summary Bulk import movies from a CSV or NDJSON file
+// @Description upload a CSV file (title,year,runtime,genres header) or one movie JSON object per line. The file is imported
+// @Description asynchronously in a single transaction, poll the returned import job for progress and line numbered errors.
+// @Tags Movies
+// @Accept plain
+// @Param format query string false "file format, detected from the Content-Type (text/csv, application/x-ndjson) by default" Enums(csv, ndjson)
+// @Param dry_run query boolean false "validate the file without importing any movie" default(false)
+// @Param Authorization header string true "Authorization: Bearer XXSGGSSHHSSJSJSSS"
+// @Success 202 {object} commons.ResponseObject{data=dto.ImportJobResponse}
+// @Header 202 {string} Location "/v1/movies/imports/QbPy4B7a2Lw1Kg7ogoEWj9k3NGMRVY"
+// @Failure 422 {object} commons.ResponseObject{data=dto.ValidationError} "status: fail"
+// @Failure 400,401,403,500 {object} commons.ResponseObject "e.g. status: error, message: the error reason"
+// @Router /movies/imports [post]
+func (handler *movieHandler) ImportMovies(rw http.ResponseWriter, r *http.Request) {
+	util := handler.sharedUtil
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	format := util.ReadString(qs, "format", importFormatFromContentType(r))
+	dryRun := util.ReadBool(qs, "dry_run", false, v)
+
+	v.Check(validator.In(format, entities.ImportFormatCSV, entities.ImportFormatNDJSON), "format", "must be one of csv or ndjson")
+	if !v.Valid() {
+		util.FailedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	// The import runs after the response has been sent, so the body is spooled to a
+	// temporary file first.
+	file, err := os.CreateTemp("", "greenlight-import-*")
+	if err != nil {
+		util.ServerErrorResponse(rw, r, err)
+		return
+	}
+
+	// one byte more than the limit is read to tell a body of the maximum size from a larger one
+	written, err := io.Copy(file, io.LimitReader(r.Body, maxImportBytes+1))
+	if err == nil && written > maxImportBytes {
+		err = errImportTooLarge
+	}
+
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+
+		if errors.Is(err, errImportTooLarge) {
+			util.BadRequestResponse(rw, r, err)
+			return
+		}
+
+		util.ServerErrorResponse(rw, r, err)
+		return
+	}
+
+	job := handler.service.CreateImportJob(format, dryRun)
+	idString, _ := custom_type.EncodeId(int(job.ID))
+
+	util.Background(func() {
+		defer os.Remove(file.Name())
+		defer file.Close()
+
+		err := handler.service.RunImport(context.Background(), job.ID, file)
+		if err != nil {
+			util.LogErrorWithContext(err, map[string]string{
+				"task":  "movie import goroutine",
+				"jobId": idString,
+			})
+		}
+	})
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/movies/imports/%s", idString))
+
+	err = util.WriteResponse(rw, r, http.StatusAccepted, commons.ResponseObject{
+		StatusMsg: custom_type.Success,
+		Data: dto.ImportJobResponse{
+			Job: getImportJobResponse(job),
+		},
+	}, headers)
+
+	if err != nil {
+		util.ServerErrorResponse(rw, r, err)
+
+		return
+	}
+}
+
+// ShowImportJob ... Show import job
+// @Summary Show the progress of a movie import
+// @Description show the status, progress and line numbered errors of an import job
+// @Tags Movies
+// @Param id path string true "Id of the import job"
+// @Param Authorization header string true "Authorization: Bearer XXSGGSSHHSSJSJSSS"
+// @Success 200 {object} commons.ResponseObject{data=dto.ImportJobResponse}
+// @Failure 401,403,404,500 {object} commons.ResponseObject "e.g. status: error, message: the error reason"
+// @Router /movies/imports/{id} [get]
+func (handler *movieHandler) ShowImportJob(rw http.ResponseWriter, r *http.Request) {
+
+	id, err := handler.sharedUtil.ExtractIdParamFromContext(r)
+	if err != nil {
+		handler.sharedUtil.NotFoundResponse(rw, r)
+
+		return
+	}
+
+	job, err := handler.service.GetImportJob(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			handler.sharedUtil.NotFoundResponse(rw, r)
+		default:
+			handler.sharedUtil.ServerErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	err = handler.sharedUtil.WriteResponse(rw, r, http.StatusOK, commons.ResponseObject{
+		StatusMsg: custom_type.Success,
+		Data: dto.ImportJobResponse{
+			Job: getImportJobResponse(job),
+		},
+	}, nil)
+
+	if err != nil {
+		handler.sharedUtil.ServerErrorResponse(rw, r, err)
+
+		return
+	}
+}
+
+func importFormatFromContentType(r *http.Request) string {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "text/csv":
+		return entities.ImportFormatCSV
+	case "application/x-ndjson", "application/jsonl":
+		return entities.ImportFormatNDJSON
+	default:
+		return ""
+	}
+}
+
+func getImportJobResponse(job *entities.ImportJob) dto.ImportJob {
+	response := dto.ImportJob{
+		ID:        job.ID,
+		Format:    job.Format,
+		DryRun:    job.DryRun,
+		Status:    job.Status,
+		Processed: job.Processed,
+		Inserted:  job.Inserted,
+		Invalid:   job.Invalid,
+		Message:   job.Message,
+		CreatedAt: job.CreatedAt,
+	}
+
+	for _, lineError := range job.Errors {
+		response.Errors = append(response.Errors, dto.ImportLineError{
+			Line:   lineError.Line,
+			Errors: lineError.Errors,
+		})
+	}
+
+	if job.Finished() {
+		response.FinishedAt = &job.FinishedAt
+	}
+
+	return response
+}
@@ -1,14 +1,22 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/terdia/greenlight/infrastructures/dto"
 	"github.com/terdia/greenlight/internal/commons"
 	"github.com/terdia/greenlight/internal/custom_type"
 	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/filter"
+	"github.com/terdia/greenlight/internal/patch"
 	"github.com/terdia/greenlight/internal/validator"
 	"github.com/terdia/greenlight/src/movies/entities"
 	"github.com/terdia/greenlight/src/movies/services"
@@ -18,8 +26,14 @@ type MovieHandle interface {
 	CreateMovie(rw http.ResponseWriter, r *http.Request)
 	ShowMovie(rw http.ResponseWriter, r *http.Request)
 	UpdateMovie(rw http.ResponseWriter, r *http.Request)
+	ReplaceMovie(rw http.ResponseWriter, r *http.Request)
 	DeleteMovie(rw http.ResponseWriter, r *http.Request)
 	ListMovie(rw http.ResponseWriter, r *http.Request)
+	BatchMovies(rw http.ResponseWriter, r *http.Request)
+	ExportMovies(rw http.ResponseWriter, r *http.Request)
+	ImportMovies(rw http.ResponseWriter, r *http.Request)
+	ShowImportJob(rw http.ResponseWriter, r *http.Request)
+	SuggestMovies(rw http.ResponseWriter, r *http.Request)
 }
 
 type movieHandler struct {
@@ -62,7 +76,11 @@ func (handler *movieHandler) CreateMovie(rw http.ResponseWriter, r *http.Request
 		Genres:  input.Genres,
 	}
 
-	validationErrors, err := handler.service.Create(movie)
+	if input.Language != nil {
+		movie.Language = *input.Language
+	}
+
+	validationErrors, err := handler.service.Create(r.Context(), movie)
 	if validationErrors != nil {
 		handler.sharedUtil.FailedValidationResponse(rw, r, validationErrors)
 
@@ -85,7 +103,7 @@ func (handler *movieHandler) CreateMovie(rw http.ResponseWriter, r *http.Request
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/movies/%s", idString))
 
-	err = handler.sharedUtil.WriteJson(rw, http.StatusCreated, result, headers)
+	err = handler.sharedUtil.WriteResponse(rw, r, http.StatusCreated, result, headers)
 	if err != nil {
 		handler.sharedUtil.ServerErrorResponse(rw, r, err)
 
@@ -98,8 +116,11 @@ func (handler *movieHandler) CreateMovie(rw http.ResponseWriter, r *http.Request
 // @Description show details of a given movie
 // @Tags Movies
 // @Param id path string false "Id of the movie to show"
+// @Param fields query string false "comma seperated list of the movie fields to return e.g. title,year, the id is always returned"
+// @Param include query string false "related resources to embed, similar: up to 5 movies sharing a genre" Enums(similar)
 // @Param Authorization header string true "Authorization: Bearer XXSGGSSHHSSJSJSSS"
 // @Success 200 {object} commons.ResponseObject{data=dto.SingleMovieResponse}
+// @Failure 422 {object} commons.ResponseObject{data=dto.ValidationError} "status: fail"
 // @Failure 400,401,403,500 {object} commons.ResponseObject "e.g. status: error, message: the error reason"
 // @Router /movies/{id} [get]
 func (handler *movieHandler) ShowMovie(rw http.ResponseWriter, r *http.Request) {
@@ -111,7 +132,15 @@ func (handler *movieHandler) ShowMovie(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	movie, err := handler.service.GetById(id)
+	v := validator.New()
+
+	shape := handler.sharedUtil.ReadShape(r.URL.Query(), dto.MovieResponse{}, []string{"similar"}, v)
+	if !v.Valid() {
+		handler.sharedUtil.FailedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	movie, err := handler.service.GetById(r.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -122,14 +151,33 @@ func (handler *movieHandler) ShowMovie(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	movieResponse := getMovieResponse(movie)
+
+	if shape.Include("similar") {
+		similar, err := handler.service.Similar(r.Context(), movie, 5)
+		if err != nil {
+			handler.sharedUtil.ServerErrorResponse(rw, r, err)
+			return
+		}
+
+		movieResponse.Similar = []dto.MovieResponse{}
+		for _, movie := range similar {
+			movieResponse.Similar = append(movieResponse.Similar, getMovieResponse(movie))
+		}
+	}
+
+	shaped, err := shape.Apply(dto.SingleMovieResponse{Movie: movieResponse}, "movie")
+	if err != nil {
+		handler.sharedUtil.ServerErrorResponse(rw, r, err)
+		return
+	}
+
 	result := commons.ResponseObject{
 		StatusMsg: custom_type.Success,
-		Data: dto.SingleMovieResponse{
-			Movie: getMovieResponse(movie),
-		},
+		Data:      shaped,
 	}
 
-	err = handler.sharedUtil.WriteJson(rw, http.StatusOK, result, nil)
+	err = handler.sharedUtil.WriteResponse(rw, r, http.StatusOK, result, nil)
 	if err != nil {
 		handler.sharedUtil.ServerErrorResponse(rw, r, err)
 
@@ -139,16 +187,20 @@ func (handler *movieHandler) ShowMovie(rw http.ResponseWriter, r *http.Request)
 
 // UpdateMovie ... Update movie
 // @Summary Update a given movie
-// @Description update movie with given details
+// @Description update movie with given details, the body is either a partial movie (application/json),
+// @Description a JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json)
 // @Tags Movies
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
 // @Param id path string true "Id of the movie to update"
-// @Param body body dto.MovieRequest false "Update movie request"
+// @Param body body dto.UpdateMovieRequest false "Update movie request"
 // @Param Authorization header string true "Authorization: Bearer XXSGGSSHHSSJSJSSS"
 // @Success 200 {object} commons.ResponseObject{data=dto.SingleMovieResponse}
 // @Header 200 {string} Location "/v1/movies/QbPy4B7a2Lw1Kg7ogoEWj9k3NGMRVY"
 // @Failure 409 {object} commons.ResponseObject "e.g. status: error, message: unable to update the record due to an edit conflict, please try again"
 // @Failure 422 {object} commons.ResponseObject{data=dto.ValidationError} "status: fail"
-// @Failure 400,401,403,404,500 {object} commons.ResponseObject "e.g. status: error, message: the error reason"
+// @Failure 400,401,403,404,415,500 {object} commons.ResponseObject "e.g. status: error, message: the error reason"
 // @Router /movies/{id} [patch]
 func (handler *movieHandler) UpdateMovie(rw http.ResponseWriter, r *http.Request) {
 
@@ -159,6 +211,78 @@ func (handler *movieHandler) UpdateMovie(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
+	var (
+		movie            *entities.Movie
+		validationErrors services.MovieValidationErrors
+	)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "", "application/json":
+		var input dto.UpdateMovieRequest
+		err = handler.sharedUtil.ReadJson(rw, r, &input)
+		if err != nil {
+			handler.sharedUtil.BadRequestResponse(rw, r, err)
+
+			return
+		}
+
+		movie, validationErrors, err = handler.service.Update(r.Context(), id, dto.MovieRequest(input))
+
+	case patch.MergePatchMediaType:
+		var input json.RawMessage
+		err = handler.sharedUtil.ReadJson(rw, r, &input)
+		if err != nil {
+			handler.sharedUtil.BadRequestResponse(rw, r, err)
+
+			return
+		}
+
+		movie, validationErrors, err = handler.service.Patch(r.Context(), id, patch.MergePatch(input))
+
+	case patch.JSONPatchMediaType:
+		var input patch.JSONPatch
+		err = handler.sharedUtil.ReadJson(rw, r, &input)
+		if err != nil {
+			handler.sharedUtil.BadRequestResponse(rw, r, err)
+
+			return
+		}
+
+		movie, validationErrors, err = handler.service.Patch(r.Context(), id, input)
+
+	default:
+		rw.Header().Set("Accept-Patch", fmt.Sprintf("application/json, %s, %s", patch.MergePatchMediaType, patch.JSONPatchMediaType))
+		handler.sharedUtil.UnsupportedMediaTypeResponse(rw, r)
+
+		return
+	}
+
+	handler.writeUpdatedMovie(rw, r, movie, validationErrors, err)
+}
+
+// ReplaceMovie ... Replace movie
+// @Summary Replace a given movie
+// @Description replace every field of the movie with the given details, omitted fields are cleared
+// @Tags Movies
+// @Param id path string true "Id of the movie to replace"
+// @Param body body dto.MovieRequest true "Replace movie request"
+// @Param Authorization header string true "Authorization: Bearer XXSGGSSHHSSJSJSSS"
+// @Success 200 {object} commons.ResponseObject{data=dto.SingleMovieResponse}
+// @Failure 409 {object} commons.ResponseObject "e.g. status: error, message: unable to update the record due to an edit conflict, please try again"
+// @Failure 422 {object} commons.ResponseObject{data=dto.ValidationError} "status: fail"
+// @Failure 400,401,403,404,500 {object} commons.ResponseObject "e.g. status: error, message: the error reason"
+// @Router /movies/{id} [put]
+func (handler *movieHandler) ReplaceMovie(rw http.ResponseWriter, r *http.Request) {
+
+	id, err := handler.sharedUtil.ExtractIdParamFromContext(r)
+	if err != nil {
+		handler.sharedUtil.NotFoundResponse(rw, r)
+
+		return
+	}
+
 	var input dto.MovieRequest
 	err = handler.sharedUtil.ReadJson(rw, r, &input)
 	if err != nil {
@@ -167,13 +291,26 @@ func (handler *movieHandler) UpdateMovie(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
-	movie, validationErrors, err := handler.service.Update(id, input)
+	movie, validationErrors, err := handler.service.Replace(r.Context(), id, input)
+
+	handler.writeUpdatedMovie(rw, r, movie, validationErrors, err)
+}
+
+func (handler *movieHandler) writeUpdatedMovie(
+	rw http.ResponseWriter,
+	r *http.Request,
+	movie *entities.Movie,
+	validationErrors services.MovieValidationErrors,
+	err error,
+) {
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			handler.sharedUtil.NotFoundResponse(rw, r)
-		case errors.Is(err, data.ErrEditConflict):
+		case errors.Is(err, data.ErrEditConflict), errors.Is(err, patch.ErrTestFailed):
 			handler.sharedUtil.EditConflictResponse(rw, r)
+		case errors.Is(err, patch.ErrInvalidPatch):
+			handler.sharedUtil.BadRequestResponse(rw, r, err)
 		default:
 			handler.sharedUtil.ServerErrorResponse(rw, r, err)
 		}
@@ -193,7 +330,7 @@ func (handler *movieHandler) UpdateMovie(rw http.ResponseWriter, r *http.Request
 		},
 	}
 
-	err = handler.sharedUtil.WriteJson(rw, http.StatusOK, result, nil)
+	err = handler.sharedUtil.WriteResponse(rw, r, http.StatusOK, result, nil)
 	if err != nil {
 		handler.sharedUtil.ServerErrorResponse(rw, r, err)
 
@@ -219,7 +356,7 @@ func (handler *movieHandler) DeleteMovie(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = handler.service.Delete(id)
+	err = handler.service.Delete(r.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -235,7 +372,7 @@ func (handler *movieHandler) DeleteMovie(rw http.ResponseWriter, r *http.Request
 		Message:   "movie successfully deleted",
 	}
 
-	err = handler.sharedUtil.WriteJson(rw, http.StatusOK, result, nil)
+	err = handler.sharedUtil.WriteResponse(rw, r, http.StatusOK, result, nil)
 	if err != nil {
 		handler.sharedUtil.ServerErrorResponse(rw, r, err)
 
@@ -245,13 +382,28 @@ func (handler *movieHandler) DeleteMovie(rw http.ResponseWriter, r *http.Request
 
 // ListMovie ... Get all movies
 // @Summary Get all movies
-// @Description get all movies
+// @Description get all movies, with a title search the matching words are highlighted in the highlight field
 // @Tags Movies
 // @Param title query string false "full text search by movie title"
+// @Param search_mode query string false "fulltext matches whole words, prefix partial words and fuzzy tolerates typos" Enums(fulltext, prefix, fuzzy) default(fulltext)
+// @Param language query string false "only movies in the language e.g. english"
 // @Param genres query string false "command seperated list e.g. crime,drama"
+// @Param genres_mode query string false "match movies with all, any or none of the genres" Enums(all, any, none) default(all)
+// @Param year_min query integer false "minimum year, inclusive"
+// @Param year_max query integer false "maximum year, inclusive"
+// @Param runtime_min query integer false "minimum runtime in minutes, inclusive"
+// @Param runtime_max query integer false "maximum runtime in minutes, inclusive"
+// @Param created_after query string false "RFC 3339 timestamp or date e.g. 2021-10-01"
+// @Param created_before query string false "RFC 3339 timestamp or date e.g. 2021-10-01"
+// @Param filter query string false "filter expression on title, year, runtime, genres, language and created_at e.g. year>=2000 and runtime<120"
 // @Param page query integer false "page number"  default(1) minimum(1) maximum(10000000)
 // @Param page_size query integer false "page size" default(10) minimum(1) maximum(100)
-// @Param sort query string false "add - to sort in descing order" Enums(id, title, year, runtime, -id, -title, -year, -runtime) default(id)
+// @Param sort query string false "comma seperated list of up to 3 of id, title, year, runtime, relevance (requires title), add - to sort in descing order e.g. -year,title" default(id)
+// @Param after query string false "cursor from metadata.next_cursor, returns the page after it instead of using page numbers"
+// @Param before query string false "cursor from metadata.prev_cursor, returns the page before it instead of using page numbers"
+// @Param include_total query boolean false "count the matching records, set to false for faster responses" default(true)
+// @Param facets query string false "comma seperated list of genres, decade, runtime_bucket, counts the matching movies by each value"
+// @Param fields query string false "comma seperated list of the movie fields to return e.g. title,year, the id is always returned"
 // @Param Authorization header string true "Authorization: Bearer XXSGGSSHHSSJSJSSS"
 // @Success 200 {object} commons.ResponseObject{data=dto.ListMovieResponse}
 // @Failure 422 {object} commons.ResponseObject{data=dto.ValidationError} "status: fail"
@@ -267,38 +419,73 @@ func (handler *movieHandler) ListMovie(rw http.ResponseWriter, r *http.Request)
 		Page:         util.ReadInt(qs, "page", 1, v),
 		PageSize:     util.ReadInt(qs, "page_size", 10, v),
 		Sort:         util.ReadString(qs, "sort", "id"),
-		SortSafelist: []string{"id", "title", "year", "runtime", "-id", "-title", "-year", "-runtime"},
+		SortSafelist: []string{"id", "title", "year", "runtime", "relevance", "-id", "-title", "-year", "-runtime", "-relevance"},
+		After:        util.ReadString(qs, "after", ""),
+		Before:       util.ReadString(qs, "before", ""),
+		IncludeTotal: util.ReadBool(qs, "include_total", true, v),
 	}
 
+	listMoviesRequest := handler.readMovieFilters(qs, v)
+	listMoviesRequest.Filters = filters
+
+	facets := util.ReadCSV(qs, "facets", []string{})
+	shape := util.ReadShape(qs, dto.MovieResponse{}, []string{"similar"}, v)
+	v.Check(len(shape.Includes) == 0, "include", "is not supported when listing movies")
+
 	filters.ValidateFilters(v)
+	v.Check(listMoviesRequest.Title != "" || !strings.Contains(filters.Sort, "relevance"), "sort", "relevance requires a title search")
+
+	for _, facet := range facets {
+		v.Check(validator.In(facet, data.FacetGenres, data.FacetDecade, data.FacetRuntimeBucket), "facets", "must be a list of genres, decade or runtime_bucket")
+	}
+	v.Check(validator.UniqueStringSlice(facets), "facets", "must not contain duplicate values")
+
 	if !v.Valid() {
 		util.FailedValidationResponse(rw, r, v.Errors)
 		return
 	}
 
-	listMoviesRequest := dto.ListMovieRequest{
-		Title:   util.ReadString(qs, "title", ""),
-		Genres:  util.ReadCSV(qs, "genres", []string{}),
-		Filters: filters,
-	}
-
-	movies, metadata, err := handler.service.List(listMoviesRequest)
+	movies, metadata, err := handler.service.List(r.Context(), listMoviesRequest)
 	if err != nil {
 		util.ServerErrorResponse(rw, r, err)
 		return
 	}
 
+	var movieFacets data.Facets
+	if len(facets) > 0 {
+		movieFacets, err = handler.service.Facets(r.Context(), listMoviesRequest, facets)
+		if err != nil {
+			util.ServerErrorResponse(rw, r, err)
+			return
+		}
+	}
+
+	if metadata.NextCursor != "" {
+		metadata.Next = pageLink(r, "after", metadata.NextCursor)
+	}
+
+	if metadata.PrevCursor != "" {
+		metadata.Prev = pageLink(r, "before", metadata.PrevCursor)
+	}
+
 	moviesDto := []dto.MovieResponse{}
 	for _, movie := range movies {
 		moviesDto = append(moviesDto, getMovieResponse(movie))
 	}
 
-	err = handler.sharedUtil.WriteJson(rw, http.StatusOK, commons.ResponseObject{
+	shaped, err := shape.Apply(dto.ListMovieResponse{
+		Metadata: metadata,
+		Movies:   moviesDto,
+		Facets:   movieFacets,
+	}, "movies")
+	if err != nil {
+		util.ServerErrorResponse(rw, r, err)
+		return
+	}
+
+	err = handler.sharedUtil.WriteResponse(rw, r, http.StatusOK, commons.ResponseObject{
 		StatusMsg: custom_type.Success,
-		Data: dto.ListMovieResponse{
-			Metadata: metadata,
-			Movies:   moviesDto,
-		},
+		Data:      shaped,
 	}, nil)
 
 	if err != nil {
@@ -308,13 +495,199 @@ func (handler *movieHandler) ListMovie(rw http.ResponseWriter, r *http.Request)
 	}
 }
 
+// BatchMovies ... Create, update and delete movies in one request
+// @Summary Create, update and delete movies in one request
+// @Description run up to 100 operations in a single transaction. In atomic mode (default) a failed operation rolls back
+// @Description the whole batch, in per_item mode only the failed operations are rolled back.
+// @Tags Movies
+// @Param body body dto.BatchMovieRequest true "Batch movie request"
+// @Param Authorization header string true "Authorization: Bearer XXSGGSSHHSSJSJSSS"
+// @Success 200 {object} commons.ResponseObject{data=dto.BatchMovieResponse}
+// @Failure 422 {object} commons.ResponseObject{data=dto.BatchMovieResponse} "status: fail, atomic batch rolled back"
+// @Failure 400,401,403,500 {object} commons.ResponseObject "e.g. status: error, message: the error reason"
+// @Router /movies/batch [post]
+func (handler *movieHandler) BatchMovies(rw http.ResponseWriter, r *http.Request) {
+	var input dto.BatchMovieRequest
+
+	err := handler.sharedUtil.ReadJson(rw, r, &input)
+	if err != nil {
+		handler.sharedUtil.BadRequestResponse(rw, r, err)
+
+		return
+	}
+
+	results, validationErrors, err := handler.service.Batch(r.Context(), input)
+	if validationErrors != nil {
+		handler.sharedUtil.FailedValidationResponse(rw, r, validationErrors)
+
+		return
+	}
+
+	committed := !errors.Is(err, services.ErrBatchAborted)
+	if err != nil && committed {
+		handler.sharedUtil.ServerErrorResponse(rw, r, err)
+
+		return
+	}
+
+	response := dto.BatchMovieResponse{
+		Mode:      input.Mode,
+		Committed: committed,
+		Results:   make([]dto.BatchMovieResult, len(results)),
+	}
+
+	if response.Mode == "" {
+		response.Mode = services.BatchModeAtomic
+	}
+
+	for i, result := range results {
+		response.Results[i] = handler.getBatchMovieResult(r, i, input.Operations[i].Op, result)
+	}
+
+	if !committed {
+		handler.sharedUtil.ErrorResponse(rw, r, http.StatusUnprocessableEntity, commons.ResponseObject{
+			StatusMsg: custom_type.Fail,
+			Code:      commons.CodeBatchFailed,
+			Message:   "the batch was rolled back, see the results of its operations",
+			Data:      response,
+		})
+
+		return
+	}
+
+	err = handler.sharedUtil.WriteResponse(rw, r, http.StatusOK, commons.ResponseObject{
+		StatusMsg: custom_type.Success,
+		Data:      response,
+	}, nil)
+
+	if err != nil {
+		handler.sharedUtil.ServerErrorResponse(rw, r, err)
+
+		return
+	}
+}
+
+func (handler *movieHandler) getBatchMovieResult(r *http.Request, index int, op string, result services.BatchResult) dto.BatchMovieResult {
+
+	item := dto.BatchMovieResult{
+		Index:  index,
+		Op:     op,
+		Status: custom_type.Fail,
+	}
+
+	switch {
+	case result.ValidationErrors != nil:
+		item.Errors = make(map[string]string, len(result.ValidationErrors))
+		for key, message := range result.ValidationErrors.Flat() {
+			item.Errors[key] = handler.sharedUtil.Translate(r, "", message)
+		}
+	case result.Err == nil:
+		item.Status = custom_type.Success
+		if result.Movie != nil {
+			movie := getMovieResponse(result.Movie)
+			item.Movie = &movie
+		}
+	case errors.Is(result.Err, data.ErrRecordNotFound):
+		item.Message = handler.sharedUtil.Translate(r, commons.CodeNotFound, "the requested resource could not be found")
+	case errors.Is(result.Err, data.ErrEditConflict):
+		item.Message = handler.sharedUtil.Translate(r, commons.CodeEditConflict, "unable to update the record due to an edit conflict, please try again")
+	case errors.Is(result.Err, services.ErrBatchAborted):
+		item.Message = handler.sharedUtil.Translate(r, "", result.Err.Error())
+	default:
+		handler.sharedUtil.LogErrorWithHttpRequestContext(r, result.Err)
+		item.Status = custom_type.Error
+		item.Message = handler.sharedUtil.Translate(r, "", "the server encountered a problem and could not process this operation")
+	}
+
+	return item
+}
+
+// movieFilterSchema holds the fields of the filter query parameter of ListMovie and ExportMovies.
+var movieFilterSchema = filter.Schema{
+	"title":      {Column: "title", Type: filter.Text},
+	"year":       {Column: "year", Type: filter.Number},
+	"runtime":    {Column: "runtime", Type: filter.Number},
+	"genres":     {Column: "genres", Type: filter.List},
+	"language":   {Column: "language", Type: filter.Text},
+	"created_at": {Column: "created_at", Type: filter.Time},
+}
+
+// readMovieFilters reads the filters shared by ListMovie and ExportMovies from the query string.
+func (handler *movieHandler) readMovieFilters(qs url.Values, v *validator.Validator) dto.ListMovieRequest {
+	util := handler.sharedUtil
+
+	request := dto.ListMovieRequest{
+		Title:         util.ReadString(qs, "title", ""),
+		SearchMode:    util.ReadString(qs, "search_mode", data.SearchModeFulltext),
+		Language:      util.ReadString(qs, "language", ""),
+		Genres:        util.ReadCSV(qs, "genres", []string{}),
+		GenresMode:    util.ReadString(qs, "genres_mode", data.GenresModeAll),
+		YearMin:       util.ReadInt(qs, "year_min", 0, v),
+		YearMax:       util.ReadInt(qs, "year_max", 0, v),
+		RuntimeMin:    util.ReadInt(qs, "runtime_min", 0, v),
+		RuntimeMax:    util.ReadInt(qs, "runtime_max", 0, v),
+		CreatedAfter:  util.ReadTime(qs, "created_after", time.Time{}, v),
+		CreatedBefore: util.ReadTime(qs, "created_before", time.Time{}, v),
+	}
+
+	v.Check(validator.In(request.SearchMode, data.SearchModeFulltext, data.SearchModePrefix, data.SearchModeFuzzy), "search_mode", "must be one of fulltext, prefix or fuzzy")
+
+	// prefix search matches words, so there must be at least one
+	if request.SearchMode == data.SearchModePrefix && request.Title != "" {
+		isWord := func(c rune) bool { return unicode.IsLetter(c) || unicode.IsDigit(c) }
+		v.Check(strings.IndexFunc(request.Title, isWord) >= 0, "title", "must contain a letter or a digit")
+	}
+
+	v.Check(request.Language == "" || validator.In(request.Language, data.Languages...), "language", "must be a supported language e.g. english")
+
+	v.Check(validator.In(request.GenresMode, data.GenresModeAll, data.GenresModeAny, data.GenresModeNone), "genres_mode", "must be one of all, any or none")
+
+	for key, year := range map[string]int{"year_min": request.YearMin, "year_max": request.YearMax} {
+		v.Check(year == 0 || year >= 1888, key, "must be greater than 1888")
+		v.Check(year <= time.Now().Year(), key, "must not be in the future")
+	}
+	v.Check(request.YearMax == 0 || request.YearMin <= request.YearMax, "year_min", "must not be greater than year_max")
+
+	v.Check(request.RuntimeMin >= 0, "runtime_min", "must be a positive integer")
+	v.Check(request.RuntimeMax >= 0, "runtime_max", "must be a positive integer")
+	v.Check(request.RuntimeMax == 0 || request.RuntimeMin <= request.RuntimeMax, "runtime_min", "must not be greater than runtime_max")
+
+	v.Check(request.CreatedAfter.IsZero() || request.CreatedBefore.IsZero() || request.CreatedAfter.Before(request.CreatedBefore),
+		"created_after", "must be before created_before")
+
+	if expression := util.ReadString(qs, "filter", ""); expression != "" {
+		expr, err := filter.Parse(expression, movieFilterSchema)
+		if err != nil {
+			v.AddError("filter", err.Error())
+		}
+
+		request.Filter = expr
+	}
+
+	return request
+}
+
+// pageLink returns the url of the current request with the page replaced by the given cursor.
+func pageLink(r *http.Request, key, cursor string) string {
+	qs := r.URL.Query()
+
+	qs.Del("page")
+	qs.Del("after")
+	qs.Del("before")
+	qs.Set(key, cursor)
+
+	return fmt.Sprintf("%s?%s", r.URL.Path, qs.Encode())
+}
+
 func getMovieResponse(movie *entities.Movie) dto.MovieResponse {
 	return dto.MovieResponse{
-		ID:      movie.ID,
-		Title:   movie.Title,
-		Year:    movie.Year,
-		Runtime: movie.Runtime,
-		Genres:  movie.Genres,
-		Version: movie.Version,
+		ID:        movie.ID,
+		Title:     movie.Title,
+		Year:      movie.Year,
+		Runtime:   movie.Runtime,
+		Genres:    movie.Genres,
+		Language:  movie.Language,
+		Version:   movie.Version,
+		Highlight: movie.Highlight,
 	}
 }
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/commons"
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/validator"
+)
+
+// SuggestMovies ... Suggest movie titles
+// @Summary Suggest movie titles for type-ahead
+// @Description return the movies with a title word starting with q, movies whose title starts with q first
+// @Tags Movies
+// @Param q query string true "the text typed so far e.g. godf"
+// @Param limit query integer false "maximum number of suggestions" default(10) minimum(1) maximum(20)
+// @Param Authorization header string true "Authorization: Bearer XXSGGSSHHSSJSJSSS"
+// @Success 200 {object} commons.ResponseObject{data=dto.SuggestMovieResponse}
+// @Failure 422 {object} commons.ResponseObject{data=dto.ValidationError} "status: fail"
+// @Failure 401,403,500 {object} commons.ResponseObject "e.g. status: error, message: the error reason"
+// @Router /movies/suggest [get]
+func (handler *movieHandler) SuggestMovies(rw http.ResponseWriter, r *http.Request) {
+	util := handler.sharedUtil
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	q := util.ReadString(qs, "q", "")
+	limit := util.ReadInt(qs, "limit", 10, v)
+
+	isWord := func(c rune) bool { return unicode.IsLetter(c) || unicode.IsDigit(c) }
+
+	v.Check(q != "", "q", "must be provided")
+	v.Check(q == "" || strings.IndexFunc(q, isWord) >= 0, "q", "must contain a letter or a digit")
+	v.Check(len(q) <= 100, "q", "must not be more than 100 bytes long")
+	v.Check(limit > 0, "limit", "must be greater than zero")
+	v.Check(limit <= 20, "limit", "must be a maximum of 20")
+
+	if !v.Valid() {
+		util.FailedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	movies, cold, err := handler.service.Suggest(r.Context(), q, limit)
+	if err != nil {
+		util.ServerErrorResponse(rw, r, err)
+		return
+	}
+
+	// the suggestions came from the database, load the title index for the next requests
+	if cold {
+		util.Background(func() {
+			err := handler.service.LoadSuggestIndex(context.Background())
+			if err != nil {
+				util.LogErrorWithContext(err, map[string]string{
+					"task": "title index goroutine",
+				})
+			}
+		})
+	}
+
+	suggestions := []dto.MovieSuggestion{}
+	for _, movie := range movies {
+		suggestions = append(suggestions, dto.MovieSuggestion{ID: movie.ID, Title: movie.Title})
+	}
+
+	err = util.WriteResponse(rw, r, http.StatusOK, commons.ResponseObject{
+		StatusMsg: custom_type.Success,
+		Data: dto.SuggestMovieResponse{
+			Suggestions: suggestions,
+		},
+	}, nil)
+
+	if err != nil {
+		util.ServerErrorResponse(rw, r, err)
+	}
+}
@@ -1,15 +1,26 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/terdia/greenlight/infrastructures/dto"
 	"github.com/terdia/greenlight/internal/data"
 	"github.com/terdia/greenlight/src/movies/entities"
 )
 
 type MovieRepository interface {
-	Insert(movie *entities.Movie) error
-	Get(id int64) (*entities.Movie, error)
-	Update(movie *entities.Movie) error
-	Delete(id int64) error
-	GetAll(dto.ListMovieRequest) ([]*entities.Movie, data.Metadata, error)
+	Insert(ctx context.Context, movie *entities.Movie) error
+	Get(ctx context.Context, id int64) (*entities.Movie, error)
+	Update(ctx context.Context, movie *entities.Movie) error
+	Delete(ctx context.Context, id int64) error
+	GetAll(ctx context.Context, r dto.ListMovieRequest) ([]*entities.Movie, data.Metadata, error)
+	// Facets counts the movies matching the filters of the request by each value of the facets.
+	Facets(ctx context.Context, r dto.ListMovieRequest, facets []string) (data.Facets, error)
+	// Export calls fn for every movie matching the title and genres of the request.
+	Export(ctx context.Context, r dto.ListMovieRequest, fn func(movie *entities.Movie) error) error
+	// CopyFrom bulk inserts movies, it doesn't set the ID, CreatedAt and Version of the movies.
+	CopyFrom(ctx context.Context, movies []*entities.Movie) (int64, error)
+	// WithinTransaction runs fn with a repository bound to a single transaction, nested
+	// calls on that repository are scoped to a savepoint.
+	WithinTransaction(ctx context.Context, fn func(repo MovieRepository) error) error
 }
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/unitofwork"
+	"github.com/terdia/greenlight/internal/validator"
+	"github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/movies/repositories"
+)
+
+const (
+	BatchModeAtomic  = "atomic"
+	BatchModePerItem = "per_item"
+
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+
+	MaxBatchOperations = 100
+)
+
+// ErrBatchAborted is reported for every operation of an atomic batch once one of its
+// operations failed, none of the operations have been applied.
+var ErrBatchAborted = errors.New("batch aborted, the operation was rolled back")
+
+// errSkipOperation rolls back the savepoint of a failed operation in per_item mode.
+var errSkipOperation = errors.New("skip batch operation")
+
+type BatchResult struct {
+	Movie            *entities.Movie
+	ValidationErrors MovieValidationErrors
+	Err              error
+}
+
+func (result BatchResult) Failed() bool {
+	return result.ValidationErrors != nil || result.Err != nil
+}
+
+// Batch runs the operations in one unit of work. In atomic mode the first failed operation
+// rolls back the whole batch and ErrBatchAborted is returned alongside the results, in
+// per_item mode every operation runs in its own savepoint and only failed ones are rolled back.
+// In both modes an operation failing on a conflict with a concurrent transaction runs the whole
+// batch again.
+func (srv *movieService) Batch(ctx context.Context, request dto.BatchMovieRequest) ([]BatchResult, MovieValidationErrors, error) {
+
+	if request.Mode == "" {
+		request.Mode = BatchModeAtomic
+	}
+
+	v := validator.New()
+
+	if validateBatchRequest(v, request); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	var results []BatchResult
+
+	err := srv.uow.Do(ctx, func(repos unitofwork.Repositories) error {
+
+		repo := repos.Movies
+
+		// the unit of work may run again, every attempt starts with fresh results
+		results = make([]BatchResult, len(request.Operations))
+
+		for i, operation := range request.Operations {
+
+			if request.Mode == BatchModePerItem {
+				err := repo.WithinTransaction(ctx, func(repo repositories.MovieRepository) error {
+					if results[i] = applyBatchOperation(ctx, repo, operation); results[i].Failed() {
+						if srv.uow.IsRetryable(results[i].Err) {
+							return results[i].Err
+						}
+						return errSkipOperation
+					}
+					return nil
+				})
+
+				if srv.uow.IsRetryable(err) {
+					// the transaction conflicts with a concurrent one, the whole batch runs again
+					return err
+				}
+
+				if err != nil && !errors.Is(err, errSkipOperation) {
+					results[i] = BatchResult{Err: err}
+				}
+
+				continue
+			}
+
+			if results[i] = applyBatchOperation(ctx, repo, operation); results[i].Failed() {
+				if srv.uow.IsRetryable(results[i].Err) {
+					return results[i].Err
+				}
+
+				for j := range results {
+					if j != i {
+						results[j] = BatchResult{Err: ErrBatchAborted}
+					}
+				}
+
+				return ErrBatchAborted
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, ErrBatchAborted) {
+		return nil, nil, err
+	}
+
+	// the batch is committed, apply the operations that weren't rolled back to the title index
+	for i, operation := range request.Operations {
+		if err != nil || results[i].Failed() {
+			continue
+		}
+
+		if operation.Op == BatchOpDelete {
+			srv.titles.remove(int64(operation.ID))
+		} else {
+			srv.titles.put(int64(results[i].Movie.ID), results[i].Movie.Title)
+		}
+	}
+
+	return results, nil, err
+}
+
+func applyBatchOperation(ctx context.Context, repo repositories.MovieRepository, operation dto.BatchMovieOperation) BatchResult {
+
+	v := newMovieValidator()
+
+	switch operation.Op {
+	case BatchOpCreate:
+		movie := &entities.Movie{}
+		replaceMovie(movie, operation.Movie)
+
+		if validateMovie(v, movie); !v.Valid() {
+			return BatchResult{ValidationErrors: v.Errors}
+		}
+
+		return BatchResult{Movie: movie, Err: repo.Insert(ctx, movie)}
+
+	case BatchOpUpdate:
+		if v.Check(operation.ID != 0, "id", "must be provided"); !v.Valid() {
+			return BatchResult{ValidationErrors: v.Errors}
+		}
+
+		movie, err := repo.Get(ctx, int64(operation.ID))
+		if err != nil {
+			return BatchResult{Err: err}
+		}
+
+		updateMovie(movie, operation.Movie)
+
+		if validateMovie(v, movie); !v.Valid() {
+			return BatchResult{ValidationErrors: v.Errors}
+		}
+
+		return BatchResult{Movie: movie, Err: repo.Update(ctx, movie)}
+
+	case BatchOpDelete:
+		if v.Check(operation.ID != 0, "id", "must be provided"); !v.Valid() {
+			return BatchResult{ValidationErrors: v.Errors}
+		}
+
+		return BatchResult{Err: repo.Delete(ctx, int64(operation.ID))}
+
+	default:
+		v.AddError("op", "must be one of create, update or delete")
+
+		return BatchResult{ValidationErrors: v.Errors}
+	}
+}
+
+func validateBatchRequest(v *validator.Validator, request dto.BatchMovieRequest) {
+	v.Check(validator.In(request.Mode, BatchModeAtomic, BatchModePerItem), "mode", "must be one of atomic or per_item")
+	v.Check(len(request.Operations) >= 1, "operations", "must contain at least 1 operation")
+	v.Check(len(request.Operations) <= MaxBatchOperations, "operations", "must not contain more than 100 operations")
+}
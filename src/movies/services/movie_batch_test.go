@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/infrastructures/persistence/memory"
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/unitofwork"
+	"github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/movies/repositories"
+)
+
+// conflictingRepository fails its first inserts with the serialization failure of the memory
+// store, like a concurrent transaction would.
+type conflictingRepository struct {
+	repositories.MovieRepository
+	conflicts *int
+}
+
+func (repo *conflictingRepository) Insert(ctx context.Context, movie *entities.Movie) error {
+	if *repo.conflicts > 0 {
+		*repo.conflicts--
+		return memory.ErrSerializationFailure
+	}
+
+	return repo.MovieRepository.Insert(ctx, movie)
+}
+
+func (repo *conflictingRepository) WithinTransaction(ctx context.Context, fn func(repo repositories.MovieRepository) error) error {
+	return repo.MovieRepository.WithinTransaction(ctx, func(tx repositories.MovieRepository) error {
+		return fn(&conflictingRepository{MovieRepository: tx, conflicts: repo.conflicts})
+	})
+}
+
+// conflictingUnitOfWork runs the memory unit of work with a conflictingRepository and counts its
+// attempts.
+type conflictingUnitOfWork struct {
+	unitofwork.UnitOfWork
+	conflicts int
+	attempts  int
+}
+
+func (uow *conflictingUnitOfWork) Do(ctx context.Context, fn func(repos unitofwork.Repositories) error) error {
+	return uow.UnitOfWork.Do(ctx, func(repos unitofwork.Repositories) error {
+		uow.attempts++
+		repos.Movies = &conflictingRepository{MovieRepository: repos.Movies, conflicts: &uow.conflicts}
+		return fn(repos)
+	})
+}
+
+func TestBatchRetry(t *testing.T) {
+
+	title, year, runtime := "Casablanca", int32(1942), custom_type.Runtime(102)
+	movie := dto.MovieRequest{Title: &title, Year: &year, Runtime: &runtime, Genres: []string{"drama"}}
+
+	for _, mode := range []string{BatchModeAtomic, BatchModePerItem} {
+		t.Run(mode, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			uow := &conflictingUnitOfWork{UnitOfWork: memory.NewUnitOfWork(store), conflicts: 1}
+			srv := NewMovieService(memory.NewMovieRepository(store), uow)
+
+			results, validationErrors, err := srv.Batch(ctx, dto.BatchMovieRequest{
+				Mode:       mode,
+				Operations: []dto.BatchMovieOperation{{Op: BatchOpCreate, Movie: movie}, {Op: BatchOpCreate, Movie: movie}},
+			})
+			if err != nil || validationErrors != nil {
+				t.Fatalf("want the batch to commit; got %v %v", err, validationErrors)
+			}
+
+			if uow.attempts != 2 {
+				t.Errorf("want 2 attempts; got %d", uow.attempts)
+			}
+
+			for i, result := range results {
+				if result.Failed() {
+					t.Fatalf("want operation %d to succeed; got %v %v", i, result.Err, result.ValidationErrors)
+				}
+
+				if _, err := memory.NewMovieRepository(store).Get(ctx, int64(result.Movie.ID)); err != nil {
+					t.Errorf("want movie %d to be committed; got %v", result.Movie.ID, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/validator"
+	"github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/movies/repositories"
+)
+
+const (
+	importChunkSize   = 1000
+	maxImportErrors   = 100
+	maxImportLineSize = 1_048_576
+	importJobTTL      = 24 * time.Hour
+)
+
+// ErrInvalidImportFile is returned when the imported file can't be read at all, e.g. a
+// CSV file with an unknown column.
+var ErrInvalidImportFile = errors.New("invalid import file")
+
+var errInvalidImportRows = errors.New("import contains invalid rows")
+
+// CreateImportJob registers a pending import job, the rows are read by RunImport.
+func (srv *movieService) CreateImportJob(format string, dryRun bool) *entities.ImportJob {
+	return srv.imports.create(format, dryRun)
+}
+
+func (srv *movieService) GetImportJob(id int64) (*entities.ImportJob, error) {
+	return srv.imports.get(custom_type.ID(id))
+}
+
+// RunImport reads, validates and inserts every row of r. The rows are inserted with COPY
+// in a single transaction, so nothing is imported when a row is invalid. The job progress
+// is updated as rows are read and can be polled with GetImportJob.
+func (srv *movieService) RunImport(ctx context.Context, id custom_type.ID, r io.Reader) error {
+
+	job, err := srv.imports.get(id)
+	if err != nil {
+		return err
+	}
+
+	srv.imports.update(id, func(job *entities.ImportJob) {
+		job.Status = entities.ImportStatusRunning
+	})
+
+	reader, err := newImportReader(job.Format, r)
+	if err == nil {
+		if job.DryRun {
+			err = srv.importRows(ctx, srv.repo, id, reader, true)
+		} else {
+			err = srv.repo.WithinTransaction(ctx, func(repo repositories.MovieRepository) error {
+				return srv.importRows(ctx, repo, id, reader, false)
+			})
+
+			// COPY doesn't return the ids of the movies, so the title index is reloaded instead
+			if err == nil {
+				srv.titles.invalidate()
+			}
+		}
+	}
+
+	srv.imports.update(id, func(job *entities.ImportJob) {
+		job.FinishedAt = time.Now()
+		job.Status = entities.ImportStatusFailed
+
+		switch {
+		case err == nil:
+			job.Status = entities.ImportStatusCompleted
+		case errors.Is(err, errInvalidImportRows):
+			job.Inserted = 0
+			job.Message = fmt.Sprintf("%d invalid rows, no movie was imported", job.Invalid)
+		case errors.Is(err, ErrInvalidImportFile):
+			job.Inserted = 0
+			job.Message = err.Error()
+		default:
+			job.Inserted = 0
+			job.Message = "the server encountered a problem and could not complete the import"
+		}
+	})
+
+	if errors.Is(err, errInvalidImportRows) || errors.Is(err, ErrInvalidImportFile) {
+		return nil
+	}
+
+	return err
+}
+
+func (srv *movieService) importRows(ctx context.Context, repo repositories.MovieRepository, id custom_type.ID, reader importReader, dryRun bool) error {
+
+	var (
+		processed, inserted, invalid int
+		lineErrors                   []entities.ImportError
+		chunk                        = make([]*entities.Movie, 0, importChunkSize)
+	)
+
+	flush := func() error {
+		if !dryRun && invalid == 0 && len(chunk) > 0 {
+			n, err := repo.CopyFrom(ctx, chunk)
+			if err != nil {
+				return err
+			}
+			inserted += int(n)
+		}
+
+		chunk = chunk[:0]
+
+		srv.imports.update(id, func(job *entities.ImportJob) {
+			job.Processed, job.Inserted, job.Invalid = processed, inserted, invalid
+			job.Errors = append(job.Errors, lineErrors...)
+		})
+		lineErrors = lineErrors[:0]
+
+		return nil
+	}
+
+	for {
+		row, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		processed++
+
+		movie := &entities.Movie{}
+		replaceMovie(movie, row.request)
+
+		v := newMovieValidator()
+		for key, message := range row.errors {
+			v.AddError(key, message)
+		}
+
+		if validateMovie(v, movie); !v.Valid() {
+			if invalid < maxImportErrors {
+				lineErrors = append(lineErrors, entities.ImportError{Line: row.line, Errors: v.Errors.Flat()})
+			}
+			invalid++
+		} else {
+			chunk = append(chunk, movie)
+		}
+
+		if processed%importChunkSize == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if invalid > 0 {
+		return errInvalidImportRows
+	}
+
+	return nil
+}
+
+type importRow struct {
+	line    int
+	request dto.MovieRequest
+	errors  map[string]string
+}
+
+type importReader interface {
+	// next returns the next row of the file, or io.EOF once every row has been read.
+	next() (importRow, error)
+}
+
+func newImportReader(format string, r io.Reader) (importReader, error) {
+	switch format {
+	case entities.ImportFormatCSV:
+		return newCSVImportReader(r)
+	case entities.ImportFormatNDJSON:
+		return newNDJSONImportReader(r), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidImportFile, format)
+	}
+}
+
+// csvImportReader reads a CSV file with a title,year,runtime,genres header and an optional
+// language column, columns may be in any order. Runtime is in minutes e.g. 98 or "98 mins"
+// and genres are comma separated within the cell e.g. "action,adventure".
+type csvImportReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: file must not be empty", ErrInvalidImportFile)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImportFile, err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if !validator.In(name, "title", "year", "runtime", "genres", "language") {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidImportFile, name)
+		}
+		if _, exists := columns[name]; exists {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidImportFile, name)
+		}
+
+		columns[name] = i
+	}
+
+	for _, name := range []string{"title", "year", "runtime", "genres"} {
+		if _, exists := columns[name]; !exists {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidImportFile, name)
+		}
+	}
+
+	return &csvImportReader{reader: reader, columns: columns}, nil
+}
+
+func (cr *csvImportReader) next() (importRow, error) {
+
+	record, err := cr.reader.Read()
+	if err != nil {
+		var parseError *csv.ParseError
+		if errors.As(err, &parseError) {
+			return importRow{line: parseError.Line, errors: map[string]string{"row": parseError.Err.Error()}}, nil
+		}
+		return importRow{}, err
+	}
+
+	line, _ := cr.reader.FieldPos(0)
+	row := importRow{line: line, errors: make(map[string]string)}
+
+	if title := record[cr.columns["title"]]; title != "" {
+		row.request.Title = &title
+	}
+
+	if year := strings.TrimSpace(record[cr.columns["year"]]); year != "" {
+		i, err := strconv.ParseInt(year, 10, 32)
+		if err != nil {
+			row.errors["year"] = "must be an integer value"
+		} else {
+			y := int32(i)
+			row.request.Year = &y
+		}
+	}
+
+	if runtime := strings.TrimSpace(record[cr.columns["runtime"]]); runtime != "" {
+		i, err := strconv.ParseInt(strings.TrimSuffix(runtime, " mins"), 10, 32)
+		if err != nil {
+			row.errors["runtime"] = `must be a number of minutes e.g. 98 or "98 mins"`
+		} else {
+			r := custom_type.Runtime(i)
+			row.request.Runtime = &r
+		}
+	}
+
+	if genres := strings.TrimSpace(record[cr.columns["genres"]]); genres != "" {
+		for _, genre := range strings.Split(genres, ",") {
+			if genre = strings.TrimSpace(genre); genre != "" {
+				row.request.Genres = append(row.request.Genres, genre)
+			}
+		}
+	}
+
+	// language is optional
+	if i, exists := cr.columns["language"]; exists {
+		if language := strings.TrimSpace(record[i]); language != "" {
+			row.request.Language = &language
+		}
+	}
+
+	return row, nil
+}
+
+// ndjsonImportReader reads one movie JSON object, as accepted by CreateMovie, per line.
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONImportReader(r io.Reader) *ndjsonImportReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+
+	return &ndjsonImportReader{scanner: scanner}
+}
+
+func (nr *ndjsonImportReader) next() (importRow, error) {
+
+	for nr.scanner.Scan() {
+		nr.line++
+
+		line := bytes.TrimSpace(nr.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		row := importRow{line: nr.line}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(&row.request); err != nil {
+			row.request = dto.MovieRequest{}
+			row.errors = map[string]string{"row": fmt.Sprintf("invalid JSON: %s", err)}
+		}
+
+		return row, nil
+	}
+
+	if err := nr.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return importRow{}, fmt.Errorf("%w: line %d is longer than %d bytes", ErrInvalidImportFile, nr.line+1, maxImportLineSize)
+		}
+		return importRow{}, err
+	}
+
+	return importRow{}, io.EOF
+}
+
+// importJobStore keeps the import jobs of this process in memory, finished jobs are
+// dropped after importJobTTL.
+type importJobStore struct {
+	mu     sync.Mutex
+	jobs   map[custom_type.ID]*entities.ImportJob
+	nextID custom_type.ID
+}
+
+func newImportJobStore() *importJobStore {
+	return &importJobStore{jobs: make(map[custom_type.ID]*entities.ImportJob)}
+}
+
+func (store *importJobStore) create(format string, dryRun bool) *entities.ImportJob {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for id, job := range store.jobs {
+		if job.Finished() && time.Since(job.FinishedAt) > importJobTTL {
+			delete(store.jobs, id)
+		}
+	}
+
+	store.nextID++
+
+	job := &entities.ImportJob{
+		ID:        store.nextID,
+		Format:    format,
+		DryRun:    dryRun,
+		Status:    entities.ImportStatusPending,
+		CreatedAt: time.Now(),
+	}
+	store.jobs[job.ID] = job
+
+	cp := *job
+
+	return &cp
+}
+
+// get returns a copy of the job, so it can be read while the import is running.
+func (store *importJobStore) get(id custom_type.ID) (*entities.ImportJob, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	job, ok := store.jobs[id]
+	if !ok {
+		return nil, data.ErrRecordNotFound
+	}
+
+	cp := *job
+	cp.Errors = append([]entities.ImportError(nil), job.Errors...)
+
+	return &cp, nil
+}
+
+func (store *importJobStore) update(id custom_type.ID, fn func(job *entities.ImportJob)) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if job, ok := store.jobs[id]; ok {
+		fn(job)
+	}
+}
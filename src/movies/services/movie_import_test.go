@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/terdia/greenlight/src/movies/entities"
+	"github.com/terdia/greenlight/src/movies/repositories"
+)
+
+type importRepositoryStub struct {
+	repositories.MovieRepository
+	copied int
+}
+
+func (repo *importRepositoryStub) CopyFrom(ctx context.Context, movies []*entities.Movie) (int64, error) {
+	repo.copied += len(movies)
+	return int64(len(movies)), nil
+}
+
+func (repo *importRepositoryStub) WithinTransaction(ctx context.Context, fn func(repo repositories.MovieRepository) error) error {
+	return fn(repo)
+}
+
+func TestRunImport(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		format       string
+		dryRun       bool
+		file         string
+		wantStatus   string
+		wantInserted int
+		wantInvalid  int
+		wantLines    []int
+	}{
+		{
+			name:         "Import CSV",
+			format:       entities.ImportFormatCSV,
+			file:         "title,year,runtime,genres\nCasablanca,1942,102,\"drama,romance\"\nAlien,1979,117 mins,horror\n",
+			wantStatus:   entities.ImportStatusCompleted,
+			wantInserted: 2,
+		},
+		{
+			name:         "CSV columns in any order",
+			format:       entities.ImportFormatCSV,
+			file:         "genres,Title,runtime,year\ndrama,Casablanca,102,1942\n",
+			wantStatus:   entities.ImportStatusCompleted,
+			wantInserted: 1,
+		},
+		{
+			name:        "Invalid CSV rows",
+			format:      entities.ImportFormatCSV,
+			file:        "title,year,runtime,genres\nCasablanca,1942,102,drama\n,1942,102,drama\nAlien,abc,117,horror\n",
+			wantStatus:  entities.ImportStatusFailed,
+			wantInvalid: 2,
+			wantLines:   []int{3, 4},
+		},
+		{
+			name:       "Unknown CSV column",
+			format:     entities.ImportFormatCSV,
+			file:       "title,year,runtime,genres,rating\n",
+			wantStatus: entities.ImportStatusFailed,
+		},
+		{
+			name:         "Import NDJSON",
+			format:       entities.ImportFormatNDJSON,
+			file:         "{\"title\":\"Casablanca\",\"year\":1942,\"runtime\":\"102 mins\",\"genres\":[\"drama\"]}\n\n{\"title\":\"Alien\",\"year\":1979,\"runtime\":\"117 mins\",\"genres\":[\"horror\"]}\n",
+			wantStatus:   entities.ImportStatusCompleted,
+			wantInserted: 2,
+		},
+		{
+			name:        "Invalid NDJSON rows",
+			format:      entities.ImportFormatNDJSON,
+			file:        "{\"title\":\"Casablanca\",\"year\":1942,\"runtime\":\"102 mins\",\"genres\":[\"drama\"]}\n{\"title\":\n{\"title\":\"Alien\",\"year\":1979,\"runtime\":\"117 mins\",\"genres\":[]}\n",
+			wantStatus:  entities.ImportStatusFailed,
+			wantInvalid: 2,
+			wantLines:   []int{2, 3},
+		},
+		{
+			name:       "Dry run",
+			format:     entities.ImportFormatCSV,
+			dryRun:     true,
+			file:       "title,year,runtime,genres\nCasablanca,1942,102,drama\n",
+			wantStatus: entities.ImportStatusCompleted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &importRepositoryStub{}
+			srv := NewMovieService(repo, nil)
+
+			job := srv.CreateImportJob(test.format, test.dryRun)
+
+			err := srv.RunImport(context.Background(), job.ID, strings.NewReader(test.file))
+			if err != nil {
+				t.Fatalf("want error to be %v; got %s", nil, err.Error())
+			}
+
+			job, _ = srv.GetImportJob(int64(job.ID))
+
+			if job.Status != test.wantStatus {
+				t.Errorf("want %s; got %s (%s)", test.wantStatus, job.Status, job.Message)
+			}
+
+			if job.Inserted != test.wantInserted {
+				t.Errorf("want %d inserted; got %d", test.wantInserted, job.Inserted)
+			}
+
+			if job.Invalid != test.wantInvalid {
+				t.Errorf("want %d invalid; got %d", test.wantInvalid, job.Invalid)
+			}
+
+			if len(job.Errors) != len(test.wantLines) {
+				t.Fatalf("want %d line errors; got %d", len(test.wantLines), len(job.Errors))
+			}
+
+			for i, line := range test.wantLines {
+				if job.Errors[i].Line != line {
+					t.Errorf("want error on line %d; got %d", line, job.Errors[i].Line)
+				}
+			}
+		})
+	}
+}
@@ -1,54 +1,209 @@
 package services
 
 import (
-	"time"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 
 	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/custom_type"
 	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/filter"
+	"github.com/terdia/greenlight/internal/patch"
+	"github.com/terdia/greenlight/internal/unitofwork"
 	"github.com/terdia/greenlight/internal/validator"
 	"github.com/terdia/greenlight/src/movies/entities"
 	"github.com/terdia/greenlight/src/movies/repositories"
 )
 
-type MovieValidationErrors map[string]string
+type MovieValidationErrors = validator.Errors
 
 type MovieService interface {
-	Create(movie *entities.Movie) (MovieValidationErrors, error)
-	GetById(id int64) (*entities.Movie, error)
-	Update(id int64, request dto.MovieRequest) (*entities.Movie, MovieValidationErrors, error)
-	Delete(id int64) error
-	List(listMovieRequest dto.ListMovieRequest) ([]*entities.Movie, data.Metadata, error)
+	Create(ctx context.Context, movie *entities.Movie) (MovieValidationErrors, error)
+	GetById(ctx context.Context, id int64) (*entities.Movie, error)
+	Update(ctx context.Context, id int64, request dto.MovieRequest) (*entities.Movie, MovieValidationErrors, error)
+	Replace(ctx context.Context, id int64, request dto.MovieRequest) (*entities.Movie, MovieValidationErrors, error)
+	Patch(ctx context.Context, id int64, p patch.Patch) (*entities.Movie, MovieValidationErrors, error)
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context, listMovieRequest dto.ListMovieRequest) ([]*entities.Movie, data.Metadata, error)
+	Facets(ctx context.Context, listMovieRequest dto.ListMovieRequest, facets []string) (data.Facets, error)
+	Similar(ctx context.Context, movie *entities.Movie, limit int) ([]*entities.Movie, error)
+	Export(ctx context.Context, listMovieRequest dto.ListMovieRequest, fn func(movie *entities.Movie) error) error
+	Batch(ctx context.Context, request dto.BatchMovieRequest) ([]BatchResult, MovieValidationErrors, error)
+	CreateImportJob(format string, dryRun bool) *entities.ImportJob
+	RunImport(ctx context.Context, id custom_type.ID, r io.Reader) error
+	GetImportJob(id int64) (*entities.ImportJob, error)
+	Suggest(ctx context.Context, prefix string, limit int) (movies []*entities.Movie, cold bool, err error)
+	LoadSuggestIndex(ctx context.Context) error
 }
 
 type movieService struct {
-	repo repositories.MovieRepository
+	repo    repositories.MovieRepository
+	uow     unitofwork.UnitOfWork
+	imports *importJobStore
+	titles  *titleIndex
 }
 
-func NewMovieService(repo repositories.MovieRepository) MovieService {
-	return &movieService{repo: repo}
+func NewMovieService(repo repositories.MovieRepository, uow unitofwork.UnitOfWork) MovieService {
+	return &movieService{repo: repo, uow: uow, imports: newImportJobStore(), titles: newTitleIndex(suggestIndexTTL)}
 }
 
-func (srv *movieService) Create(movie *entities.Movie) (MovieValidationErrors, error) {
-	v := validator.New()
+func (srv *movieService) Create(ctx context.Context, movie *entities.Movie) (MovieValidationErrors, error) {
+	v := newMovieValidator()
+
+	if movie.Language == "" {
+		movie.Language = data.DefaultLanguage
+	}
 
 	if validateMovie(v, movie); !v.Valid() {
 		return v.Errors, nil
 	}
 
-	return nil, srv.repo.Insert(movie)
+	if err := srv.repo.Insert(ctx, movie); err != nil {
+		return nil, err
+	}
+
+	srv.titles.put(int64(movie.ID), movie.Title)
+
+	return nil, nil
 }
 
-func (srv *movieService) GetById(id int64) (*entities.Movie, error) {
-	return srv.repo.Get(id)
+func (srv *movieService) GetById(ctx context.Context, id int64) (*entities.Movie, error) {
+	return srv.repo.Get(ctx, id)
+}
+
+func (srv *movieService) Update(ctx context.Context, id int64, request dto.MovieRequest) (*entities.Movie, MovieValidationErrors, error) {
+
+	movie, err := srv.GetById(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updateMovie(movie, request)
+
+	v := newMovieValidator()
+
+	if validateMovie(v, movie); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return movie, nil, srv.update(ctx, movie)
 }
 
-func (srv *movieService) Update(id int64, request dto.MovieRequest) (*entities.Movie, MovieValidationErrors, error) {
+// Replace overwrites every field of the movie with the request, fields missing
+// from the request are cleared and reported by validateMovie.
+func (srv *movieService) Replace(ctx context.Context, id int64, request dto.MovieRequest) (*entities.Movie, MovieValidationErrors, error) {
+
+	movie, err := srv.GetById(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	replaceMovie(movie, request)
+
+	v := newMovieValidator()
+
+	if validateMovie(v, movie); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return movie, nil, srv.update(ctx, movie)
+}
+
+// Patch applies a JSON Merge Patch or JSON Patch document to the request representation
+// of the movie, the patched document then replaces the movie.
+func (srv *movieService) Patch(ctx context.Context, id int64, p patch.Patch) (*entities.Movie, MovieValidationErrors, error) {
+
+	movie, err := srv.GetById(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := json.Marshal(getMovieRequest(movie))
+	if err != nil {
+		return nil, nil, err
+	}
 
-	movie, err := srv.GetById(id)
+	doc, err = p.Apply(doc)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+
+	var request dto.MovieRequest
+	if err := decoder.Decode(&request); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", patch.ErrInvalidPatch, err)
+	}
+
+	replaceMovie(movie, request)
+
+	v := newMovieValidator()
+
+	if validateMovie(v, movie); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return movie, nil, srv.update(ctx, movie)
+}
+
+func (srv *movieService) Delete(ctx context.Context, id int64) error {
+	if err := srv.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	srv.titles.remove(id)
+
+	return nil
+}
+
+// update saves the movie and keeps the title index fresh.
+func (srv *movieService) update(ctx context.Context, movie *entities.Movie) error {
+	if err := srv.repo.Update(ctx, movie); err != nil {
+		return err
+	}
+
+	srv.titles.put(int64(movie.ID), movie.Title)
+
+	return nil
+}
+
+func (srv *movieService) List(ctx context.Context, listMovieRequest dto.ListMovieRequest) ([]*entities.Movie, data.Metadata, error) {
+	return srv.repo.GetAll(ctx, listMovieRequest)
+}
+
+func (srv *movieService) Facets(ctx context.Context, listMovieRequest dto.ListMovieRequest, facets []string) (data.Facets, error) {
+	return srv.repo.Facets(ctx, listMovieRequest, facets)
+}
+
+// Similar returns up to limit other movies sharing a genre with movie, newest first.
+func (srv *movieService) Similar(ctx context.Context, movie *entities.Movie, limit int) ([]*entities.Movie, error) {
+
+	movies, _, err := srv.repo.GetAll(ctx, dto.ListMovieRequest{
+		Genres:     movie.Genres,
+		GenresMode: data.GenresModeAny,
+		Filter:     &filter.Comparison{Field: filter.Field{Column: "id", Type: filter.Number}, Op: "!=", Value: int64(movie.ID)},
+		Filters: data.Filters{
+			Page:         1,
+			PageSize:     limit,
+			Sort:         "-year",
+			SortSafelist: []string{"-year"},
+		},
+	})
+
+	return movies, err
+}
+
+func (srv *movieService) Export(ctx context.Context, listMovieRequest dto.ListMovieRequest, fn func(movie *entities.Movie) error) error {
+	return srv.repo.Export(ctx, listMovieRequest, fn)
+}
+
+// updateMovie copies the fields present in the request onto movie.
+func updateMovie(movie *entities.Movie, request dto.MovieRequest) {
+
 	if request.Title != nil {
 		movie.Title = *request.Title
 	}
@@ -65,34 +220,36 @@ func (srv *movieService) Update(id int64, request dto.MovieRequest) (*entities.M
 		movie.Genres = request.Genres
 	}
 
-	v := validator.New()
-
-	if validateMovie(v, movie); !v.Valid() {
-		return nil, v.Errors, nil
+	if request.Language != nil {
+		movie.Language = *request.Language
 	}
+}
 
-	return movie, nil, srv.repo.Update(movie)
+// replaceMovie overwrites every field of movie, fields missing from the request are cleared
+// and the language is reset to the default.
+func replaceMovie(movie *entities.Movie, request dto.MovieRequest) {
+
+	movie.Title, movie.Year, movie.Runtime, movie.Genres, movie.Language = "", 0, 0, nil, data.DefaultLanguage
+
+	updateMovie(movie, request)
 }
 
-func (srv *movieService) Delete(id int64) error {
-	return srv.repo.Delete(id)
+func getMovieRequest(movie *entities.Movie) dto.MovieRequest {
+	return dto.MovieRequest{
+		Title:    &movie.Title,
+		Year:     &movie.Year,
+		Runtime:  &movie.Runtime,
+		Genres:   movie.Genres,
+		Language: &movie.Language,
+	}
 }
 
-func (srv *movieService) List(listMovieRequest dto.ListMovieRequest) ([]*entities.Movie, data.Metadata, error) {
-	return srv.repo.GetAll(listMovieRequest)
+// newMovieValidator returns a validator with the rules of the validate tags of dto.MovieRequest.
+func newMovieValidator() *validator.Validator {
+	return validator.New().Use(data.Rules)
 }
 
+// validateMovie checks the movie against the validate tags of dto.MovieRequest.
 func validateMovie(v *validator.Validator, movie *entities.Movie) {
-
-	v.Check(movie.Title != "", "title", "must be provided")
-	v.Check(len(movie.Title) <= 500, "title", "must not be more than 500 bytes long")
-	v.Check(movie.Year != 0, "year", "must be provided")
-	v.Check(movie.Year >= 1888, "year", "must be greater than 1888")
-	v.Check(movie.Year <= int32(time.Now().Year()), "year", "must not be in the future")
-	v.Check(movie.Runtime != 0, "runtime", "must be provided")
-	v.Check(movie.Runtime > 0, "runtime", "must be a positive integer")
-	v.Check(movie.Genres != nil, "genres", "must be provided")
-	v.Check(len(movie.Genres) >= 1, "genres", "must contain at least 1 genre")
-	v.Check(len(movie.Genres) <= 5, "genres", "must not contain more than 5 genres")
-	v.Check(validator.UniqueStringSlice(movie.Genres), "genres", "must not contain duplicate values")
+	v.Struct(getMovieRequest(movie))
 }
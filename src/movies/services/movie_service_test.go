@@ -0,0 +1,48 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/terdia/greenlight/src/movies/entities"
+)
+
+func TestValidateMovie(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		movie entities.Movie
+		want  map[string]string
+	}{
+		{
+			name:  "Valid",
+			movie: entities.Movie{Title: "Casablanca", Year: 1942, Runtime: 102, Genres: []string{"drama"}, Language: "english"},
+			want:  map[string]string{},
+		},
+		{
+			name:  "Invalid",
+			movie: entities.Movie{Title: "Casablanca", Year: 1700, Runtime: -1, Genres: []string{}, Language: "klingon"},
+			want: map[string]string{
+				"year":     "must be greater than 1888",
+				"runtime":  "must be a positive integer",
+				"genres":   "must contain at least 1 genre",
+				"language": "must be a supported language e.g. english",
+			},
+		},
+		{
+			name:  "Too many genres",
+			movie: entities.Movie{Title: "Casablanca", Year: 1942, Runtime: 102, Genres: []string{"a", "b", "c", "d", "e", "f"}, Language: "english"},
+			want:  map[string]string{"genres": "must not contain more than 5 genres"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := newMovieValidator()
+
+			if validateMovie(v, &test.movie); !reflect.DeepEqual(v.Errors.Flat(), test.want) {
+				t.Errorf("want %v; got %v", test.want, v.Errors.Flat())
+			}
+		})
+	}
+}
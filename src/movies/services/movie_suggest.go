@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/custom_type"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/src/movies/entities"
+)
+
+const (
+	titleIndexCold = iota
+	titleIndexLoading
+	titleIndexReady
+)
+
+// suggestIndexTTL is how long a loaded title index serves suggestions before it is dropped and
+// loaded again from the database.
+const suggestIndexTTL = 5 * time.Minute
+
+// Suggest returns up to limit movies with a title word starting with prefix, whole title
+// matches first. Only the ID and Title of the movies are set when they come from the title
+// index, while the index is cold they are read from the database and cold is true, the
+// index can then be loaded with LoadSuggestIndex.
+func (srv *movieService) Suggest(ctx context.Context, prefix string, limit int) (movies []*entities.Movie, cold bool, err error) {
+
+	if movies, ok := srv.titles.suggest(prefix, limit); ok {
+		return movies, false, nil
+	}
+
+	movies, _, err = srv.repo.GetAll(ctx, dto.ListMovieRequest{
+		Title:      prefix,
+		SearchMode: data.SearchModePrefix,
+		Filters: data.Filters{
+			Page:         1,
+			PageSize:     limit,
+			Sort:         "-relevance",
+			SortSafelist: []string{"-relevance"},
+		},
+	})
+
+	return movies, true, err
+}
+
+// LoadSuggestIndex reads every title into the title index, it returns straight away when
+// the index is already loaded or being loaded.
+func (srv *movieService) LoadSuggestIndex(ctx context.Context) error {
+
+	generation, ok := srv.titles.startLoading()
+	if !ok {
+		return nil
+	}
+
+	titles := map[int64]string{}
+
+	err := srv.repo.Export(ctx, dto.ListMovieRequest{}, func(movie *entities.Movie) error {
+		titles[int64(movie.ID)] = movie.Title
+		return nil
+	})
+
+	srv.titles.finishLoading(generation, titles, err)
+
+	return err
+}
+
+// titleIndex is an in-process prefix index of the movie titles for type-ahead suggestions.
+// Titles are normalised to lower case words and kept in two sorted arrays, one of whole
+// titles and one of every title from each of its other words onward, so "godf" matches
+// "Godfather" first and then "The Godfather".
+//
+// The index is kept fresh with put and remove as movies are written by this process, changes
+// made while it is loading are replayed once the load completes. Movies written elsewhere, by
+// another replica, the import and seed subcommands or plain SQL, only show up once the index
+// expires ttl after its load, so suggestions may be up to ttl behind the database.
+type titleIndex struct {
+	mu         sync.RWMutex
+	state      int
+	generation int
+	ttl        time.Duration
+	loadedAt   time.Time
+	titles     []titleKey
+	words      []titleKey
+	movies     map[int64]string  // title by movie id
+	pending    map[int64]*string // changes made while loading, nil when the movie was deleted
+}
+
+type titleKey struct {
+	key string
+	id  int64
+}
+
+func newTitleIndex(ttl time.Duration) *titleIndex {
+	return &titleIndex{ttl: ttl}
+}
+
+func (idx *titleIndex) put(id int64, title string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	switch idx.state {
+	case titleIndexLoading:
+		idx.pending[id] = &title
+	case titleIndexReady:
+		idx.removeLocked(id)
+		idx.insertLocked(id, title)
+	}
+}
+
+func (idx *titleIndex) remove(id int64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	switch idx.state {
+	case titleIndexLoading:
+		idx.pending[id] = nil
+	case titleIndexReady:
+		idx.removeLocked(id)
+	}
+}
+
+// invalidate marks the index cold e.g. after a bulk import, a load in progress is discarded.
+func (idx *titleIndex) invalidate() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.invalidateLocked()
+}
+
+func (idx *titleIndex) expired() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return idx.expiredLocked()
+}
+
+// expire invalidates the index once it has been loaded for longer than its ttl.
+func (idx *titleIndex) expire() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.expiredLocked() {
+		idx.invalidateLocked()
+	}
+}
+
+func (idx *titleIndex) expiredLocked() bool {
+	return idx.state == titleIndexReady && time.Since(idx.loadedAt) >= idx.ttl
+}
+
+func (idx *titleIndex) invalidateLocked() {
+	idx.state = titleIndexCold
+	idx.generation++
+	idx.titles, idx.words, idx.movies, idx.pending = nil, nil, nil, nil
+}
+
+func (idx *titleIndex) startLoading() (generation int, ok bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.state != titleIndexCold {
+		return 0, false
+	}
+
+	idx.state = titleIndexLoading
+	idx.generation++
+	idx.pending = map[int64]*string{}
+
+	return idx.generation, true
+}
+
+func (idx *titleIndex) finishLoading(generation int, titles map[int64]string, err error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if generation != idx.generation || idx.state != titleIndexLoading {
+		return
+	}
+
+	if err != nil {
+		idx.state, idx.pending = titleIndexCold, nil
+		return
+	}
+
+	for id, title := range idx.pending {
+		if title == nil {
+			delete(titles, id)
+		} else {
+			titles[id] = *title
+		}
+	}
+
+	idx.movies, idx.titles, idx.words, idx.pending = titles, nil, nil, nil
+
+	for id, title := range titles {
+		whole, words := titleKeys(title)
+
+		idx.titles = append(idx.titles, titleKey{whole, id})
+		for _, key := range words {
+			idx.words = append(idx.words, titleKey{key, id})
+		}
+	}
+
+	sortTitleKeys(idx.titles)
+	sortTitleKeys(idx.words)
+
+	idx.state, idx.loadedAt = titleIndexReady, time.Now()
+}
+
+// suggest returns up to limit movies matching prefix, ok is false when the index isn't ready
+// or has expired, an expired index is invalidated so it is loaded again.
+func (idx *titleIndex) suggest(prefix string, limit int) (movies []*entities.Movie, ok bool) {
+
+	if idx.expired() {
+		idx.expire()
+		return nil, false
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if idx.state != titleIndexReady {
+		return nil, false
+	}
+
+	prefix, _ = titleKeys(prefix)
+
+	movies = []*entities.Movie{}
+	seen := map[int64]bool{}
+
+	for _, keys := range [][]titleKey{idx.titles, idx.words} {
+		for i := searchTitleKeys(keys, titleKey{prefix, 0}); i < len(keys) && len(movies) < limit; i++ {
+			if !strings.HasPrefix(keys[i].key, prefix) {
+				break
+			}
+
+			if id := keys[i].id; !seen[id] {
+				seen[id] = true
+				movies = append(movies, &entities.Movie{ID: custom_type.ID(id), Title: idx.movies[id]})
+			}
+		}
+	}
+
+	return movies, true
+}
+
+func (idx *titleIndex) insertLocked(id int64, title string) {
+
+	whole, words := titleKeys(title)
+
+	idx.movies[id] = title
+	idx.titles = insertTitleKey(idx.titles, titleKey{whole, id})
+	for _, key := range words {
+		idx.words = insertTitleKey(idx.words, titleKey{key, id})
+	}
+}
+
+func (idx *titleIndex) removeLocked(id int64) {
+
+	title, exists := idx.movies[id]
+	if !exists {
+		return
+	}
+
+	whole, words := titleKeys(title)
+
+	delete(idx.movies, id)
+	idx.titles = removeTitleKey(idx.titles, titleKey{whole, id})
+	for _, key := range words {
+		idx.words = removeTitleKey(idx.words, titleKey{key, id})
+	}
+}
+
+// titleKeys returns the normalised title, and the title from each of its other words onward
+// e.g. "The Godfather: Part II" gives "the godfather part ii" and "godfather part ii", "part ii", "ii".
+func titleKeys(title string) (whole string, words []string) {
+
+	fields := strings.FieldsFunc(strings.ToLower(title), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+
+	for i := 1; i < len(fields); i++ {
+		words = append(words, strings.Join(fields[i:], " "))
+	}
+
+	return strings.Join(fields, " "), words
+}
+
+func lessTitleKey(a, b titleKey) bool {
+	return a.key < b.key || (a.key == b.key && a.id < b.id)
+}
+
+func sortTitleKeys(keys []titleKey) {
+	sort.Slice(keys, func(i, j int) bool { return lessTitleKey(keys[i], keys[j]) })
+}
+
+// searchTitleKeys returns the index of the first key not less than key.
+func searchTitleKeys(keys []titleKey, key titleKey) int {
+	return sort.Search(len(keys), func(i int) bool { return !lessTitleKey(keys[i], key) })
+}
+
+func insertTitleKey(keys []titleKey, key titleKey) []titleKey {
+	i := searchTitleKeys(keys, key)
+
+	keys = append(keys, titleKey{})
+	copy(keys[i+1:], keys[i:])
+	keys[i] = key
+
+	return keys
+}
+
+func removeTitleKey(keys []titleKey, key titleKey) []titleKey {
+	if i := searchTitleKeys(keys, key); i < len(keys) && keys[i] == key {
+		keys = append(keys[:i], keys[i+1:]...)
+	}
+
+	return keys
+}
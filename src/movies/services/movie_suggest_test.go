@@ -0,0 +1,112 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func suggestedTitles(t *testing.T, idx *titleIndex, prefix string, limit int) []string {
+	t.Helper()
+
+	movies, ok := idx.suggest(prefix, limit)
+	if !ok {
+		t.Fatalf("want index to be ready")
+	}
+
+	titles := []string{}
+	for _, movie := range movies {
+		titles = append(titles, movie.Title)
+	}
+
+	return titles
+}
+
+func TestTitleIndex(t *testing.T) {
+
+	idx := newTitleIndex(time.Hour)
+
+	if _, ok := idx.suggest("god", 10); ok {
+		t.Fatalf("want cold index")
+	}
+
+	generation, ok := idx.startLoading()
+	if !ok {
+		t.Fatalf("want load to start")
+	}
+
+	if _, ok := idx.startLoading(); ok {
+		t.Fatalf("want a single load at a time")
+	}
+
+	// changes made while loading are replayed over the loaded titles
+	idx.put(4, "Godzilla")
+	idx.remove(3)
+
+	idx.finishLoading(generation, map[int64]string{
+		1: "The Godfather",
+		2: "Godfather: Part II",
+		3: "God's Own Country",
+	}, nil)
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"Whole title matches first", "godf", 10, []string{"Godfather: Part II", "The Godfather"}},
+		{"Case and punctuation are ignored", "GODFATHER part", 10, []string{"Godfather: Part II"}},
+		{"Limit", "god", 2, []string{"Godfather: Part II", "Godzilla"}},
+		{"Deleted while loading", "god's", 10, []string{}},
+		{"No match", "alien", 10, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := suggestedTitles(t, idx, test.prefix, test.limit); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("want %v; got %v", test.want, got)
+			}
+		})
+	}
+
+	idx.put(1, "Alien")
+	idx.remove(2)
+
+	if got, want := suggestedTitles(t, idx, "godf", 10), []string{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v; got %v", want, got)
+	}
+
+	if got, want := suggestedTitles(t, idx, "ali", 10), []string{"Alien"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v; got %v", want, got)
+	}
+
+	idx.invalidate()
+
+	if _, ok := idx.suggest("ali", 10); ok {
+		t.Errorf("want cold index after invalidate")
+	}
+}
+
+func TestTitleIndexExpiry(t *testing.T) {
+
+	idx := newTitleIndex(time.Hour)
+
+	generation, _ := idx.startLoading()
+	idx.finishLoading(generation, map[int64]string{1: "Alien"}, nil)
+
+	if got, want := suggestedTitles(t, idx, "ali", 10), []string{"Alien"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v; got %v", want, got)
+	}
+
+	// the index was loaded an hour ago
+	idx.loadedAt = idx.loadedAt.Add(-time.Hour)
+
+	if _, ok := idx.suggest("ali", 10); ok {
+		t.Fatalf("want expired index")
+	}
+
+	if _, ok := idx.startLoading(); !ok {
+		t.Errorf("want expired index to be loaded again")
+	}
+}
@@ -13,3 +13,10 @@ type Token struct {
 	Expiry    time.Time
 	Scope     string
 }
+
+// TokenCounts is the number of tokens which are still valid by scope, and of expired tokens
+// which have not been deleted yet.
+type TokenCounts struct {
+	Active  map[string]int
+	Expired int
+}
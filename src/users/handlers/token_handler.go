@@ -32,7 +32,7 @@ func (handler *userHandler) GetAuthenticationToken(rw http.ResponseWriter, r *ht
 		return
 	}
 
-	token, validationErrors, err := handler.service.CreateAuthenticationToken(request, data.TokenScopeAuthentication)
+	token, validationErrors, err := handler.service.CreateAuthenticationToken(r.Context(), request, data.TokenScopeAuthentication)
 	if validationErrors != nil {
 		utils.FailedValidationResponse(rw, r, validationErrors)
 
@@ -56,7 +56,7 @@ func (handler *userHandler) GetAuthenticationToken(rw http.ResponseWriter, r *ht
 		Expiry:    token.Expiry,
 	}
 
-	err = handler.sharedUtil.WriteJson(rw, http.StatusOK, commons.ResponseObject{
+	err = handler.sharedUtil.WriteResponse(rw, r, http.StatusOK, commons.ResponseObject{
 		StatusMsg: custom_type.Success,
 		Data: dto.TokenResponse{
 			Token: tokenDto,
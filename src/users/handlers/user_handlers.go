@@ -4,14 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/terdia/greenlight/infrastructures/dto"
 	"github.com/terdia/greenlight/internal/commons"
 	"github.com/terdia/greenlight/internal/custom_type"
 	"github.com/terdia/greenlight/internal/data"
 	"github.com/terdia/greenlight/src/users/entities"
-	"github.com/terdia/greenlight/src/users/repositories"
 	"github.com/terdia/greenlight/src/users/services"
 )
 
@@ -22,23 +20,14 @@ type UserHandler interface {
 }
 
 type userHandler struct {
-	sharedUtil           commons.SharedUtil
-	service              services.UserService
-	tokenService         services.TokenService
-	permissionRepository repositories.PermissionRepository
+	sharedUtil commons.SharedUtil
+	service    services.UserService
 }
 
-func NewUserHandler(
-	utils commons.SharedUtil,
-	srv services.UserService,
-	tokenService services.TokenService,
-	permissionRepository repositories.PermissionRepository,
-) UserHandler {
+func NewUserHandler(utils commons.SharedUtil, srv services.UserService) UserHandler {
 	return &userHandler{
-		sharedUtil:           utils,
-		service:              srv,
-		tokenService:         tokenService,
-		permissionRepository: permissionRepository,
+		sharedUtil: utils,
+		service:    srv,
 	}
 }
 
@@ -64,7 +53,7 @@ func (handler *userHandler) CreateUser(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user, validationErrors, err := handler.service.Create(request)
+	user, token, validationErrors, err := handler.service.Create(r.Context(), request)
 	if validationErrors != nil {
 		utils.FailedValidationResponse(rw, r, validationErrors)
 
@@ -77,20 +66,7 @@ func (handler *userHandler) CreateUser(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = handler.permissionRepository.AddForUser(user.ID, "movies:read")
-	if err != nil {
-		utils.ServerErrorResponse(rw, r, err)
-
-		return
-	}
-
 	idString, _ := custom_type.EncodeId(int(user.ID))
-	token, err := handler.tokenService.CreateNew(user.ID, 3*24*time.Hour, data.TokenScopeActivation)
-	if err != nil {
-		utils.ServerErrorResponse(rw, r, err)
-
-		return
-	}
 
 	// send welocme email using background process
 	utils.Background(func() {
@@ -116,7 +92,7 @@ func (handler *userHandler) CreateUser(rw http.ResponseWriter, r *http.Request)
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/users/%s", idString))
 
-	err = handler.sharedUtil.WriteJson(rw, http.StatusCreated, commons.ResponseObject{
+	err = handler.sharedUtil.WriteResponse(rw, r, http.StatusCreated, commons.ResponseObject{
 		StatusMsg: custom_type.Success,
 		Data: dto.SingleUserResponse{
 			User: getUserResponse(user),
@@ -151,7 +127,7 @@ func (handler *userHandler) ActivateUser(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
-	user, validationErrors, err := handler.service.ActivateUser(request)
+	user, validationErrors, err := handler.service.ActivateUser(r.Context(), request)
 	if validationErrors != nil {
 		utils.FailedValidationResponse(rw, r, validationErrors)
 
@@ -168,23 +144,7 @@ func (handler *userHandler) ActivateUser(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// run background task to delete tokens
-	utils.Background(func() {
-		plainTokenText := request.TokenPlaintext
-		err := handler.tokenService.DeleteByUserIdAndScope(user.ID, data.TokenScopeActivation)
-
-		if err != nil {
-			encodedUserId, err := custom_type.EncodeId(int(user.ID))
-			utils.LogErrorWithContext(err, map[string]string{
-				"task":            "user activation, delete token for user by scope",
-				"userId":          encodedUserId,
-				"activationToken": plainTokenText,
-			})
-		}
-
-	})
-
-	err = handler.sharedUtil.WriteJson(rw, http.StatusOK, commons.ResponseObject{
+	err = handler.sharedUtil.WriteResponse(rw, r, http.StatusOK, commons.ResponseObject{
 		StatusMsg: custom_type.Success,
 		Data: dto.SingleUserResponse{
 			User: getUserResponse(user),
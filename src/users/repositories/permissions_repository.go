@@ -1,11 +1,14 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/terdia/greenlight/internal/custom_type"
 	"github.com/terdia/greenlight/internal/data"
 )
 
 type PermissionRepository interface {
-	GetAllForUser(userID custom_type.ID) (data.Permissions, error)
-	AddForUser(userID custom_type.ID, codes ...string) error
+	GetAllForUser(ctx context.Context, userID custom_type.ID) (data.Permissions, error)
+	AddForUser(ctx context.Context, userID custom_type.ID, codes ...string) error
+	RemoveForUser(ctx context.Context, userID custom_type.ID, codes ...string) error
 }
@@ -1,11 +1,16 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/terdia/greenlight/internal/custom_type"
 	"github.com/terdia/greenlight/src/users/entities"
 )
 
 type TokenRepository interface {
-	Create(token *entities.Token) error
-	DeleteAllForUserByScope(scope string, userID custom_type.ID) error
+	Create(ctx context.Context, token *entities.Token) error
+	DeleteAllForUserByScope(ctx context.Context, scope string, userID custom_type.ID) error
+	// DeleteExpired deletes the expired tokens of every user and returns how many were deleted.
+	DeleteExpired(ctx context.Context) (int64, error)
+	Count(ctx context.Context) (entities.TokenCounts, error)
 }
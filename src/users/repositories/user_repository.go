@@ -1,10 +1,15 @@
 package repositories
 
-import "github.com/terdia/greenlight/src/users/entities"
+import (
+	"context"
+
+	"github.com/terdia/greenlight/src/users/entities"
+)
 
 type UserRepository interface {
-	Insert(user *entities.User) error
-	Update(user *entities.User) error
-	GetByEmail(email string) (*entities.User, error)
-	GetForToken(tokenPlainText, scope string) (*entities.User, error)
+	Insert(ctx context.Context, user *entities.User) error
+	Update(ctx context.Context, user *entities.User) error
+	GetByEmail(ctx context.Context, email string) (*entities.User, error)
+	GetForToken(ctx context.Context, tokenPlainText, scope string) (*entities.User, error)
+	Count(ctx context.Context) (entities.UserCounts, error)
 }
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/internal/unitofwork"
+	"github.com/terdia/greenlight/src/users/entities"
+	"github.com/terdia/greenlight/src/users/repositories"
+)
+
+var ErrUnknownPermission = errors.New("unknown permission")
+
+// AdminService runs the operational tasks of the admin CLI. Users are found by email, and a
+// missing user is reported with data.ErrRecordNotFound.
+type AdminService interface {
+	CreateUser(ctx context.Context, request dto.CreateUserRequest, activate bool, codes ...string) (*entities.User, *entities.Token, data.Permissions, UserValidationErrors, error)
+	ActivateUser(ctx context.Context, email string) (*entities.User, error)
+	GrantPermissions(ctx context.Context, email string, codes ...string) (data.Permissions, error)
+	RevokePermissions(ctx context.Context, email string, codes ...string) (data.Permissions, error)
+	RevokeTokens(ctx context.Context, email string, scopes ...string) error
+	PurgeExpiredTokens(ctx context.Context) (int64, error)
+	CountUsers(ctx context.Context) (entities.UserCounts, error)
+	CountTokens(ctx context.Context) (entities.TokenCounts, error)
+}
+
+type adminService struct {
+	users           repositories.UserRepository
+	tokens          repositories.TokenRepository
+	passHashService PasswordHashService
+	uow             unitofwork.UnitOfWork
+}
+
+func NewAdminService(
+	users repositories.UserRepository,
+	tokens repositories.TokenRepository,
+	passHashService PasswordHashService,
+	uow unitofwork.UnitOfWork,
+) AdminService {
+	return &adminService{users: users, tokens: tokens, passHashService: passHashService, uow: uow}
+}
+
+// CreateUser signs up a user like UserService.Create, activated when activate is set and
+// granted the permissions of codes besides the default ones. Everything is saved in one unit of
+// work, so a failure never leaves a user without the requested activation or permissions. The
+// activation token is nil for an activated user.
+func (srv *adminService) CreateUser(
+	ctx context.Context,
+	request dto.CreateUserRequest,
+	activate bool,
+	codes ...string,
+) (*entities.User, *entities.Token, data.Permissions, UserValidationErrors, error) {
+
+	if err := checkPermissionCodes(codes); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	user, errs, err := newUser(request, srv.passHashService)
+	if err != nil || errs != nil {
+		return nil, nil, nil, errs, err
+	}
+
+	user.Activated = activate
+
+	var token *entities.Token
+	var permissions data.Permissions
+
+	err = srv.uow.Do(ctx, func(repos unitofwork.Repositories) error {
+
+		token, err = insertUser(ctx, repos, user)
+		if err != nil {
+			return err
+		}
+
+		if len(codes) > 0 {
+			if err := repos.Permissions.AddForUser(ctx, user.ID, codes...); err != nil {
+				return err
+			}
+		}
+
+		permissions, err = repos.Permissions.GetAllForUser(ctx, user.ID)
+
+		return err
+	})
+
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateEmail) {
+			return nil, nil, nil, duplicateEmailErrors(), nil
+		}
+
+		return nil, nil, nil, nil, err
+	}
+
+	return user, token, permissions, nil, nil
+}
+
+// ActivateUser activates the user without an activation token, and deletes the activation
+// tokens already sent to them.
+func (srv *adminService) ActivateUser(ctx context.Context, email string) (*entities.User, error) {
+
+	var user *entities.User
+
+	err := srv.uow.Do(ctx, func(repos unitofwork.Repositories) error {
+
+		var err error
+
+		user, err = repos.Users.GetByEmail(ctx, email)
+		if err != nil {
+			return err
+		}
+
+		if !user.Activated {
+			user.Activated = true
+
+			if err := repos.Users.Update(ctx, user); err != nil {
+				return err
+			}
+		}
+
+		return repos.Tokens.DeleteAllForUserByScope(ctx, data.TokenScopeActivation, user.ID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GrantPermissions grants the permissions to the user and returns all the permissions of the
+// user.
+func (srv *adminService) GrantPermissions(ctx context.Context, email string, codes ...string) (data.Permissions, error) {
+	return srv.changePermissions(ctx, email, codes, func(repos unitofwork.Repositories, user *entities.User) error {
+		return repos.Permissions.AddForUser(ctx, user.ID, codes...)
+	})
+}
+
+// RevokePermissions revokes the permissions of the user and returns the permissions the user
+// still has.
+func (srv *adminService) RevokePermissions(ctx context.Context, email string, codes ...string) (data.Permissions, error) {
+	return srv.changePermissions(ctx, email, codes, func(repos unitofwork.Repositories, user *entities.User) error {
+		return repos.Permissions.RemoveForUser(ctx, user.ID, codes...)
+	})
+}
+
+func (srv *adminService) changePermissions(
+	ctx context.Context,
+	email string,
+	codes []string,
+	change func(repos unitofwork.Repositories, user *entities.User) error,
+) (data.Permissions, error) {
+
+	if err := checkPermissionCodes(codes); err != nil {
+		return nil, err
+	}
+
+	var permissions data.Permissions
+
+	err := srv.uow.Do(ctx, func(repos unitofwork.Repositories) error {
+
+		user, err := repos.Users.GetByEmail(ctx, email)
+		if err != nil {
+			return err
+		}
+
+		if err := change(repos, user); err != nil {
+			return err
+		}
+
+		permissions, err = repos.Permissions.GetAllForUser(ctx, user.ID)
+
+		return err
+	})
+
+	return permissions, err
+}
+
+// checkPermissionCodes returns ErrUnknownPermission for the first unknown code, the repositories
+// ignore unknown codes and a typo must not go unnoticed.
+func checkPermissionCodes(codes []string) error {
+
+	for _, code := range codes {
+		if !data.Permissions(data.PermissionCodes).Includes(code) {
+			return fmt.Errorf("%w %q", ErrUnknownPermission, code)
+		}
+	}
+
+	return nil
+}
+
+// RevokeTokens deletes the tokens of the user in the scopes, so they have to authenticate or
+// be sent a new activation token again.
+func (srv *adminService) RevokeTokens(ctx context.Context, email string, scopes ...string) error {
+	return srv.uow.Do(ctx, func(repos unitofwork.Repositories) error {
+
+		user, err := repos.Users.GetByEmail(ctx, email)
+		if err != nil {
+			return err
+		}
+
+		for _, scope := range scopes {
+			if err := repos.Tokens.DeleteAllForUserByScope(ctx, scope, user.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (srv *adminService) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	return srv.tokens.DeleteExpired(ctx)
+}
+
+func (srv *adminService) CountUsers(ctx context.Context) (entities.UserCounts, error) {
+	return srv.users.Count(ctx)
+}
+
+func (srv *adminService) CountTokens(ctx context.Context) (entities.TokenCounts, error) {
+	return srv.tokens.Count(ctx)
+}
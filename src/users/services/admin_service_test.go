@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/terdia/greenlight/infrastructures/dto"
+	"github.com/terdia/greenlight/infrastructures/persistence/memory"
+	"github.com/terdia/greenlight/internal/data"
+	"github.com/terdia/greenlight/src/users/entities"
+	"github.com/terdia/greenlight/src/users/repositories"
+)
+
+func newTestAdminService(t *testing.T) (AdminService, *entities.User, repositories.TokenRepository) {
+
+	store := memory.NewStore()
+	users, tokens := memory.NewUserRepository(store), memory.NewTokenRepository(store)
+
+	user := &entities.User{Name: "Alice", Email: "alice@example.com", Password: entities.Password{Hash: []byte("hash")}}
+	if err := users.Insert(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewAdminService(users, tokens, NewPasswordService(), memory.NewUnitOfWork(store)), user, tokens
+}
+
+func TestAdminCreateUser(t *testing.T) {
+
+	ctx := context.Background()
+	srv, _, _ := newTestAdminService(t)
+
+	tests := []struct {
+		name            string
+		email           string
+		activate        bool
+		codes           []string
+		wantPermissions data.Permissions
+		wantErrors      map[string]string
+		wantErr         error
+	}{
+		{name: "Inactive", email: "bob@example.com", wantPermissions: data.Permissions{"movies:read"}},
+		{name: "Activated with permissions", email: "carol@example.com", activate: true, codes: []string{"movies:write"}, wantPermissions: data.Permissions{"movies:read", "movies:write"}},
+		{name: "Duplicate email", email: "Alice@example.com", wantErrors: map[string]string{"email": data.ErrDuplicateEmail.Error()}},
+		{name: "Unknown permission", email: "dave@example.com", activate: true, codes: []string{"movies:delete"}, wantErr: ErrUnknownPermission},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := dto.CreateUserRequest{Name: "User", Email: test.email, Password: "pa55word"}
+
+			user, token, permissions, errs, err := srv.CreateUser(ctx, request, test.activate, test.codes...)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("want error %v; got %v", test.wantErr, err)
+			}
+
+			if test.wantErrors != nil {
+				if !reflect.DeepEqual(errs.Flat(), test.wantErrors) {
+					t.Errorf("want validation errors %v; got %v", test.wantErrors, errs.Flat())
+				}
+
+				return
+			}
+
+			if test.wantErr != nil {
+				return
+			}
+
+			if user.Activated != test.activate || (token == nil) != test.activate {
+				t.Errorf("want user activated %t with an activation token %t; got %+v and %v", test.activate, !test.activate, user, token)
+			}
+
+			if !reflect.DeepEqual(permissions, test.wantPermissions) {
+				t.Errorf("want permissions %v; got %v", test.wantPermissions, permissions)
+			}
+		})
+	}
+
+	// the user of the unknown permission isn't created
+	counts, err := srv.CountUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if counts.Total != 3 || counts.Activated != 1 {
+		t.Errorf("want 3 users, 1 activated; got %+v", counts)
+	}
+}
+
+func TestAdminActivateUser(t *testing.T) {
+
+	ctx := context.Background()
+	srv, user, tokens := newTestAdminService(t)
+
+	token, err := NewTokenService(tokens).CreateNew(ctx, user.ID, time.Hour, data.TokenScopeActivation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// activating an active user again is harmless
+	for i := 0; i < 2; i++ {
+		activated, err := srv.ActivateUser(ctx, "Alice@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !activated.Activated || activated.Version != 2 {
+			t.Errorf("want activated user at version 2; got %+v", activated)
+		}
+	}
+
+	counts, err := srv.CountTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if counts.Active[token.Scope] != 0 {
+		t.Errorf("want the activation token to be deleted; got %d", counts.Active[token.Scope])
+	}
+
+	if _, err := srv.ActivateUser(ctx, "bob@example.com"); !errors.Is(err, data.ErrRecordNotFound) {
+		t.Errorf("want error %v; got %v", data.ErrRecordNotFound, err)
+	}
+}
+
+func TestAdminPermissions(t *testing.T) {
+
+	ctx := context.Background()
+	srv, _, _ := newTestAdminService(t)
+
+	tests := []struct {
+		name    string
+		change  func(ctx context.Context, email string, codes ...string) (data.Permissions, error)
+		email   string
+		codes   []string
+		want    data.Permissions
+		wantErr error
+	}{
+		{name: "Grant", change: srv.GrantPermissions, email: "alice@example.com", codes: []string{"movies:read", "movies:write"}, want: data.Permissions{"movies:read", "movies:write"}},
+		{name: "Revoke", change: srv.RevokePermissions, email: "alice@example.com", codes: []string{"movies:write"}, want: data.Permissions{"movies:read"}},
+		{name: "Unknown permission", change: srv.GrantPermissions, email: "alice@example.com", codes: []string{"movies:delete"}, wantErr: ErrUnknownPermission},
+		{name: "Unknown user", change: srv.GrantPermissions, email: "bob@example.com", codes: []string{"movies:read"}, wantErr: data.ErrRecordNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			permissions, err := test.change(ctx, test.email, test.codes...)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("want error %v; got %v", test.wantErr, err)
+			}
+
+			if !reflect.DeepEqual(permissions, test.want) {
+				t.Errorf("want permissions %v; got %v", test.want, permissions)
+			}
+		})
+	}
+}
+
+func TestAdminTokens(t *testing.T) {
+
+	ctx := context.Background()
+	srv, user, tokens := newTestAdminService(t)
+
+	for _, ttl := range []time.Duration{time.Hour, -time.Hour} {
+		for _, scope := range []string{data.TokenScopeActivation, data.TokenScopeAuthentication} {
+			if _, err := NewTokenService(tokens).CreateNew(ctx, user.ID, ttl, scope); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	deleted, err := srv.PurgeExpiredTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted != 2 {
+		t.Errorf("want 2 expired tokens deleted; got %d", deleted)
+	}
+
+	if err := srv.RevokeTokens(ctx, user.Email, data.TokenScopeAuthentication); err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := srv.CountTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := entities.TokenCounts{Active: map[string]int{data.TokenScopeActivation: 1}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("want token counts %+v; got %+v", want, counts)
+	}
+
+	if err := srv.RevokeTokens(ctx, "bob@example.com", data.TokenScopeActivation); !errors.Is(err, data.ErrRecordNotFound) {
+		t.Errorf("want error %v; got %v", data.ErrRecordNotFound, err)
+	}
+}
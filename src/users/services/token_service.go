@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base32"
@@ -12,8 +13,8 @@ import (
 )
 
 type TokenService interface {
-	CreateNew(userId custom_type.ID, ttl time.Duration, scope string) (*entities.Token, error)
-	DeleteByUserIdAndScope(userId custom_type.ID, scope string) error
+	CreateNew(ctx context.Context, userId custom_type.ID, ttl time.Duration, scope string) (*entities.Token, error)
+	DeleteByUserIdAndScope(ctx context.Context, userId custom_type.ID, scope string) error
 }
 
 type tokenService struct {
@@ -24,19 +25,19 @@ func NewTokenService(tokenRepository repositories.TokenRepository) TokenService
 	return &tokenService{repo: tokenRepository}
 }
 
-func (tsrv tokenService) CreateNew(userId custom_type.ID, ttl time.Duration, scope string) (*entities.Token, error) {
+func (tsrv tokenService) CreateNew(ctx context.Context, userId custom_type.ID, ttl time.Duration, scope string) (*entities.Token, error) {
 	token, err := generateToken(userId, ttl, scope)
 	if err != nil {
 		return nil, err
 	}
 
-	err = tsrv.repo.Create(token)
+	err = tsrv.repo.Create(ctx, token)
 
 	return token, err
 }
 
-func (tsrv tokenService) DeleteByUserIdAndScope(userId custom_type.ID, scope string) error {
-	return tsrv.repo.DeleteAllForUserByScope(scope, userId)
+func (tsrv tokenService) DeleteByUserIdAndScope(ctx context.Context, userId custom_type.ID, scope string) error {
+	return tsrv.repo.DeleteAllForUserByScope(ctx, scope, userId)
 }
 
 func generateToken(userId custom_type.ID, ttl time.Duration, scope string) (*entities.Token, error) {
@@ -1,24 +1,32 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"github.com/terdia/greenlight/infrastructures/dto"
 	"github.com/terdia/greenlight/internal/data"
 	"github.com/terdia/greenlight/internal/mailer"
+	"github.com/terdia/greenlight/internal/unitofwork"
 	"github.com/terdia/greenlight/internal/validator"
 	"github.com/terdia/greenlight/src/users/entities"
 	"github.com/terdia/greenlight/src/users/repositories"
 )
 
-type UserValidationErrors map[string]string
+type UserValidationErrors = validator.Errors
+
+// ActivationTokenTTL is how long the activation token sent to a new user stays valid.
+const ActivationTokenTTL = 3 * 24 * time.Hour
+
+// defaultPermissions are granted to every new user.
+var defaultPermissions = []string{"movies:read"}
 
 type UserService interface {
-	Create(request dto.CreateUserRequest) (*entities.User, UserValidationErrors, error)
+	Create(ctx context.Context, request dto.CreateUserRequest) (*entities.User, *entities.Token, UserValidationErrors, error)
 	SendMail(recipient, templateFile string, data interface{}) error
-	ActivateUser(request dto.ActivateUserRequest) (*entities.User, UserValidationErrors, error)
-	CreateAuthenticationToken(request dto.AuthTokenRequest, scope string) (*entities.Token, UserValidationErrors, error)
+	ActivateUser(ctx context.Context, request dto.ActivateUserRequest) (*entities.User, UserValidationErrors, error)
+	CreateAuthenticationToken(ctx context.Context, request dto.AuthTokenRequest, scope string) (*entities.Token, UserValidationErrors, error)
 }
 
 type userService struct {
@@ -26,6 +34,7 @@ type userService struct {
 	passHashService PasswordHashService
 	mailer          mailer.Mailer
 	tokenService    TokenService
+	uow             unitofwork.UnitOfWork
 }
 
 func NewUserService(
@@ -33,20 +42,57 @@ func NewUserService(
 	passHashService PasswordHashService,
 	mailer mailer.Mailer,
 	tokenService TokenService,
+	uow unitofwork.UnitOfWork,
 ) UserService {
 	return &userService{
 		repo:            repo,
 		passHashService: passHashService,
 		mailer:          mailer,
 		tokenService:    tokenService,
+		uow:             uow,
+	}
+}
+
+// Create signs up a new user with the default permissions and an activation token, all of
+// them are saved in one unit of work so a failure never leaves a half-created account.
+func (srv *userService) Create(ctx context.Context, request dto.CreateUserRequest) (*entities.User, *entities.Token, UserValidationErrors, error) {
+
+	user, errs, err := newUser(request, srv.passHashService)
+	if err != nil || errs != nil {
+		return nil, nil, errs, err
+	}
+
+	var token *entities.Token
+
+	err = srv.uow.Do(ctx, func(repos unitofwork.Repositories) error {
+		token, err = insertUser(ctx, repos, user)
+		return err
+	})
+
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateEmail) {
+			return nil, nil, duplicateEmailErrors(), nil
+		}
+
+		return nil, nil, nil, err
 	}
+
+	return user, token, nil, nil
 }
 
-func (srv *userService) Create(request dto.CreateUserRequest) (*entities.User, UserValidationErrors, error) {
+// newUser validates the signup request and returns the user it signs up, with the password
+// hashed.
+func newUser(request dto.CreateUserRequest, passHashService PasswordHashService) (*entities.User, UserValidationErrors, error) {
+
+	v := validator.New()
+
+	if v.Struct(request); !v.Valid() {
+		return nil, v.Errors, nil
+	}
 
 	password := entities.Password{PlainText: &request.Password}
 
-	err := srv.passHashService.Hash(&password)
+	err := passHashService.Hash(&password)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -58,31 +104,44 @@ func (srv *userService) Create(request dto.CreateUserRequest) (*entities.User, U
 		Activated: false,
 	}
 
-	v := validator.New()
-	user.ValidateRequest(v)
+	return user, nil, nil
+}
 
-	if !v.Valid() {
-		return nil, v.Errors, nil
-	}
+// insertUser saves a new user with the default permissions, and an activation token unless the
+// user is already activated. It returns data.ErrDuplicateEmail when the email is taken.
+func insertUser(ctx context.Context, repos unitofwork.Repositories, user *entities.User) (*entities.Token, error) {
 
-	duplicateEmail, err := srv.repo.GetByEmail(request.Email)
-	if err != nil {
-		if !errors.Is(err, data.ErrRecordNotFound) {
-			return nil, nil, err
-		}
+	duplicateEmail, err := repos.Users.GetByEmail(ctx, user.Email)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		return nil, err
 	}
 
 	if duplicateEmail != nil {
-		v.AddError("email", data.ErrDuplicateEmail.Error())
-		return nil, v.Errors, nil
+		return nil, data.ErrDuplicateEmail
 	}
 
-	err = srv.repo.Insert(user)
-	if err != nil {
-		return nil, nil, err
+	if err := repos.Users.Insert(ctx, user); err != nil {
+		return nil, err
 	}
 
-	return user, nil, nil
+	if err := repos.Permissions.AddForUser(ctx, user.ID, defaultPermissions...); err != nil {
+		return nil, err
+	}
+
+	if user.Activated {
+		return nil, nil
+	}
+
+	return NewTokenService(repos.Tokens).CreateNew(ctx, user.ID, ActivationTokenTTL, data.TokenScopeActivation)
+}
+
+// duplicateEmailErrors returns the validation errors of a signup with an email already taken.
+func duplicateEmailErrors() UserValidationErrors {
+
+	v := validator.New()
+	v.AddError("email", data.ErrDuplicateEmail.Error())
+
+	return v.Errors
 }
 
 func (srv *userService) SendMail(recipient, templateFile string, data interface{}) error {
@@ -90,15 +149,35 @@ func (srv *userService) SendMail(recipient, templateFile string, data interface{
 	return srv.mailer.Send(recipient, templateFile, data)
 }
 
-func (srv *userService) ActivateUser(request dto.ActivateUserRequest) (*entities.User, UserValidationErrors, error) {
+func (srv *userService) ActivateUser(ctx context.Context, request dto.ActivateUserRequest) (*entities.User, UserValidationErrors, error) {
 
 	v := validator.New()
 
-	if validateTokenRequest(v, request.TokenPlaintext); !v.Valid() {
+	if v.Struct(request); !v.Valid() {
 		return nil, v.Errors, nil
 	}
 
-	user, err := srv.repo.GetForToken(request.TokenPlaintext, data.TokenScopeActivation)
+	var user *entities.User
+
+	// the user is activated and its activation tokens deleted together, so a token can't
+	// be used twice
+	err := srv.uow.Do(ctx, func(repos unitofwork.Repositories) error {
+
+		var err error
+
+		user, err = repos.Users.GetForToken(ctx, request.TokenPlaintext, data.TokenScopeActivation)
+		if err != nil {
+			return err
+		}
+
+		user.Activated = true
+
+		if err := repos.Users.Update(ctx, user); err != nil {
+			return err
+		}
+
+		return repos.Tokens.DeleteAllForUserByScope(ctx, data.TokenScopeActivation, user.ID)
+	})
 
 	if err != nil {
 		switch {
@@ -110,31 +189,22 @@ func (srv *userService) ActivateUser(request dto.ActivateUserRequest) (*entities
 		}
 	}
 
-	user.Activated = true
-
-	err = srv.repo.Update(user)
-	if err != nil {
-		return nil, nil, err
-	}
-
 	return user, nil, nil
 }
 
 func (srv *userService) CreateAuthenticationToken(
+	ctx context.Context,
 	request dto.AuthTokenRequest,
 	scope string,
 ) (*entities.Token, UserValidationErrors, error) {
 
 	v := validator.New()
 
-	entities.ValidateEmail(v, request.Email)
-	entities.ValidatePasswordPlaintext(v, request.Password)
-
-	if !v.Valid() {
+	if v.Struct(request); !v.Valid() {
 		return nil, v.Errors, nil
 	}
 
-	user, err := srv.repo.GetByEmail(request.Email)
+	user, err := srv.repo.GetByEmail(ctx, request.Email)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -148,12 +218,7 @@ func (srv *userService) CreateAuthenticationToken(
 		return nil, nil, data.ErrInvalidCredentials
 	}
 
-	token, err := srv.tokenService.CreateNew(user.ID, 24*time.Hour, data.TokenScopeAuthentication)
+	token, err := srv.tokenService.CreateNew(ctx, user.ID, 24*time.Hour, data.TokenScopeAuthentication)
 
 	return token, nil, err
 }
-
-func validateTokenRequest(v *validator.Validator, plainText string) {
-	v.Check(plainText != "", "token", "must be provided")
-	v.Check(len(plainText) == 26, "token", "must be 26 bytes long")
-}